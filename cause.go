@@ -0,0 +1,10 @@
+//go:build go1.20
+// +build go1.20
+
+package check
+
+import "context"
+
+func init() {
+	contextCause = context.Cause
+}
@@ -0,0 +1,18 @@
+//go:build go1.20
+// +build go1.20
+
+package check_test
+
+import (
+	"context"
+	"errors"
+	"github.com/masukomi/check"
+)
+
+func (s *CheckersS) TestHasCauseWithCause(c *check.C) {
+	errShutdown := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errShutdown)
+	testCheck(c, check.HasCause(check.ErrorIs), true, "", ctx, errShutdown)
+	testCheck(c, check.HasCause(check.ErrorIs), false, "", ctx, context.Canceled)
+}
@@ -18,6 +18,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -41,6 +42,7 @@ const (
 	panickedSt
 	fixturePanickedSt
 	missedSt
+	quarantinedSt
 )
 
 type funcStatus int
@@ -67,6 +69,20 @@ func (method *methodType) suiteName() string {
 	return t.Name()
 }
 
+// suiteName returns the name of the type of the given suite value.
+func suiteName(suite interface{}) string {
+	return suiteType(suite).Name()
+}
+
+// suiteType returns the type of suite, dereferencing it if it's a pointer.
+func suiteType(suite interface{}) reflect.Type {
+	t := reflect.TypeOf(suite)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
 func (method *methodType) String() string {
 	return method.suiteName() + "." + method.Info.Name
 }
@@ -78,21 +94,37 @@ func (method *methodType) matches(re *regexp.Regexp) bool {
 }
 
 type C struct {
-	method    *methodType
-	kind      funcKind
-	testName  string
-	status    funcStatus
-	logb      *logger
-	logw      io.Writer
-	done      chan *C
-	reason    string
-	mustFail  bool
-	tempDir   *tempDir
-	benchMem  bool
-	startTime time.Time
+	method      *methodType
+	kind        funcKind
+	testName    string
+	status      funcStatus
+	logb        *logger
+	logw        io.Writer
+	done        chan *C
+	reason      string
+	mustFail    bool
+	quarantined bool
+	tempDir     *tempDir
+	shared      *suiteValues
+	cleanups    cleanups
+	cleanupsTo  *cleanups // Where the cleanups go once done, rather than run
+	meta        metadata
+	helpers     sync.Map // Names of functions marked with Helper
+	benchMem    bool
+	startTime   time.Time
+	stopTime    time.Time
 	timer
 }
 
+// elapsed returns how long the call took, or has taken so far if it's
+// still running.
+func (c *C) elapsed() time.Duration {
+	if c.stopTime.IsZero() {
+		return time.Since(c.startTime)
+	}
+	return c.stopTime.Sub(c.startTime)
+}
+
 func (c *C) stopNow() {
 	runtime.Goexit()
 }
@@ -121,6 +153,14 @@ func (l *logger) String() string {
 	return l.writer.String()
 }
 
+// reset replaces the logged content with s.
+func (l *logger) reset(s string) {
+	l.Lock()
+	defer l.Unlock()
+	l.writer.Reset()
+	l.writer.WriteString(s)
+}
+
 // -----------------------------------------------------------------------
 // Handling of temporary files and directories.
 
@@ -172,6 +212,162 @@ func (c *C) MkDir() string {
 	return path
 }
 
+// -----------------------------------------------------------------------
+// Handling of functions run once a call is done.
+
+type cleanups struct {
+	sync.Mutex
+	funcs []func()
+}
+
+// Cleanup registers f to be run once the current test or fixture method
+// is done, whether it succeeded, failed, panicked or was stopped by
+// FailNow, Fatal or a failed Assert. The functions registered for a test
+// run after its TearDownTest, in the reverse order of their registration,
+// like those deferred. They may use c to check values and log, but a
+// panic in one of them marks the call as panicked.
+//
+// Functions registered by SetUpTest are rather run once the test is done,
+// after those registered by the test itself, and functions registered by
+// SetUpSuite along with the TearDownSuite of the suite, after those it
+// registers. Without a TearDownSuite, they run in a call of their own,
+// reported as SetUpSuite, once all the tests of the suite are done.
+//
+// For example:
+//
+//     server := httptest.NewServer(handler)
+//     c.Cleanup(server.Close)
+//
+func (c *C) Cleanup(f func()) {
+	c.addCleanup(f)
+}
+
+// addCleanup registers f to be run once the current call is done.
+func (c *C) addCleanup(f func()) {
+	c.addCleanups([]func(){f})
+}
+
+// addCleanups registers funcs to be run once the current call is done.
+func (c *C) addCleanups(funcs []func()) {
+	c.cleanups.Lock()
+	c.cleanups.funcs = append(c.cleanups.funcs, funcs...)
+	c.cleanups.Unlock()
+}
+
+// handOverCleanups moves the cleanup functions registered on c to the end
+// of c.cleanupsTo, so that they run before those registered there earlier.
+func (c *C) handOverCleanups() {
+	c.cleanups.Lock()
+	funcs := c.cleanups.funcs
+	c.cleanups.funcs = nil
+	c.cleanups.Unlock()
+	c.cleanupsTo.Lock()
+	c.cleanupsTo.funcs = append(c.cleanupsTo.funcs, funcs...)
+	c.cleanupsTo.Unlock()
+}
+
+// runCleanups runs the cleanup functions registered on c in the reverse
+// order of their registration. Panics are handed to the OnPanic hook,
+// logged and mark the call as panicked, without preventing the remaining
+// functions from running. Each function
+// runs in a goroutine of its own, so that those which stop with FailNow
+// don't stop the others, nor the call from being reported as done.
+func (runner *suiteRunner) runCleanups(c *C) {
+	c.cleanups.Lock()
+	funcs := c.cleanups.funcs
+	c.cleanups.funcs = nil
+	c.cleanups.Unlock()
+	for i := len(funcs) - 1; i >= 0; i-- {
+		done := make(chan bool)
+		go func(f func()) {
+			defer close(done)
+			defer func() {
+				if value := recover(); value != nil {
+					runner.reportPanic(c, value, debug.Stack())
+					c.logPanic(1, value)
+					c.status = panickedSt
+				}
+			}()
+			f()
+		}(funcs[i])
+		<-done
+	}
+}
+
+// -----------------------------------------------------------------------
+// Handling of metadata attached to a call.
+
+type metadata struct {
+	sync.Mutex
+	values map[string]string
+}
+
+// metadata returns a copy of the metadata set with SetMeta, or nil if
+// none was set.
+func (c *C) metadata() map[string]string {
+	c.meta.Lock()
+	defer c.meta.Unlock()
+	if len(c.meta.values) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(c.meta.values))
+	for key, value := range c.meta.values {
+		values[key] = value
+	}
+	return values
+}
+
+// -----------------------------------------------------------------------
+// Handling of values shared by all the calls of a suite.
+
+type suiteValues struct {
+	sync.Mutex
+	values map[string]*suiteValue
+}
+
+type suiteValue struct {
+	once     sync.Once
+	value    interface{}
+	panicked bool
+}
+
+func (sv *suiteValues) get(key string, factory func() interface{}) interface{} {
+	sv.Lock()
+	if sv.values == nil {
+		sv.values = make(map[string]*suiteValue)
+	}
+	v, ok := sv.values[key]
+	if !ok {
+		v = &suiteValue{}
+		sv.values[key] = v
+	}
+	sv.Unlock()
+	v.once.Do(func() {
+		v.panicked = true
+		v.value = factory()
+		v.panicked = false
+	})
+	if v.panicked {
+		panic(fmt.Sprintf("factory of suite value %q has panicked before", key))
+	}
+	return v.value
+}
+
+func (sv *suiteValues) clear() {
+	sv.Lock()
+	sv.values = nil
+	sv.Unlock()
+}
+
+// SuiteValue returns the value stored under key for the running suite,
+// calling factory to build it the first time it is requested. The factory
+// runs at most once per key, even when the suite's tests run concurrently,
+// and every call of the suite gets the same value back. Values are
+// discarded once TearDownSuite has run.
+func (c *C) SuiteValue(key string, factory func() interface{}) interface{} {
+	return c.shared.get(key, factory)
+}
+
 // -----------------------------------------------------------------------
 // Low-level logging functions.
 
@@ -205,13 +401,19 @@ func hasStringOrError(x interface{}) (ok bool) {
 
 func (c *C) logValue(label string, value interface{}) {
 	if label == "" {
-		if hasStringOrError(value) {
+		if s, ok := formatValue(reflect.ValueOf(value)); ok {
+			c.logf("... %s", s)
+		} else if hasStringOrError(value) {
 			c.logf("... %#v (%q)", value, value)
 		} else {
 			c.logf("... %#v", value)
 		}
 	} else if value == nil {
 		c.logf("... %s = nil", label)
+	} else if e, ok := value.(elidedValue); ok {
+		c.logf("... %s %s = (see difference)", label, e.typ)
+	} else if s, ok := formatValue(reflect.ValueOf(value)); ok {
+		c.logf("... %s %s = %s", label, reflect.TypeOf(value), s)
 	} else {
 		if hasStringOrError(value) {
 			fv := fmt.Sprintf("%#v", value)
@@ -264,35 +466,53 @@ func (c *C) logString(issue string) {
 }
 
 func (c *C) logCaller(skip int) {
-	// This is a bit heavier than it ought to be.
-	skip += 1 // Our own frame.
-	pc, callerFile, callerLine, ok := runtime.Caller(skip)
-	if !ok {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs) // Skip runtime.Callers and our own frame.
+	if n == 0 {
 		return
 	}
+	// The caller is the first frame which is neither part of this package
+	// nor marked with Helper. Note that the test line may be different on
+	// distinct calls for the same test. Showing the "internal" line is
+	// helpful when debugging.
+	testFunc := runtime.FuncForPC(c.method.PC()).Name()
+	var caller runtime.Frame
 	var testFile string
 	var testLine int
-	testFunc := runtime.FuncForPC(c.method.PC())
-	if runtime.FuncForPC(pc) != testFunc {
-		for {
-			skip += 1
-			if pc, file, line, ok := runtime.Caller(skip); ok {
-				// Note that the test line may be different on
-				// distinct calls for the same test.  Showing
-				// the "internal" line is helpful when debugging.
-				if runtime.FuncForPC(pc) == testFunc {
-					testFile, testLine = file, line
-					break
-				}
-			} else {
-				break
-			}
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if caller.PC == 0 && !c.isHelperFrame(frame) {
+			caller = frame
 		}
+		if caller.PC != 0 && frame.Function == testFunc {
+			testFile, testLine = frame.File, frame.Line
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	if caller.PC == 0 {
+		return
 	}
-	if testFile != "" && (testFile != callerFile || testLine != callerLine) {
+	if testFile != "" && (testFile != caller.File || testLine != caller.Line) {
 		c.logCode(testFile, testLine)
 	}
-	c.logCode(callerFile, callerLine)
+	c.logCode(caller.File, caller.Line)
+}
+
+var packagePrefix = reflect.TypeOf(C{}).PkgPath() + "."
+
+// isHelperFrame returns whether frame belongs to the implementation of this
+// package, or to a function marked with Helper, and so should not be
+// reported as the location of a problem.
+func (c *C) isHelperFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, packagePrefix) && !strings.HasSuffix(frame.File, "_test.go") {
+		return true
+	}
+	_, ok := c.helpers.Load(frame.Function)
+	return ok
 }
 
 func (c *C) logCode(path string, line int) {
@@ -430,6 +650,9 @@ type Result struct {
 	FixturePanicked  int
 	ExpectedFailures int
 	Missed           int    // Not even tried to run, related to a panic in the fixture.
+	DeadlineMissed   int    // Missed because the run deadline was exceeded.
+	Quarantined      int    // Failed, but marked with C.Quarantine.
+	QuarantinePassed int    // Marked with C.Quarantine, but passed.
 	RunError         error  // Houston, we've got a problem.
 	WorkDir          string // If KeepWorkDir is true
 }
@@ -482,6 +705,8 @@ func (tracker *resultTracker) _loopRoutine() {
 					if c.kind == testKd {
 						if c.mustFail {
 							tracker.result.ExpectedFailures++
+						} else if c.quarantined {
+							tracker.result.QuarantinePassed++
 						} else {
 							tracker.result.Succeeded++
 						}
@@ -500,10 +725,15 @@ func (tracker *resultTracker) _loopRoutine() {
 					tracker.result.Missed++
 				case missedSt:
 					tracker.result.Missed++
+					if c.reason == deadlineExceededReason {
+						tracker.result.DeadlineMissed++
+					}
 				case skippedSt:
 					if c.kind == testKd {
 						tracker.result.Skipped++
 					}
+				case quarantinedSt:
+					tracker.result.Quarantined++
 				}
 			}
 		} else {
@@ -538,21 +768,102 @@ type suiteRunner struct {
 	concurrent                bool
 	concurrencyLevel          int
 	concurrencyBucket         *concurrencyBucket
+	onPanic                   func(info PanicInfo)
+	onTestResult              func(TestResult)
+	testResultMutex           *sync.Mutex // Serializes the calls to onTestResult within a run
+	deadline                  <-chan struct{}
+	maxRunTime                time.Duration
+	suiteValues               *suiteValues
+	discovered                int // Test methods found before filtering
+	verbose                   bool
+	benchmark                 bool
+	warnEmpty                 bool
+	strictEmpty               bool
+	order                     *testOrder
+	suiteCleanups             cleanups // Registered by SetUpSuite, run by TearDownSuite
 }
 
 type RunConf struct {
-	Output           io.Writer
-	Stream           bool
-	Verbose          bool
-	Filter           string
-	Benchmark        bool
-	BenchmarkTime    time.Duration // Defaults to 1 second
-	BenchmarkMem     bool
-	KeepWorkDir      bool
-	ConcurrencyLevel int
-	Writer           outputWriter
+	Output            io.Writer
+	Stream            bool
+	Verbose           bool
+	Filter            string
+	Benchmark         bool
+	BenchmarkTime     time.Duration // Defaults to 1 second
+	BenchmarkMem      bool
+	KeepWorkDir       bool
+	ConcurrencyLevel  int
+	Writer            outputWriter
+	OnPanic           func(info PanicInfo) // Called when a test or fixture panics
+	VerboseOnFailure  bool                 // Verbose output only for failing suites; ignored with Stream
+	MaxRunTime        time.Duration        // Tests not started within this time are missed
+	DedupOutput       bool                 // Collapse repeated log records; ignored with Stream
+	NoWarnEmptySuites bool                 // Don't warn about suites which contribute no tests
+	StrictEmpty       bool                 // Fail the run on suites with no test methods at all
+	ShuffleSuites     bool                 // Dispatch concurrent suites in random order
+	Seed              int64                // Seed for ShuffleSuites; zero picks one from the clock
+	FailuresAtEnd     bool                 // Repeat all problems after the summary; ignored with Stream
+	StreamXunit       bool                 // Write xunit reports suite by suite as they finish
+	OrderFile         string               // Write the names of the tests run into this file, in order
+	OrderFrom         string               // Run the tests named in this file serially, in its order
+	AllureDir         string               // Directory for the allure reporter; defaults to allure-results
+	Color             bool                 // Colorize the labels of the plain reporter
+	Slowest           int                  // List this many slowest tests after the run
+	OnTestResult      func(TestResult)     // Called as each test finishes
+	deadline          chan struct{}        // Closed once MaxRunTime has elapsed
+	testResultMutex   *sync.Mutex          // Serializes the calls to OnTestResult of all the suites
+	order             *testOrder           // Records the order of tests for OrderFile
+	colorMode         string               // The check.color mode deciding Color per reporter target
+}
+
+// startDeadline returns a channel which is closed once maxRunTime has
+// elapsed. The returned function releases the timer.
+func startDeadline(maxRunTime time.Duration) (deadline chan struct{}, stop func()) {
+	deadline = make(chan struct{})
+	timer := time.AfterFunc(maxRunTime, func() { close(deadline) })
+	return deadline, func() { timer.Stop() }
 }
 
+// PanicInfo describes a panic recovered while running a test or fixture
+// method. It is handed to RunConf.OnPanic before the result of the call
+// is recorded, so that panics may be forwarded to external systems.
+// The hook may be called concurrently when running concurrent suites.
+type PanicInfo struct {
+	Suite string      // Name of the suite type, e.g. "MySuite"
+	Test  string      // Name of the method, e.g. "MySuite.TestFoo"
+	Value interface{} // The value the method panicked with
+	Stack []byte      // Stack trace of the panicking goroutine
+}
+
+// TestResult describes the outcome of a single test. It is handed to
+// RunConf.OnTestResult once the test has finished and was reported.
+// Calls to the hook are serialized within a run, even across concurrent
+// suites.
+type TestResult struct {
+	Suite    string        // Name of the suite type, e.g. "MySuite"
+	Name     string        // Name of the test, e.g. "MySuite.TestFoo"
+	Status   string        // See below
+	Duration time.Duration // Time spent running the test
+	Reason   string        // Reason given to Skip, ExpectFailure or Quarantine
+	Message  string        // Log of the test, unless it passed
+
+	// Meta holds the metadata set with SetMeta, or is nil if there's none.
+	Meta map[string]string
+}
+
+// The values of TestResult.Status.
+const (
+	TestPassed           = "passed"
+	TestFailed           = "failed"
+	TestPanicked         = "panicked"
+	TestSkipped          = "skipped"
+	TestMissed           = "missed"
+	TestFixturePanicked  = "fixture panicked"
+	TestExpectedFailure  = "expected failure"
+	TestQuarantined      = "quarantined"
+	TestQuarantinePassed = "quarantine passed"
+)
+
 type concurrencyBucket struct {
 	size int
 	ch   chan struct{}
@@ -590,9 +901,8 @@ func newSuiteRunner(suite interface{}, runConf *RunConf, concurrent bool, bucket
 	if conf.ConcurrencyLevel < 1 {
 		conf.ConcurrencyLevel = 1
 	}
-
 	if conf.Writer == nil {
-		conf.Writer = newPlainWriter(conf.Output, conf.Verbose, conf.Stream)
+		conf.Writer = plainWriterFor(conf.Output, &conf)
 	}
 
 	suiteType := reflect.TypeOf(suite)
@@ -606,11 +916,25 @@ func newSuiteRunner(suite interface{}, runConf *RunConf, concurrent bool, bucket
 		benchTime:         conf.BenchmarkTime,
 		benchMem:          conf.BenchmarkMem,
 		tempDir:           &tempDir{},
+		suiteValues:       &suiteValues{},
 		keepDir:           conf.KeepWorkDir,
 		tests:             make([]*methodType, 0, suiteNumMethods),
 		concurrent:        concurrent,
 		concurrencyLevel:  conf.ConcurrencyLevel,
 		concurrencyBucket: bucket,
+		onPanic:           conf.OnPanic,
+		onTestResult:      conf.OnTestResult,
+		deadline:          conf.deadline,
+		maxRunTime:        conf.MaxRunTime,
+		verbose:           conf.Verbose,
+		benchmark:         conf.Benchmark,
+		warnEmpty:         !conf.NoWarnEmptySuites,
+		strictEmpty:       conf.StrictEmpty,
+		order:             conf.order,
+		testResultMutex:   conf.testResultMutex,
+	}
+	if runner.testResultMutex == nil {
+		runner.testResultMutex = &sync.Mutex{}
 	}
 	if runner.benchTime == 0 {
 		runner.benchTime = 1 * time.Second
@@ -646,6 +970,7 @@ func newSuiteRunner(suite interface{}, runConf *RunConf, concurrent bool, bucket
 			if !strings.HasPrefix(method.Info.Name, prefix) {
 				continue
 			}
+			runner.discovered++
 			if filterRegexp == nil || method.matches(filterRegexp) {
 				runner.tests = append(runner.tests, method)
 			}
@@ -656,16 +981,28 @@ func newSuiteRunner(suite interface{}, runConf *RunConf, concurrent bool, bucket
 
 // Run all methods in the given suite.
 func (runner *suiteRunner) run() *Result {
+	if runner.maxRunTime > 0 && runner.deadline == nil {
+		// Not part of a RunAll call, so the budget is for this suite alone.
+		deadline, stop := startDeadline(runner.maxRunTime)
+		defer stop()
+		runner.deadline = deadline
+	}
+	runner.checkEmpty()
 	if runner.tracker.result.RunError == nil && len(runner.tests) > 0 {
 		runner.tracker.start()
-		if runner.checkFixtureArgs() {
-			c := runner.runFixture(runner.setUpSuite, "", nil)
+		if runner.deadlineExceeded() {
+			runner.missDeadline(runner.tests)
+		} else if runner.checkFixtureArgs() {
+			c := runner.runFixture(runner.setUpSuite, "", nil, &runner.suiteCleanups)
 			if c == nil || c.status == succeededSt {
 				if runner.concurrent {
 					var wg sync.WaitGroup
-					wg.Add(len(runner.tests))
-					for _, t := range runner.tests {
-						<-runner.concurrencyBucket.ch
+					for i, t := range runner.tests {
+						if !runner.acquireSlot() {
+							runner.missDeadline(runner.tests[i:])
+							break
+						}
+						wg.Add(1)
 						go func(t *methodType) {
 							runner.runTest(t)
 							runner.concurrencyBucket.ch <- struct{}{}
@@ -675,6 +1012,10 @@ func (runner *suiteRunner) run() *Result {
 					wg.Wait()
 				} else {
 					for i, t := range runner.tests {
+						if runner.deadlineExceeded() {
+							runner.missDeadline(runner.tests[i:])
+							break
+						}
 						c := runner.runTest(t)
 						if c.status == fixturePanickedSt {
 							runner.skipTests(missedSt, runner.tests[i+1:])
@@ -687,11 +1028,15 @@ func (runner *suiteRunner) run() *Result {
 			} else {
 				runner.skipTests(missedSt, runner.tests)
 			}
-			runner.runFixture(runner.tearDownSuite, "", nil)
+			runner.tearDownSuiteWithCleanups()
+			runner.suiteValues.clear()
 		} else {
 			runner.skipTests(missedSt, runner.tests)
 		}
 		runner.tracker.waitAndStop()
+		if w, ok := runner.output.(suiteWriter); ok {
+			w.WriteSuiteDone(suiteName(runner.suite))
+		}
 		if runner.keepDir {
 			runner.tracker.result.WorkDir = runner.tempDir.path
 		} else {
@@ -718,6 +1063,7 @@ func (runner *suiteRunner) forkCall(method *methodType, kind funcKind, testName
 		logb:      logb,
 		logw:      logw,
 		tempDir:   runner.tempDir,
+		shared:    runner.suiteValues,
 		done:      make(chan *C, 1),
 		timer:     timer{benchTime: runner.benchTime},
 		startTime: time.Now(),
@@ -753,10 +1099,16 @@ func (runner *suiteRunner) callDone(c *C) {
 				c.status = fixturePanickedSt
 			}
 		default:
+			runner.reportPanic(c, value, debug.Stack())
 			c.logPanic(1, value)
 			c.status = panickedSt
 		}
 	}
+	if c.cleanupsTo != nil {
+		c.handOverCleanups()
+	} else {
+		runner.runCleanups(c)
+	}
 	if c.mustFail {
 		switch c.status {
 		case failedSt:
@@ -767,22 +1119,49 @@ func (runner *suiteRunner) callDone(c *C) {
 			c.logString("Reason: " + c.reason)
 		}
 	}
+	if c.quarantined {
+		switch c.status {
+		case failedSt, panickedSt:
+			c.status = quarantinedSt
+			c.logString("Quarantined: " + c.reason)
+		}
+	}
 
+	c.stopTime = time.Now()
 	runner.reportCallDone(c)
 	c.done <- c
 }
 
+// Hand a recovered panic to the OnPanic hook, if any. Panics within the
+// hook itself are logged and otherwise ignored so that the accounting of
+// the run isn't disturbed.
+func (runner *suiteRunner) reportPanic(c *C, value interface{}, stack []byte) {
+	if runner.onPanic == nil {
+		return
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: OnPanic hook has panicked: %v\n", v)
+		}
+	}()
+	runner.onPanic(PanicInfo{
+		Suite: c.method.suiteName(),
+		Test:  c.method.String(),
+		Value: value,
+		Stack: stack,
+	})
+}
+
 // Runs a fixture call synchronously.  The fixture will still be run in a
 // goroutine like all suite methods, but this method will not return
 // while the fixture goroutine is not done, because the fixture must be
-// run in a desired order.
-func (runner *suiteRunner) runFixture(method *methodType, testName string, logb *logger) *C {
+// run in a desired order.  Unless cleanupsTo is nil, the cleanups the
+// fixture registers are handed over to it rather than run.
+func (runner *suiteRunner) runFixture(method *methodType, testName string, logb *logger, cleanupsTo *cleanups) *C {
 	if method != nil {
 		c := runner.runFunc(method, fixtureKd, testName, logb, func(c *C) {
-			c.ResetTimer()
-			c.StartTimer()
-			defer c.StopTimer()
-			c.method.Call([]reflect.Value{reflect.ValueOf(c)})
+			c.cleanupsTo = cleanupsTo
+			callFixture(c)
 		})
 		return c
 	}
@@ -792,11 +1171,11 @@ func (runner *suiteRunner) runFixture(method *methodType, testName string, logb
 // Run the fixture method with runFixture(), but panic with a fixturePanic{}
 // in case the fixture method panics.  This makes it easier to track the
 // fixture panic together with other call panics within forkTest().
-func (runner *suiteRunner) runFixtureWithPanic(method *methodType, testName string, logb *logger, skipped *bool) *C {
+func (runner *suiteRunner) runFixtureWithPanic(method *methodType, testName string, logb *logger, cleanupsTo *cleanups, skipped *bool) *C {
 	if skipped != nil && *skipped {
 		return nil
 	}
-	c := runner.runFixture(method, testName, logb)
+	c := runner.runFixture(method, testName, logb, cleanupsTo)
 	if c != nil && c.status != succeededSt {
 		if skipped != nil {
 			*skipped = c.status == skippedSt
@@ -806,6 +1185,38 @@ func (runner *suiteRunner) runFixtureWithPanic(method *methodType, testName stri
 	return c
 }
 
+// tearDownSuiteWithCleanups runs TearDownSuite, followed by the cleanups
+// registered by SetUpSuite, in the same call. Without a TearDownSuite,
+// those cleanups run in a call of their own, reported as SetUpSuite.
+func (runner *suiteRunner) tearDownSuiteWithCleanups() {
+	runner.suiteCleanups.Lock()
+	funcs := runner.suiteCleanups.funcs
+	runner.suiteCleanups.funcs = nil
+	runner.suiteCleanups.Unlock()
+	method := runner.tearDownSuite
+	if method == nil {
+		if len(funcs) == 0 {
+			return
+		}
+		method = runner.setUpSuite
+	}
+	runner.runFunc(method, fixtureKd, "", nil, func(c *C) {
+		// Registered first, they run last.
+		c.addCleanups(funcs)
+		if method == runner.tearDownSuite {
+			callFixture(c)
+		}
+	})
+}
+
+// callFixture calls the fixture method of c, timing it.
+func callFixture(c *C) {
+	c.ResetTimer()
+	c.StartTimer()
+	defer c.StopTimer()
+	c.method.Call([]reflect.Value{reflect.ValueOf(c)})
+}
+
 type fixturePanic struct {
 	status funcStatus
 	method *methodType
@@ -817,11 +1228,11 @@ func (runner *suiteRunner) forkTest(method *methodType) *C {
 	testName := method.String()
 	return runner.forkCall(method, testKd, testName, nil, func(c *C) {
 		var skipped bool
-		defer runner.runFixtureWithPanic(runner.tearDownTest, testName, nil, &skipped)
+		defer runner.runFixtureWithPanic(runner.tearDownTest, testName, nil, nil, &skipped)
 		defer c.StopTimer()
 		benchN := 1
 		for {
-			runner.runFixtureWithPanic(runner.setUpTest, testName, c.logb, &skipped)
+			runner.runFixtureWithPanic(runner.setUpTest, testName, c.logb, &c.cleanups, &skipped)
 			mt := c.method.Type()
 			if mt.NumIn() != 1 || mt.In(0) != reflect.TypeOf(c) {
 				// Rather than a plain panic, provide a more helpful message when
@@ -862,7 +1273,7 @@ func (runner *suiteRunner) forkTest(method *methodType) *C {
 			benchN = roundUp(benchN)
 
 			skipped = true // Don't run the deferred one if this panics.
-			runner.runFixtureWithPanic(runner.tearDownTest, testName, nil, nil)
+			runner.runFixtureWithPanic(runner.tearDownTest, testName, nil, nil, nil)
 			skipped = false
 		}
 	})
@@ -870,6 +1281,9 @@ func (runner *suiteRunner) forkTest(method *methodType) *C {
 
 // Same as forkTest(), but wait for the test to finish before returning.
 func (runner *suiteRunner) runTest(method *methodType) *C {
+	if runner.order != nil {
+		runner.order.record(method.String())
+	}
 	c := runner.forkTest(method)
 	<-c.done
 	return c
@@ -886,6 +1300,84 @@ func (runner *suiteRunner) skipTests(status funcStatus, methods []*methodType) {
 	}
 }
 
+// reorderTests replaces the tests of the suite with the named ones, in
+// the given order. Names of tests which the suite doesn't have, or which
+// were filtered out, are warned about and skipped.
+func (runner *suiteRunner) reorderTests(names []string) {
+	byName := make(map[string]*methodType, len(runner.tests))
+	for _, method := range runner.tests {
+		byName[method.String()] = method
+	}
+	tests := make([]*methodType, 0, len(names))
+	for _, name := range names {
+		method, ok := byName[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "WARNING: skipping %s from the replayed order: test not found\n", name)
+			continue
+		}
+		delete(byName, name)
+		tests = append(tests, method)
+	}
+	runner.tests = tests
+}
+
+// checkEmpty reports suites which contribute no tests to the run. Having
+// no test methods at all is likely a mistake, and is warned about or, in
+// strict mode, turned into a run error. Having all of them filtered out is
+// expected, and only noted in verbose mode.
+func (runner *suiteRunner) checkEmpty() {
+	if len(runner.tests) > 0 || runner.tracker.result.RunError != nil || runner.benchmark {
+		return
+	}
+	name := suiteName(runner.suite)
+	if runner.discovered == 0 {
+		if runner.strictEmpty {
+			runner.tracker.result.RunError = fmt.Errorf("suite %s has no test methods", name)
+		} else if runner.warnEmpty {
+			fmt.Fprintf(os.Stderr, "WARNING: suite %s has no test methods\n", name)
+		}
+	} else if runner.warnEmpty && runner.verbose {
+		fmt.Fprintf(os.Stderr, "NOTE: all %d tests of suite %s were filtered out\n", runner.discovered, name)
+	}
+}
+
+const deadlineExceededReason = "run deadline exceeded"
+
+// deadlineExceeded returns whether the MaxRunTime budget is exhausted.
+func (runner *suiteRunner) deadlineExceeded() bool {
+	select {
+	case <-runner.deadline:
+		return true
+	default:
+		return false
+	}
+}
+
+// acquireSlot waits for a free slot in the concurrency bucket, and returns
+// false without holding one if the run deadline is exceeded meanwhile.
+func (runner *suiteRunner) acquireSlot() bool {
+	select {
+	case <-runner.concurrencyBucket.ch:
+		if runner.deadlineExceeded() {
+			runner.concurrencyBucket.ch <- struct{}{}
+			return false
+		}
+		return true
+	case <-runner.deadline:
+		return false
+	}
+}
+
+// missDeadline marks the given tests as missed due to the run deadline.
+func (runner *suiteRunner) missDeadline(methods []*methodType) {
+	for _, method := range methods {
+		runner.runFunc(method, testKd, "", nil, func(c *C) {
+			c.reason = deadlineExceededReason
+			c.status = missedSt
+		})
+	}
+}
+
 // Verify if the fixture arguments are *check.C.  In case of errors,
 // log the error as a panic in the fixture method call, and return false.
 func (runner *suiteRunner) checkFixtureArgs() bool {
@@ -912,10 +1404,17 @@ func (runner *suiteRunner) reportCallStarted(c *C) {
 
 func (runner *suiteRunner) reportCallDone(c *C) {
 	runner.tracker.callDone(c)
+	var result *TestResult
+	if c.kind == testKd && runner.onTestResult != nil {
+		// Built upfront, since writing the output may consume the log.
+		result = newTestResult(c)
+	}
 	switch c.status {
 	case succeededSt:
 		if c.mustFail {
 			runner.output.WriteCallSuccess("FAIL EXPECTED", c)
+		} else if c.quarantined {
+			runner.output.WriteCallSuccess("QUARANTINE PASSED", c)
 		} else {
 			runner.output.WriteCallSuccess("PASS", c)
 		}
@@ -933,5 +1432,61 @@ func (runner *suiteRunner) reportCallDone(c *C) {
 		runner.output.WriteCallError("PANIC", c)
 	case missedSt:
 		runner.output.WriteCallSuccess("MISS", c)
+	case quarantinedSt:
+		runner.output.WriteCallSkipped("QUARANTINED", c)
+	}
+	if result != nil {
+		runner.reportTestResult(*result)
+	}
+}
+
+// Hand the outcome of a test to the OnTestResult hook. As with OnPanic,
+// panics within the hook are logged and otherwise ignored.
+func (runner *suiteRunner) reportTestResult(result TestResult) {
+	runner.testResultMutex.Lock()
+	defer runner.testResultMutex.Unlock()
+	defer func() {
+		if v := recover(); v != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: OnTestResult hook has panicked: %v\n", v)
+		}
+	}()
+	runner.onTestResult(result)
+}
+
+func newTestResult(c *C) *TestResult {
+	result := &TestResult{
+		Suite:    c.method.suiteName(),
+		Name:     c.method.String(),
+		Status:   testStatus(c),
+		Duration: c.duration,
+		Reason:   c.reason,
+		Meta:     c.metadata(),
+	}
+	if c.status != succeededSt {
+		result.Message = c.logb.String()
+	}
+	return result
+}
+
+func testStatus(c *C) string {
+	switch c.status {
+	case succeededSt:
+		if c.mustFail {
+			return TestExpectedFailure
+		} else if c.quarantined {
+			return TestQuarantinePassed
+		}
+		return TestPassed
+	case failedSt:
+		return TestFailed
+	case panickedSt:
+		return TestPanicked
+	case skippedSt:
+		return TestSkipped
+	case fixturePanickedSt:
+		return TestFixturePanicked
+	case quarantinedSt:
+		return TestQuarantined
 	}
+	return TestMissed
 }
@@ -1,10 +1,30 @@
 package check
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
+	"math/big"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // -----------------------------------------------------------------------
@@ -57,6 +77,12 @@ type Checker interface {
 type CheckerInfo struct {
 	Name   string
 	Params []string
+
+	// Negated optionally explains a success of the checker, for when Not
+	// turns it into a failure. Parameters may be referred to by name in
+	// braces, as in "Obtained value is equal to {expected}", to be
+	// replaced by their values.
+	Negated string
 }
 
 func (info *CheckerInfo) Info() *CheckerInfo {
@@ -68,7 +94,10 @@ func (info *CheckerInfo) Info() *CheckerInfo {
 
 // The Not checker inverts the logic of the provided checker.  The
 // resulting checker will succeed where the original one failed, and
-// vice-versa.
+// vice-versa. When it fails, it explains what the original checker found,
+// with the Negated message of its CheckerInfo if it has one. It fails as
+// well when the original checker can't check the values at all, such as
+// HasLen given a number.
 //
 // For example:
 //
@@ -85,13 +114,130 @@ type notChecker struct {
 func (checker *notChecker) Info() *CheckerInfo {
 	info := *checker.sub.Info()
 	info.Name = "Not(" + info.Name + ")"
+	info.Negated = ""
 	return &info
 }
 
 func (checker *notChecker) Check(params []interface{}, names []string) (result bool, error string) {
 	result, error = checker.sub.Check(params, names)
-	result = !result
-	return
+	if !result {
+		if isUsageError(error) {
+			return false, error
+		}
+		// The explanation of why the checker failed doesn't apply anymore.
+		return true, ""
+	}
+	if error != "" {
+		return false, error
+	}
+	info := checker.sub.Info()
+	if info.Negated == "" {
+		return false, fmt.Sprintf("%s succeeded, and it must not", info.Name)
+	}
+	error = info.Negated
+	for i, name := range names {
+		error = strings.Replace(error, "{"+name+"}", goSyntax(params[i]), -1)
+	}
+	return false, error
+}
+
+// usageErrors holds the errors returned by usageError, for Not to tell
+// them apart from explanations of failed checks.
+var usageErrors sync.Map
+
+// usageError returns the error of a checker that can't check the values
+// it was given at all, such as HasLen given a value with no length. Not
+// fails with such errors rather than turning them into successes.
+func usageError(error string) string {
+	usageErrors.Store(error, true)
+	return error
+}
+
+func isUsageError(error string) bool {
+	_, ok := usageErrors.Load(error)
+	return ok
+}
+
+// -----------------------------------------------------------------------
+// And and Or checker combinators.
+
+// The And checker succeeds when all of the provided checkers succeed on
+// the obtained value, and reports the first one which fails otherwise.
+// The arguments after the obtained value are handed out to the checkers
+// in order, as many to each as it takes.
+//
+// For example:
+//
+//     c.Assert(port, And(GreaterThan, LessOrEqual), 0, 65535)
+//
+func And(checkers ...Checker) Checker {
+	return newCombinedChecker("And", checkers)
+}
+
+// The Or checker succeeds when any of the provided checkers succeeds on
+// the obtained value, and reports why each of them failed otherwise.
+// Arguments are handed out to the checkers as with And.
+//
+// For example:
+//
+//     c.Assert(err, Or(IsNil, ErrorIs), os.ErrNotExist)
+//
+func Or(checkers ...Checker) Checker {
+	return newCombinedChecker("Or", checkers)
+}
+
+type combinedChecker struct {
+	info     *CheckerInfo
+	op       string
+	checkers []Checker
+}
+
+func newCombinedChecker(op string, checkers []Checker) *combinedChecker {
+	info := &CheckerInfo{Params: []string{"obtained"}}
+	subNames := make([]string, len(checkers))
+	for i, checker := range checkers {
+		subInfo := checker.Info()
+		subNames[i] = subInfo.Name
+		info.Params = append(info.Params, subInfo.Params[1:]...)
+	}
+	info.Name = op + "(" + strings.Join(subNames, ", ") + ")"
+	return &combinedChecker{info, op, checkers}
+}
+
+func (checker *combinedChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *combinedChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	var failures []string
+	next := 1
+	for _, sub := range checker.checkers {
+		info := sub.Info()
+		n := len(info.Params) - 1
+		// Each checker gets copies, as checkers may change them.
+		subParams := append([]interface{}{params[0]}, params[next:next+n]...)
+		subNames := append([]string{}, info.Params...)
+		next += n
+		ok, subError := sub.Check(subParams, subNames)
+		if ok && subError == "" {
+			if checker.op == "Or" {
+				return true, ""
+			}
+			continue
+		}
+		failure := info.Name + " failed"
+		if subError != "" {
+			failure += ": " + subError
+		}
+		if checker.op == "And" {
+			return false, failure
+		}
+		failures = append(failures, failure)
+	}
+	if checker.op == "And" {
+		return true, ""
+	}
+	return false, strings.Join(failures, "\n")
 }
 
 // -----------------------------------------------------------------------
@@ -108,7 +254,7 @@ type isNilChecker struct {
 //    c.Assert(err, IsNil)
 //
 var IsNil Checker = &isNilChecker{
-	&CheckerInfo{Name: "IsNil", Params: []string{"value"}},
+	&CheckerInfo{Name: "IsNil", Params: []string{"value"}, Negated: "Value is nil"},
 }
 
 func (checker *isNilChecker) Check(params []interface{}, names []string) (result bool, error string) {
@@ -144,7 +290,7 @@ type notNilChecker struct {
 // fairly common check.
 //
 var NotNil Checker = &notNilChecker{
-	&CheckerInfo{Name: "NotNil", Params: []string{"value"}},
+	&CheckerInfo{Name: "NotNil", Params: []string{"value"}, Negated: "Value is not nil"},
 }
 
 func (checker *notNilChecker) Check(params []interface{}, names []string) (result bool, error string) {
@@ -166,7 +312,8 @@ type equalsChecker struct {
 //     c.Assert(value, Equals, 42)
 //
 var Equals Checker = &equalsChecker{
-	&CheckerInfo{Name: "Equals", Params: []string{"obtained", "expected"}},
+	&CheckerInfo{Name: "Equals", Params: []string{"obtained", "expected"},
+		Negated: "Obtained value is equal to {expected}"},
 }
 
 func (checker *equalsChecker) Check(params []interface{}, names []string) (result bool, error string) {
@@ -179,6 +326,144 @@ func (checker *equalsChecker) Check(params []interface{}, names []string) (resul
 	return params[0] == params[1], ""
 }
 
+// -----------------------------------------------------------------------
+// NumEquals checker.
+
+type numEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The NumEquals checker verifies that the obtained and expected values are
+// the same number, whatever their numeric types, so that int(5), int64(5)
+// and float64(5) are all equal, as are the numbers of decoded JSON and the
+// constants they're compared with. Integers and floats are only equal when
+// the float holds exactly the integer, and NaN equals nothing.
+//
+// For example:
+//
+//     c.Assert(doc["count"], NumEquals, 5)
+//
+var NumEquals Checker = &numEqualsChecker{
+	&CheckerInfo{Name: "NumEquals", Params: []string{"obtained", "expected"},
+		Negated: "Obtained value is numerically equal to {expected}"},
+}
+
+func (checker *numEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	a, b := reflect.ValueOf(params[0]), reflect.ValueOf(params[1])
+	ka, kb := numberKind(a), numberKind(b)
+	if ka == reflect.Invalid {
+		return false, usageError("obtained value must be a number")
+	}
+	if kb == reflect.Invalid {
+		return false, usageError("expected value must be a number")
+	}
+	if ka == reflect.Float64 && kb == reflect.Float64 {
+		return a.Float() == b.Float(), ""
+	}
+	if ka == reflect.Float64 {
+		return floatEqualsInteger(a.Float(), b), ""
+	}
+	if kb == reflect.Float64 {
+		return floatEqualsInteger(b.Float(), a), ""
+	}
+	switch {
+	case ka == reflect.Int && kb == reflect.Int:
+		return a.Int() == b.Int(), ""
+	case ka == reflect.Uint && kb == reflect.Uint:
+		return a.Uint() == b.Uint(), ""
+	case ka == reflect.Int:
+		return a.Int() >= 0 && uint64(a.Int()) == b.Uint(), ""
+	}
+	return b.Int() >= 0 && uint64(b.Int()) == a.Uint(), ""
+}
+
+// floatEqualsInteger tells whether f holds exactly the value of the signed
+// or unsigned integer i.
+func floatEqualsInteger(f float64, i reflect.Value) bool {
+	return !math.IsNaN(f) && compareFloatInteger(f, i) == 0
+}
+
+// compareFloatInteger returns -1, 0 or 1 as f is less than, equal to or
+// greater than the signed or unsigned integer i, without rounding either.
+// f must not be NaN.
+func compareFloatInteger(f float64, i reflect.Value) int {
+	t := math.Trunc(f)
+	var cmp int
+	if numberKind(i) == reflect.Int {
+		switch {
+		case t < -(1 << 63):
+			return -1
+		case t >= 1<<63:
+			return 1
+		}
+		cmp = compareInts(int64(t), i.Int())
+	} else {
+		switch {
+		case t < 0:
+			return -1
+		case t >= 1<<64:
+			return 1
+		}
+		cmp = compareUints(uint64(t), i.Uint())
+	}
+	if cmp == 0 {
+		// The integral parts are equal, so any fraction decides.
+		switch {
+		case f < t:
+			return -1
+		case f > t:
+			return 1
+		}
+	}
+	return cmp
+}
+
+// -----------------------------------------------------------------------
+// SameInstance checker.
+
+type sameInstanceChecker struct {
+	*CheckerInfo
+}
+
+// The SameInstance checker verifies that the obtained and expected values
+// are the same pointer, map or channel, referring to the same object,
+// rather than merely equal ones. Unlike Equals, it refuses other values,
+// for which identity means nothing.
+//
+// For example:
+//
+//     c.Assert(cache.Get("key"), SameInstance, first)
+//
+var SameInstance Checker = &sameInstanceChecker{
+	&CheckerInfo{Name: "SameInstance", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *sameInstanceChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, expected := reflect.ValueOf(params[0]), reflect.ValueOf(params[1])
+	if !isReference(obtained) {
+		return false, usageError("obtained value must be a pointer, map or channel")
+	}
+	if !isReference(expected) {
+		return false, usageError("expected value must be a pointer, map or channel")
+	}
+	if obtained.Type() != expected.Type() {
+		return false, usageError(fmt.Sprintf("Obtained %s and expected %s can't be the same instance", obtained.Type(), expected.Type()))
+	}
+	if obtained.Pointer() != expected.Pointer() {
+		return false, fmt.Sprintf("Obtained %#x and expected %#x are different instances", obtained.Pointer(), expected.Pointer())
+	}
+	return true, ""
+}
+
+// isReference tells whether v is a pointer, map or channel.
+func isReference(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Map, reflect.Chan:
+		return true
+	}
+	return false
+}
+
 // -----------------------------------------------------------------------
 // DeepEquals checker.
 
@@ -189,7 +474,10 @@ type deepEqualsChecker struct {
 // The DeepEquals checker verifies that the obtained value is deep-equal to
 // the expected value.  The check will work correctly even when facing
 // slices, interfaces, and values of different types (which always fail
-// the test).
+// the test). When large values or multi-line strings differ, a unified
+// diff of them, with a field or element per line, is shown instead of the
+// values themselves. Values of types with a comparer registered with
+// RegisterComparer are compared with it.
 //
 // For example:
 //
@@ -197,11 +485,27 @@ type deepEqualsChecker struct {
 //     c.Assert(array, DeepEquals, []string{"hi", "there"})
 //
 var DeepEquals Checker = &deepEqualsChecker{
-	&CheckerInfo{Name: "DeepEquals", Params: []string{"obtained", "expected"}},
+	&CheckerInfo{Name: "DeepEquals", Params: []string{"obtained", "expected"},
+		Negated: "Obtained value is deep-equal to {expected}"},
 }
 
 func (checker *deepEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
-	return reflect.DeepEqual(params[0], params[1]), ""
+	if hasComparers() {
+		d := &deepCompare{}
+		if d.diff(params[0], params[1]) == "" {
+			return true, ""
+		}
+	} else if reflect.DeepEqual(params[0], params[1]) {
+		return true, ""
+	}
+	diff := valueDiff(params[0], params[1])
+	if diff == "" {
+		return false, ""
+	}
+	for i := range params {
+		params[i] = elidedValue{reflect.TypeOf(params[i])}
+	}
+	return false, "Difference (-obtained +expected):\n...     " + strings.Replace(diff, "\n", "\n...     ", -1)
 }
 
 // -----------------------------------------------------------------------
@@ -222,19 +526,19 @@ type hasLenChecker struct {
 //     c.Assert(list, HasLen, 5)
 //
 var HasLen Checker = &hasLenChecker{
-	&CheckerInfo{Name: "HasLen", Params: []string{"obtained", "n"}},
+	&CheckerInfo{Name: "HasLen", Params: []string{"obtained", "n"}, Negated: "Obtained value has length {n}"},
 }
 
 func (checker *hasLenChecker) Check(params []interface{}, names []string) (result bool, error string) {
 	n, ok := params[1].(int)
 	if !ok {
-		return false, "n must be an int"
+		return false, usageError("n must be an int")
 	}
 	value := reflect.ValueOf(params[0])
 	switch value.Kind() {
 	case reflect.Map, reflect.Array, reflect.Slice, reflect.Chan, reflect.String:
 	default:
-		return false, "obtained value type has no length"
+		return false, usageError("obtained value type has no length")
 	}
 	if value.Len() == n {
 		return true, ""
@@ -242,6 +546,107 @@ func (checker *hasLenChecker) Check(params []interface{}, names []string) (resul
 	return false, fmt.Sprintf("obtained length = %d", value.Len())
 }
 
+// -----------------------------------------------------------------------
+// LenAtLeast, LenAtMost and LenBetween checkers.
+
+type lenAtLeastChecker struct {
+	*CheckerInfo
+}
+
+// The LenAtLeast checker verifies that the obtained value has at least
+// the provided length. Like with HasLen, the value itself is printed when
+// the check fails.
+//
+// For example:
+//
+//     c.Assert(results, LenAtLeast, 1)
+//
+var LenAtLeast Checker = &lenAtLeastChecker{
+	&CheckerInfo{Name: "LenAtLeast", Params: []string{"obtained", "min"}},
+}
+
+func (checker *lenAtLeastChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	min, ok := params[1].(int)
+	if !ok {
+		return false, usageError("min must be an int")
+	}
+	return checkLen(params[0], min, -1)
+}
+
+type lenAtMostChecker struct {
+	*CheckerInfo
+}
+
+// The LenAtMost checker verifies that the obtained value has at most the
+// provided length.
+//
+// For example:
+//
+//     c.Assert(batch, LenAtMost, 100)
+//
+var LenAtMost Checker = &lenAtMostChecker{
+	&CheckerInfo{Name: "LenAtMost", Params: []string{"obtained", "max"}},
+}
+
+func (checker *lenAtMostChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	max, ok := params[1].(int)
+	if !ok {
+		return false, usageError("max must be an int")
+	}
+	if max < 0 {
+		return false, usageError("max must not be negative")
+	}
+	return checkLen(params[0], 0, max)
+}
+
+type lenBetweenChecker struct {
+	*CheckerInfo
+}
+
+// The LenBetween checker verifies that the obtained value has a length
+// within the inclusive range from min to max.
+//
+// For example:
+//
+//     c.Assert(password, LenBetween, 8, 64)
+//
+var LenBetween Checker = &lenBetweenChecker{
+	&CheckerInfo{Name: "LenBetween", Params: []string{"obtained", "min", "max"}},
+}
+
+func (checker *lenBetweenChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	min, ok := params[1].(int)
+	if !ok {
+		return false, usageError("min must be an int")
+	}
+	max, ok := params[2].(int)
+	if !ok {
+		return false, usageError("max must be an int")
+	}
+	if max < 0 {
+		return false, usageError("max must not be negative")
+	}
+	if min > max {
+		return false, usageError("min must not be greater than max")
+	}
+	return checkLen(params[0], min, max)
+}
+
+// checkLen verifies that the length of obtained is at least min and, unless
+// max is negative, at most max.
+func checkLen(obtained interface{}, min, max int) (result bool, error string) {
+	value := reflect.ValueOf(obtained)
+	switch value.Kind() {
+	case reflect.Map, reflect.Array, reflect.Slice, reflect.Chan, reflect.String:
+	default:
+		return false, usageError("obtained value type has no length")
+	}
+	if value.Len() >= min && (max < 0 || value.Len() <= max) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("obtained length = %d", value.Len())
+}
+
 // -----------------------------------------------------------------------
 // ErrorMatches checker.
 
@@ -257,7 +662,7 @@ type errorMatchesChecker struct {
 //     c.Assert(err, ErrorMatches, "perm.*denied")
 //
 var ErrorMatches Checker = errorMatchesChecker{
-	&CheckerInfo{Name: "ErrorMatches", Params: []string{"value", "regex"}},
+	&CheckerInfo{Name: "ErrorMatches", Params: []string{"value", "regex"}, Negated: "Error matches {regex}"},
 }
 
 func (checker errorMatchesChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
@@ -266,13 +671,129 @@ func (checker errorMatchesChecker) Check(params []interface{}, names []string) (
 	}
 	err, ok := params[0].(error)
 	if !ok {
-		return false, "Value is not an error"
+		return false, usageError("Value is not an error")
 	}
 	params[0] = err.Error()
 	names[0] = "error"
 	return matches(params[0], params[1])
 }
 
+// -----------------------------------------------------------------------
+// ErrorContains checker.
+
+type errorContainsChecker struct {
+	*CheckerInfo
+}
+
+// The ErrorContains checker verifies that the error value is non nil and
+// that its message contains the given substring or, if a *regexp.Regexp
+// is given, that the regular expression matches some part of it.
+//
+// For example:
+//
+//     c.Assert(err, ErrorContains, "permission denied")
+//     c.Assert(err, ErrorContains, regexp.MustCompile(`port [0-9]+ in use`))
+//
+var ErrorContains Checker = &errorContainsChecker{
+	&CheckerInfo{Name: "ErrorContains", Params: []string{"value", "substring"}},
+}
+
+func (checker *errorContainsChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
+	if params[0] == nil {
+		return false, "Error value is nil"
+	}
+	err, ok := params[0].(error)
+	if !ok {
+		return false, usageError("Value is not an error")
+	}
+	params[0] = err.Error()
+	names[0] = "error"
+	switch expected := params[1].(type) {
+	case string:
+		return strings.Contains(err.Error(), expected), ""
+	case *regexp.Regexp:
+		return expected.MatchString(err.Error()), ""
+	}
+	return false, usageError("Substring must be a string or a *regexp.Regexp")
+}
+
+// -----------------------------------------------------------------------
+// ErrorIs checker.
+
+type errorIsChecker struct {
+	*CheckerInfo
+}
+
+// The ErrorIs checker verifies that the obtained error is, or wraps, the
+// target error, as reported by errors.Is. Unlike Equals, it still succeeds
+// once the error has been wrapped with more context.
+//
+// For example:
+//
+//     c.Assert(err, ErrorIs, sql.ErrNoRows)
+//
+var ErrorIs Checker = &errorIsChecker{
+	&CheckerInfo{Name: "ErrorIs", Params: []string{"value", "target"}, Negated: "Error is or wraps the target"},
+}
+
+func (checker *errorIsChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
+	if params[0] == nil {
+		return false, "Error value is nil"
+	}
+	err, ok := params[0].(error)
+	if !ok {
+		return false, usageError("Value is not an error")
+	}
+	target, ok := params[1].(error)
+	if !ok {
+		return false, usageError("Target is not an error")
+	}
+	return errors.Is(err, target), ""
+}
+
+// -----------------------------------------------------------------------
+// ErrorAs checker.
+
+type errorAsChecker struct {
+	*CheckerInfo
+}
+
+// The ErrorAs checker verifies that the obtained error is, or wraps, an
+// error which may be assigned to the value the target points to, as
+// reported by errors.As. When it does, the target is set to that error,
+// so its fields may be checked next.
+//
+// For example:
+//
+//     var pathErr *os.PathError
+//     c.Assert(err, ErrorAs, &pathErr)
+//     c.Assert(pathErr.Path, Equals, "/missing")
+//
+var ErrorAs Checker = &errorAsChecker{
+	&CheckerInfo{Name: "ErrorAs", Params: []string{"value", "target"}},
+}
+
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+func (checker *errorAsChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
+	if params[0] == nil {
+		return false, "Error value is nil"
+	}
+	err, ok := params[0].(error)
+	if !ok {
+		return false, usageError("Value is not an error")
+	}
+	// errors.As panics on targets it can't set, so they're checked first.
+	target := reflect.ValueOf(params[1])
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return false, usageError("Target must be a non-nil pointer")
+	}
+	if elem := target.Type().Elem(); elem.Kind() != reflect.Interface && !elem.Implements(errorInterface) {
+		return false, usageError("Target must point to an interface or to a type implementing error")
+	}
+	return errors.As(err, params[1]), ""
+}
+
 // -----------------------------------------------------------------------
 // Matches checker.
 
@@ -280,16 +801,17 @@ type matchesChecker struct {
 	*CheckerInfo
 }
 
-// The Matches checker verifies that the string provided as the obtained
-// value (or the string resulting from obtained.String()) matches the
-// regular expression provided.
+// The Matches checker verifies that the string or []byte provided as the
+// obtained value (or the string resulting from obtained.String()) matches
+// the regular expression provided, in full. See ContainsMatch for
+// matching part of it.
 //
 // For example:
 //
 //     c.Assert(err, Matches, "perm.*denied")
 //
 var Matches Checker = &matchesChecker{
-	&CheckerInfo{Name: "Matches", Params: []string{"value", "regex"}},
+	&CheckerInfo{Name: "Matches", Params: []string{"value", "regex"}, Negated: "Value matches {regex}"},
 }
 
 func (checker *matchesChecker) Check(params []interface{}, names []string) (result bool, error string) {
@@ -299,22 +821,59 @@ func (checker *matchesChecker) Check(params []interface{}, names []string) (resu
 func matches(value, regex interface{}) (result bool, error string) {
 	reStr, ok := regex.(string)
 	if !ok {
-		return false, "Regex must be a string"
+		return false, usageError("Regex must be a string")
 	}
-	valueStr, valueIsStr := value.(string)
-	if !valueIsStr {
-		if valueWithStr, valueHasStr := value.(fmt.Stringer); valueHasStr {
-			valueStr, valueIsStr = valueWithStr.String(), true
-		}
+	return matchesRegex(value, "^"+reStr+"$")
+}
+
+// matchesRegex tells whether the regular expression re matches the string
+// of value: a string, a []byte or a fmt.Stringer.
+func matchesRegex(value interface{}, re string) (result bool, error string) {
+	var valueStr string
+	switch v := value.(type) {
+	case string:
+		valueStr = v
+	case []byte:
+		valueStr = string(v)
+	case fmt.Stringer:
+		valueStr = v.String()
+	default:
+		return false, usageError("Obtained value is not a string or []byte and has no .String()")
 	}
-	if valueIsStr {
-		matches, err := regexp.MatchString("^"+reStr+"$", valueStr)
-		if err != nil {
-			return false, "Can't compile regex: " + err.Error()
-		}
-		return matches, ""
+	matches, err := regexp.MatchString(re, valueStr)
+	if err != nil {
+		return false, usageError("Can't compile regex: " + err.Error())
+	}
+	return matches, ""
+}
+
+// -----------------------------------------------------------------------
+// ContainsMatch checker.
+
+type containsMatchChecker struct {
+	*CheckerInfo
+}
+
+// The ContainsMatch checker verifies that the regular expression provided
+// matches some part of the string or []byte provided as the obtained value
+// (or of the string resulting from obtained.String()). The regular
+// expression is in multi-line mode, so that ^ and $ match at the start and
+// end of lines as well as of the whole value.
+//
+// For example:
+//
+//     c.Assert(logOutput, ContainsMatch, `^ERROR .*timeout$`)
+//
+var ContainsMatch Checker = &containsMatchChecker{
+	&CheckerInfo{Name: "ContainsMatch", Params: []string{"value", "regex"}},
+}
+
+func (checker *containsMatchChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	reStr, ok := params[1].(string)
+	if !ok {
+		return false, usageError("Regex must be a string")
 	}
-	return false, "Obtained value is not a string and has no .String()"
+	return matchesRegex(params[0], "(?m)"+reStr)
 }
 
 // -----------------------------------------------------------------------
@@ -349,7 +908,7 @@ var DoesntPanic Checker = &doesntPanicChecker{
 func (checker *panicsChecker) Check(params []interface{}, names []string) (result bool, error string) {
 	f := reflect.ValueOf(params[0])
 	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 {
-		return false, "Function must take zero arguments"
+		return false, usageError("Function must take zero arguments")
 	}
 	defer func() {
 		// If the function has not panicked, then don't do the check.
@@ -365,11 +924,12 @@ func (checker *panicsChecker) Check(params []interface{}, names []string) (resul
 }
 
 // The DoesntPanic checker verifies that calling the provided zero-argument
-// function will NOT cause a panic.
+// function will NOT cause a panic. When it does, the panic value is shown
+// along with where it was raised from.
 //
 // The first param must be a function so that the execution of the code
 // to be tested can be delayed, and any unexpected panic caught.
-
+//
 // For example:
 //
 //     c.Assert(func() { f(1, 2) }, DoesntPanic)
@@ -378,40 +938,80 @@ func (checker *panicsChecker) Check(params []interface{}, names []string) (resul
 func (checker *doesntPanicChecker) Check(params []interface{}, names []string) (result bool, err string) {
 	f := reflect.ValueOf(params[0])
 	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 {
-		return false, "Function must take zero arguments"
+		return false, usageError("Function must take zero arguments")
 	}
+	panicked := true
 	defer func() {
-		if e := recover(); e != nil {
-			result = false
-			// TODO: Figure out how to set the error string
-			err = fmt.Sprintf("%v", e)
+		if !panicked {
+			return
 		}
+		params[0] = recover()
+		names[0] = "panic"
+		result, err = false, "Function panicked at:\n"+panicTrace()
 	}()
 	f.Call(nil)
+	panicked = false
 	return true, ""
 }
 
-type panicMatchesChecker struct {
-	*CheckerInfo
-}
-
-// The PanicMatches checker verifies that calling the provided zero-argument
-// function will cause a panic with an error value matching
-// the regular expression provided.
+// The NotPanics checker is the same as DoesntPanic, under another name.
 //
 // For example:
 //
-//     c.Assert(func() { f(1, 2) }, PanicMatches, `open.*: no such file or directory`).
-//
+//     c.Assert(func() { server.Close() }, NotPanics)
 //
-var PanicMatches Checker = &panicMatchesChecker{
+var NotPanics Checker = &doesntPanicChecker{
+	&CheckerInfo{Name: "NotPanics", Params: []string{"function"}},
+}
+
+// panicTrace returns the stack of the function which panicked, as called
+// by a checker, formatted for the log. It must be called from a deferred
+// function recovering from the panic.
+func panicTrace() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	var lines []string
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking {
+			if strings.HasPrefix(frame.Function, "reflect.") {
+				break
+			}
+			lines = append(lines, fmt.Sprintf("...     %s:%d\n...       in %s",
+				nicePath(frame.File), frame.Line, niceFuncName(frame.PC)))
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type panicMatchesChecker struct {
+	*CheckerInfo
+}
+
+// The PanicMatches checker verifies that calling the provided zero-argument
+// function will cause a panic with an error value matching
+// the regular expression provided.
+//
+// For example:
+//
+//     c.Assert(func() { f(1, 2) }, PanicMatches, `open.*: no such file or directory`).
+//
+//
+var PanicMatches Checker = &panicMatchesChecker{
 	&CheckerInfo{Name: "PanicMatches", Params: []string{"function", "expected"}},
 }
 
 func (checker *panicMatchesChecker) Check(params []interface{}, names []string) (result bool, errmsg string) {
 	f := reflect.ValueOf(params[0])
 	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 {
-		return false, "Function must take zero arguments"
+		return false, usageError("Function must take zero arguments")
 	}
 	defer func() {
 		// If the function has not panicked, then don't do the check.
@@ -434,6 +1034,55 @@ func (checker *panicMatchesChecker) Check(params []interface{}, names []string)
 	return false, "Function has not panicked"
 }
 
+type panicsWithChecker struct {
+	info    *CheckerInfo
+	checker Checker
+}
+
+// The PanicsWith checker verifies that calling the provided zero-argument
+// function causes a panic, and that the given checker succeeds on the
+// value recovered, given the remaining arguments. This allows asserting
+// on typed panic values, rather than on their messages.
+//
+// For example:
+//
+//     c.Assert(func() { parse(input) }, PanicsWith(ErrorIs), ErrSyntax)
+//     c.Assert(func() { f(1, 2) }, PanicsWith(FitsTypeOf), &SomeErrorType{})
+//
+func PanicsWith(checker Checker) Checker {
+	info := *checker.Info()
+	info.Name = "PanicsWith(" + info.Name + ")"
+	info.Params = append([]string{"function"}, info.Params[1:]...)
+	return &panicsWithChecker{&info, checker}
+}
+
+func (checker *panicsWithChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *panicsWithChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f := reflect.ValueOf(params[0])
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 {
+		return false, usageError("Function must take zero arguments")
+	}
+	panicked := true
+	defer func() {
+		if !panicked {
+			return
+		}
+		// The checker gets copies, as checkers may change them.
+		subParams := append([]interface{}{recover()}, params[1:]...)
+		subNames := append([]string{}, checker.checker.Info().Params...)
+		result, error = checker.checker.Check(subParams, subNames)
+		copy(params, subParams)
+		copy(names, subNames)
+		names[0] = "panic"
+	}()
+	f.Call(nil)
+	panicked = false
+	return false, "Function has not panicked"
+}
+
 // -----------------------------------------------------------------------
 // FitsTypeOf checker.
 
@@ -461,7 +1110,7 @@ func (checker *fitsTypeChecker) Check(params []interface{}, names []string) (res
 		return false, ""
 	}
 	if !sample.IsValid() {
-		return false, "Invalid sample value"
+		return false, usageError("Invalid sample value")
 	}
 	return obtained.Type().AssignableTo(sample.Type()), ""
 }
@@ -493,11 +1142,71 @@ func (checker *implementsChecker) Check(params []interface{}, names []string) (r
 		return false, ""
 	}
 	if !ifaceptr.IsValid() || ifaceptr.Kind() != reflect.Ptr || ifaceptr.Elem().Kind() != reflect.Interface {
-		return false, "ifaceptr should be a pointer to an interface variable"
+		return false, usageError("ifaceptr should be a pointer to an interface variable")
 	}
 	return obtained.Type().Implements(ifaceptr.Elem().Type()), ""
 }
 
+// -----------------------------------------------------------------------
+// AssignableTo and ConvertibleTo checkers.
+
+type typeRelationChecker struct {
+	*CheckerInfo
+	relation string
+	holds    func(obtained, target reflect.Type) bool
+}
+
+// The AssignableTo checker verifies that the type of the obtained value is
+// assignable to the given type, which is either a reflect.Type or given
+// via a pointer to a variable of it, so that interfaces may be given as
+// for Implements. Both types are reported on failure.
+//
+// For example:
+//
+//     var handler http.Handler
+//     c.Assert(plugin, AssignableTo, &handler)
+//     c.Assert(id, AssignableTo, reflect.TypeOf(UserID("")))
+//
+var AssignableTo Checker = &typeRelationChecker{
+	&CheckerInfo{Name: "AssignableTo", Params: []string{"obtained", "type"}},
+	"assignable", reflect.Type.AssignableTo,
+}
+
+// The ConvertibleTo checker verifies that the type of the obtained value is
+// convertible to the given type, which is either a reflect.Type or given
+// via a pointer to a variable of it. Both types are reported on failure.
+//
+// For example:
+//
+//     c.Assert(raw, ConvertibleTo, reflect.TypeOf(Celsius(0)))
+//
+var ConvertibleTo Checker = &typeRelationChecker{
+	&CheckerInfo{Name: "ConvertibleTo", Params: []string{"obtained", "type"}},
+	"convertible", reflect.Type.ConvertibleTo,
+}
+
+func (checker *typeRelationChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	var target reflect.Type
+	switch t := params[1].(type) {
+	case reflect.Type:
+		target = t
+	default:
+		ptr := reflect.ValueOf(params[1])
+		if ptr.Kind() != reflect.Ptr {
+			return false, usageError("type must be a reflect.Type or a pointer to a variable of the type")
+		}
+		target = ptr.Type().Elem()
+	}
+	if params[0] == nil {
+		return false, usageError("Obtained value is nil, which has no type")
+	}
+	obtained := reflect.TypeOf(params[0])
+	if checker.holds(obtained, target) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("Obtained type %s is not %s to %s", obtained, checker.relation, target)
+}
+
 // -----------------------------------------------------------------------
 // IsTrue / IsFalse checker.
 
@@ -509,7 +1218,7 @@ type isBoolValueChecker struct {
 func (checker *isBoolValueChecker) Check(params []interface{}, names []string) (result bool, error string) {
 	obtained, ok := params[0].(bool)
 	if !ok {
-		return false, "Argument to " + checker.Name + " must be bool"
+		return false, usageError("Argument to " + checker.Name + " must be bool")
 	}
 
 	return obtained == checker.expected, ""
@@ -561,7 +1270,7 @@ func (checker *sliceIncludesChecker) Check(params []interface{}, names []string)
 	//params[1] == aThing (that we hope is in the slice)
 	s := reflect.ValueOf(params[0]) //aSlice
 	if s.Kind() != reflect.Slice {
-		return false, fmt.Sprintf("SliceIncludes given a non-slice type: %v", params[0])
+		return false, usageError(fmt.Sprintf("SliceIncludes given a non-slice type: %v", params[0]))
 	}
 
 	for i := 0; i < s.Len(); i++ {
@@ -598,15 +1307,15 @@ var WithinDelta Checker = &withinDeltaChecker{
 func (c *withinDeltaChecker) Check(params []interface{}, names []string) (result bool, error string) {
 	obtained, ok := params[0].(float64)
 	if !ok {
-		return false, "obtained must be a float64"
+		return false, usageError("obtained must be a float64")
 	}
 	delta, ok := params[1].(float64)
 	if !ok {
-		return false, "delta must be a float64"
+		return false, usageError("delta must be a float64")
 	}
 	expected, ok := params[2].(float64)
 	if !ok {
-		return false, "expected must be a float64"
+		return false, usageError("expected must be a float64")
 	}
 	return math.Abs(obtained-expected) <= delta, ""
 }
@@ -630,15 +1339,3728 @@ var BetweenFloats Checker = &betweenFloatsChecker{
 func (c *betweenFloatsChecker) Check(params []interface{}, names []string) (result bool, error string) {
 	obtained, ok := params[0].(float64)
 	if !ok {
-		return false, "obtained must be a float64"
+		return false, usageError("obtained must be a float64")
 	}
 	low, ok := params[1].(float64)
 	if !ok {
-		return false, "low must be a float64"
+		return false, usageError("low must be a float64")
 	}
 	high, ok := params[2].(float64)
 	if !ok {
-		return false, "high must be a float64"
+		return false, usageError("high must be a float64")
 	}
 	return (obtained >= low && obtained <= high), ""
 }
+
+// -----------------------------------------------------------------------
+// FieldsMatch checker.
+
+type fieldsMatchChecker struct {
+	*CheckerInfo
+}
+
+// The FieldsMatch checker verifies that the named fields of the obtained
+// struct (or pointer to struct) are deeply equal to the values in the
+// provided map. Fields not mentioned in the map are ignored, and every
+// mismatching field is reported at once.
+//
+// For example:
+//
+//     c.Assert(config, FieldsMatch, map[string]interface{}{"Name": "x", "Port": 8080})
+//
+var FieldsMatch Checker = &fieldsMatchChecker{
+	&CheckerInfo{Name: "FieldsMatch", Params: []string{"obtained", "fields"}},
+}
+
+func (checker *fieldsMatchChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	v := reflect.ValueOf(params[0])
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false, usageError("obtained value is not a struct or pointer to struct")
+	}
+	fields, ok := params[1].(map[string]interface{})
+	if !ok {
+		return false, usageError("fields must be a map[string]interface{}")
+	}
+	fieldNames := make([]string, 0, len(fields))
+	for name := range fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+
+	var mismatches []string
+	for _, name := range fieldNames {
+		field, ok := v.Type().FieldByName(name)
+		if !ok {
+			return false, usageError(fmt.Sprintf("%s has no field named %q", v.Type(), name))
+		}
+		if field.PkgPath != "" {
+			return false, usageError(fmt.Sprintf("field %q of %s is unexported", name, v.Type()))
+		}
+		fv, ok := fieldByIndex(v, field.Index)
+		if !ok {
+			return false, usageError(fmt.Sprintf("field %q of %s is behind a nil embedded pointer", name, v.Type()))
+		}
+		obtained := fv.Interface()
+		if !reflect.DeepEqual(obtained, fields[name]) {
+			mismatches = append(mismatches, fmt.Sprintf("%s: obtained %s, expected %s", name, goSyntax(obtained), goSyntax(fields[name])))
+		}
+	}
+	if len(mismatches) > 0 {
+		return false, "Mismatched fields:\n" + strings.Join(mismatches, "\n")
+	}
+	return true, ""
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports whether the
+// field could be reached instead of panicking on nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// -----------------------------------------------------------------------
+// ApproxDeepEquals checker.
+
+type approxDeepEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The ApproxDeepEquals checker verifies that the obtained value is
+// deep-equal to the expected value, except that floating point values
+// found anywhere within them (struct fields, slice elements, map values,
+// etc) only need to be within the given tolerance of each other. The path
+// to the first difference is reported on failure.
+//
+// For example:
+//
+//     c.Assert(got, ApproxDeepEquals, want, 1e-9)
+//
+var ApproxDeepEquals Checker = &approxDeepEqualsChecker{
+	&CheckerInfo{Name: "ApproxDeepEquals", Params: []string{"obtained", "expected", "tolerance"}},
+}
+
+func (checker *approxDeepEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	tolerance, ok := params[2].(float64)
+	if !ok {
+		return false, usageError("tolerance must be a float64")
+	}
+	if tolerance < 0 || math.IsNaN(tolerance) {
+		return false, usageError("tolerance must not be negative")
+	}
+	d := &deepCompare{floatTolerance: tolerance}
+	if diff := d.diff(params[0], params[1]); diff != "" {
+		return false, diff
+	}
+	return true, ""
+}
+
+// -----------------------------------------------------------------------
+// DeepEqualsIgnoring checker.
+
+type deepEqualsIgnoringChecker struct {
+	info   *CheckerInfo
+	fields map[string]bool
+}
+
+// The DeepEqualsIgnoring checker verifies that the obtained value is
+// deep-equal to the expected value, except for the struct fields with the
+// given names, which are skipped in structs at any depth. The path to the
+// first difference is reported on failure.
+//
+// For example:
+//
+//     c.Assert(got, DeepEqualsIgnoring("ID", "CreatedAt"), want)
+//
+func DeepEqualsIgnoring(fields ...string) Checker {
+	checker := &deepEqualsIgnoringChecker{
+		info: &CheckerInfo{
+			Name:   "DeepEqualsIgnoring(" + strings.Join(fields, ", ") + ")",
+			Params: []string{"obtained", "expected"},
+		},
+		fields: make(map[string]bool),
+	}
+	for _, field := range fields {
+		checker.fields[field] = true
+	}
+	return checker
+}
+
+func (checker *deepEqualsIgnoringChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *deepEqualsIgnoringChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	d := &deepCompare{ignoredFields: checker.fields}
+	if diff := d.diff(params[0], params[1]); diff != "" {
+		return false, diff
+	}
+	return true, ""
+}
+
+// -----------------------------------------------------------------------
+// DeepEqualsWith checker.
+
+// DeepOptions relaxes how DeepEqualsWith compares values.
+type DeepOptions struct {
+	// EquateEmpty makes nil slices and maps equal to empty ones.
+	EquateEmpty bool
+	// IgnoreUnexported skips unexported struct fields, except in structs
+	// with no exported ones, such as time.Time.
+	IgnoreUnexported bool
+	// TimeEqual compares time.Time values with their Equal method, so
+	// that the same instant in different locations is equal.
+	TimeEqual bool
+}
+
+type deepEqualsWithChecker struct {
+	info    *CheckerInfo
+	options DeepOptions
+}
+
+// The DeepEqualsWith checker verifies that the obtained value is
+// deep-equal to the expected value, with the comparison relaxed as the
+// given options tell. The path to the first difference is reported on
+// failure.
+//
+// For example:
+//
+//     c.Assert(got, DeepEqualsWith(DeepOptions{EquateEmpty: true, TimeEqual: true}), want)
+//
+func DeepEqualsWith(options DeepOptions) Checker {
+	return &deepEqualsWithChecker{
+		info:    &CheckerInfo{Name: "DeepEqualsWith", Params: []string{"obtained", "expected"}},
+		options: options,
+	}
+}
+
+func (checker *deepEqualsWithChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *deepEqualsWithChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	d := &deepCompare{options: checker.options}
+	if diff := d.diff(params[0], params[1]); diff != "" {
+		return false, diff
+	}
+	return true, ""
+}
+
+// -----------------------------------------------------------------------
+// ErrorChain checker.
+
+type errorChainChecker struct {
+	*CheckerInfo
+}
+
+// The ErrorChain checker verifies that the obtained error wraps exactly
+// the expected sequence of errors, outermost first. Each link of the chain
+// matches an expected string if either its full Error() text or its own
+// message (that is, without the ": " and text of the error it wraps) is
+// equal to it. Errors wrapping multiple errors through Unwrap() []error are
+// traversed depth-first, in the order the wrapped errors are returned.
+//
+// For example:
+//
+//     c.Assert(err, ErrorChain, []string{"outer", "middle", "root"})
+//
+var ErrorChain Checker = &errorChainChecker{
+	&CheckerInfo{Name: "ErrorChain", Params: []string{"value", "expected"}},
+}
+
+func (checker *errorChainChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
+	if params[0] == nil {
+		return false, "Error value is nil"
+	}
+	err, ok := params[0].(error)
+	if !ok {
+		return false, usageError("Value is not an error")
+	}
+	expected, ok := params[1].([]string)
+	if !ok {
+		return false, usageError("expected must be a []string")
+	}
+	chain := errorChain(err, nil)
+	params[0] = chainMessages(chain)
+	names[0] = "chain"
+
+	for i, link := range chain {
+		if i >= len(expected) {
+			return false, fmt.Sprintf("Error chain has %d links, expected %d", len(chain), len(expected))
+		}
+		if link.Error() != expected[i] && errorMessage(link) != expected[i] {
+			return false, fmt.Sprintf("Error chain diverges at link %d: obtained %q, expected %q",
+				i, errorMessage(link), expected[i])
+		}
+	}
+	if len(chain) != len(expected) {
+		return false, fmt.Sprintf("Error chain has %d links, expected %d", len(chain), len(expected))
+	}
+	return true, ""
+}
+
+// errorChain appends err and all the errors it wraps to chain, depth-first.
+func errorChain(err error, chain []error) []error {
+	if err == nil {
+		return chain
+	}
+	chain = append(chain, err)
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		chain = errorChain(e.Unwrap(), chain)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			chain = errorChain(wrapped, chain)
+		}
+	}
+	return chain
+}
+
+// errorMessage returns the message of err without the text of the error it
+// wraps, when err follows the usual "message: cause" convention.
+func errorMessage(err error) string {
+	msg := err.Error()
+	if e, ok := err.(interface{ Unwrap() error }); ok && e.Unwrap() != nil {
+		msg = strings.TrimSuffix(msg, ": "+e.Unwrap().Error())
+	}
+	return msg
+}
+
+// -----------------------------------------------------------------------
+// HasErrorCount and ContainsError checkers.
+
+type hasErrorCountChecker struct {
+	*CheckerInfo
+}
+
+// The HasErrorCount checker verifies that the obtained error is made of
+// the given number of errors: those it wraps, at any depth, which don't
+// wrap any others themselves, such as the errors joined by errors.Join.
+// An error wrapping no others counts as one, and a nil error as none. The
+// errors of the chain are listed on failure.
+//
+// For example:
+//
+//     c.Assert(validate(form), HasErrorCount, 3)
+//
+var HasErrorCount Checker = &hasErrorCountChecker{
+	&CheckerInfo{Name: "HasErrorCount", Params: []string{"value", "count"}},
+}
+
+func (checker *hasErrorCountChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
+	expected, ok := params[1].(int)
+	if !ok {
+		return false, usageError("count must be an int")
+	}
+	var err error
+	if params[0] != nil {
+		if err, ok = params[0].(error); !ok {
+			return false, usageError("Value is not an error")
+		}
+	}
+	count := 0
+	chain := errorChain(err, nil)
+	for _, link := range chain {
+		if len(errorChain(link, nil)) == 1 {
+			count++
+		}
+	}
+	if count == expected {
+		return true, ""
+	}
+	params[0] = chainMessages(chain)
+	names[0] = "chain"
+	return false, fmt.Sprintf("Error has %d errors, expected %d", count, expected)
+}
+
+type containsErrorChecker struct {
+	info    *CheckerInfo
+	checker Checker
+}
+
+// The ContainsError checker verifies that the given checker succeeds on
+// the obtained error or on any error it wraps, at any depth, including the
+// errors joined by errors.Join, given the remaining arguments. The errors
+// of the chain are listed on failure.
+//
+// For example:
+//
+//     c.Assert(err, ContainsError(ErrorMatches), "field .* is required")
+//     c.Assert(err, ContainsError(FitsTypeOf), &ValidationError{})
+//
+func ContainsError(checker Checker) Checker {
+	info := *checker.Info()
+	info.Name = "ContainsError(" + info.Name + ")"
+	info.Params = append([]string{"value"}, info.Params[1:]...)
+	return &containsErrorChecker{&info, checker}
+}
+
+func (checker *containsErrorChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *containsErrorChecker) Check(params []interface{}, names []string) (result bool, errStr string) {
+	if params[0] == nil {
+		return false, "Error value is nil"
+	}
+	err, ok := params[0].(error)
+	if !ok {
+		return false, usageError("Value is not an error")
+	}
+	chain := errorChain(err, nil)
+	for _, link := range chain {
+		// The checker gets copies, as checkers may change them.
+		subParams := append([]interface{}{link}, params[1:]...)
+		subNames := append([]string{}, checker.checker.Info().Params...)
+		if ok, subError := checker.checker.Check(subParams, subNames); ok && subError == "" {
+			return true, ""
+		}
+	}
+	params[0] = chainMessages(chain)
+	names[0] = "chain"
+	return false, fmt.Sprintf("None of the %d errors of the chain satisfies %s", len(chain), checker.checker.Info().Name)
+}
+
+// chainMessages returns the messages of the errors of chain.
+func chainMessages(chain []error) []string {
+	messages := make([]string, len(chain))
+	for i, link := range chain {
+		messages[i] = link.Error()
+	}
+	return messages
+}
+
+// -----------------------------------------------------------------------
+// WithinPercent checker.
+
+type withinPercentChecker struct {
+	*CheckerInfo
+}
+
+// The WithinPercent checker verifies that the obtained value is within the
+// given percentage of the expected value, either way. Values may be of any
+// numeric kind, including types such as time.Duration. Note that when the
+// expected value is zero, only an obtained value of exactly zero passes.
+//
+// For example:
+//
+//     c.Assert(elapsed, WithinPercent, 2*time.Second, 10.0)
+//
+var WithinPercent Checker = &withinPercentChecker{
+	&CheckerInfo{Name: "WithinPercent", Params: []string{"obtained", "expected", "percent"}},
+}
+
+func (checker *withinPercentChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, ok := toFloat64(params[0])
+	if !ok {
+		return false, usageError("obtained value is not a number")
+	}
+	expected, ok := toFloat64(params[1])
+	if !ok {
+		return false, usageError("expected value is not a number")
+	}
+	percent, ok := toFloat64(params[2])
+	if !ok {
+		return false, usageError("percent must be a number")
+	}
+	if percent < 0 || math.IsNaN(percent) {
+		return false, usageError("percent must not be negative")
+	}
+	delta := math.Abs(expected) * percent / 100
+	low, high := expected-delta, expected+delta
+	if obtained >= low && obtained <= high {
+		return true, ""
+	}
+	return false, fmt.Sprintf("Expected %s ± %g%% (between %s and %s), obtained %s",
+		formatNumberLike(params[1], expected), percent, formatNumberLike(params[1], low),
+		formatNumberLike(params[1], high), formatNumberLike(params[0], obtained))
+}
+
+// formatNumber writes out f, the value of the number v, as %g does, or
+// with the formatter registered for the type of v if there's one.
+func formatNumber(v reflect.Value, f float64) string {
+	if s, ok := formatValue(v); ok {
+		return s
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// toFloat64 converts a value of any numeric kind to a float64.
+func toFloat64(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// formatNumberLike formats f, which was converted from or computed for
+// sample, so that durations are still shown as such.
+func formatNumberLike(sample interface{}, f float64) string {
+	if _, ok := sample.(time.Duration); ok {
+		return time.Duration(f).String()
+	}
+	return fmt.Sprintf("%g", f)
+}
+
+// -----------------------------------------------------------------------
+// AlmostEquals checker.
+
+// Tolerance is an epsilon for the AlmostEquals checker which may be
+// absolute, relative or both. Values are almost equal if they differ by
+// at most Abs, or by at most Rel times the larger of their magnitudes.
+type Tolerance struct {
+	Abs float64
+	Rel float64
+}
+
+type almostEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The AlmostEquals checker verifies that the obtained number is equal to
+// the expected one up to an epsilon, so that floating point rounding
+// doesn't make tests fail. A number as the epsilon is an absolute
+// tolerance, while a Tolerance may also or instead give a relative one.
+// NaN is never almost equal to anything.
+//
+// For example:
+//
+//     c.Assert(got, AlmostEquals, 3.14159, 1e-6)
+//     c.Assert(got, AlmostEquals, 6.02e23, Tolerance{Rel: 1e-9})
+//
+var AlmostEquals Checker = &almostEqualsChecker{
+	&CheckerInfo{Name: "AlmostEquals", Params: []string{"obtained", "expected", "epsilon"}},
+}
+
+func (checker *almostEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, ok := toFloat64(params[0])
+	if !ok {
+		return false, usageError("obtained value is not a number")
+	}
+	expected, ok := toFloat64(params[1])
+	if !ok {
+		return false, usageError("expected value is not a number")
+	}
+	tolerance, error := toTolerance(params[2])
+	if error != "" {
+		return false, error
+	}
+	if tolerance.allows(obtained, expected) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("Difference of %g exceeds the tolerance", math.Abs(obtained-expected))
+}
+
+// toTolerance returns the epsilon given to a checker as a Tolerance.
+func toTolerance(epsilon interface{}) (tolerance Tolerance, errStr string) {
+	tolerance, ok := epsilon.(Tolerance)
+	if !ok {
+		if tolerance.Abs, ok = toFloat64(epsilon); !ok {
+			return tolerance, usageError("epsilon must be a number or a Tolerance")
+		}
+	}
+	if tolerance.Abs < 0 || tolerance.Rel < 0 || math.IsNaN(tolerance.Abs) || math.IsNaN(tolerance.Rel) {
+		return tolerance, usageError("epsilon must not be negative")
+	}
+	return tolerance, ""
+}
+
+// allows tells whether obtained and expected are equal up to the tolerance.
+func (tolerance Tolerance) allows(obtained, expected float64) bool {
+	if obtained == expected {
+		return true
+	}
+	diff := math.Abs(obtained - expected)
+	return diff <= tolerance.Abs || diff <= tolerance.Rel*math.Max(math.Abs(obtained), math.Abs(expected))
+}
+
+// -----------------------------------------------------------------------
+// SliceAlmostEquals checker.
+
+// maxSliceMismatches is the most mismatched elements SliceAlmostEquals
+// reports.
+const maxSliceMismatches = 5
+
+type sliceAlmostEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The SliceAlmostEquals checker verifies that the obtained and expected
+// slices or arrays of numbers have the same length, and that their
+// elements are equal one by one up to an epsilon, as for AlmostEquals. On
+// failure, the first mismatched elements are listed with their index and
+// the difference between them.
+//
+// For example:
+//
+//     c.Assert(signal, SliceAlmostEquals, []float64{0, 0.707, 1, 0.707}, 1e-3)
+//
+var SliceAlmostEquals Checker = &sliceAlmostEqualsChecker{
+	&CheckerInfo{Name: "SliceAlmostEquals", Params: []string{"obtained", "expected", "epsilon"}},
+}
+
+func (checker *sliceAlmostEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained := reflect.ValueOf(params[0])
+	if obtained.Kind() != reflect.Slice && obtained.Kind() != reflect.Array {
+		return false, usageError("obtained value must be a slice or array")
+	}
+	expected := reflect.ValueOf(params[1])
+	if expected.Kind() != reflect.Slice && expected.Kind() != reflect.Array {
+		return false, usageError("expected value must be a slice or array")
+	}
+	tolerance, error := toTolerance(params[2])
+	if error != "" {
+		return false, error
+	}
+	if obtained.Len() != expected.Len() {
+		return false, fmt.Sprintf("Obtained length %d, expected length %d", obtained.Len(), expected.Len())
+	}
+	var mismatches []string
+	count := 0
+	for i := 0; i < obtained.Len(); i++ {
+		a, ok := toFloat64(obtained.Index(i).Interface())
+		if !ok {
+			return false, usageError(fmt.Sprintf("obtained element %d is not a number", i))
+		}
+		b, ok := toFloat64(expected.Index(i).Interface())
+		if !ok {
+			return false, usageError(fmt.Sprintf("expected element %d is not a number", i))
+		}
+		if tolerance.allows(a, b) {
+			continue
+		}
+		count++
+		if count <= maxSliceMismatches {
+			mismatches = append(mismatches, fmt.Sprintf("[%d]: obtained %s, expected %s, difference %g",
+				i, formatNumber(obtained.Index(i), a), formatNumber(expected.Index(i), b), math.Abs(a-b)))
+		}
+	}
+	if count == 0 {
+		return true, ""
+	}
+	if count > maxSliceMismatches {
+		mismatches = append(mismatches, fmt.Sprintf("... and %d more", count-maxSliceMismatches))
+	}
+	return false, fmt.Sprintf("%d of %d elements differ:\n", count, obtained.Len()) + strings.Join(mismatches, "\n")
+}
+
+// -----------------------------------------------------------------------
+// IsZero and NotZero checkers.
+
+type isZeroChecker struct {
+	*CheckerInfo
+}
+
+// The IsZero checker verifies that the obtained value is the zero value
+// of its type, such as 0, "", a nil pointer or a struct with all of its
+// fields zeroed. A bare nil (nil interface) value is considered zero.
+// When a struct isn't zero, its exported fields which aren't are listed.
+//
+// For example:
+//
+//     c.Assert(config.Timeout, IsZero)
+//
+var IsZero Checker = &isZeroChecker{
+	&CheckerInfo{Name: "IsZero", Params: []string{"value"}, Negated: "Value is zero"},
+}
+
+func (checker *isZeroChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	if isZero(params[0]) {
+		return true, ""
+	}
+	if fields := nonZeroFields(reflect.ValueOf(params[0])); len(fields) > 0 {
+		return false, "Non-zero fields: " + strings.Join(fields, ", ")
+	}
+	return false, ""
+}
+
+// nonZeroFields returns the names of the exported fields of the struct v
+// which aren't zero, or nil if v isn't a struct. Unexported fields are
+// left out, as they're details of types like time.Time.
+func nonZeroFields(v reflect.Value) []string {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var fields []string
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).PkgPath == "" && !v.Field(i).IsZero() {
+			fields = append(fields, v.Type().Field(i).Name)
+		}
+	}
+	return fields
+}
+
+type notZeroChecker struct {
+	*CheckerInfo
+}
+
+// The NotZero checker verifies that the obtained value is not the zero
+// value of its type. A bare nil (nil interface) value is considered zero.
+//
+// For example:
+//
+//     c.Assert(user.ID, NotZero)
+//
+var NotZero Checker = &notZeroChecker{
+	&CheckerInfo{Name: "NotZero", Params: []string{"value"}},
+}
+
+func (checker *notZeroChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	if params[0] == nil {
+		return false, "Value is a nil interface, which is zero"
+	}
+	return !isZero(params[0]), ""
+}
+
+func isZero(obtained interface{}) bool {
+	if obtained == nil {
+		return true
+	}
+	return reflect.ValueOf(obtained).IsZero()
+}
+
+// -----------------------------------------------------------------------
+// HasExactKeys checker.
+
+type hasExactKeysChecker struct {
+	*CheckerInfo
+}
+
+// The HasExactKeys checker verifies that the keys of the obtained map are
+// exactly the provided ones, in any order. Keys missing from the map and
+// unexpected keys found in it are reported separately. The provided keys
+// must be a slice with elements assignable to the map's key type.
+//
+// For example:
+//
+//     c.Assert(config, HasExactKeys, []string{"host", "port"})
+//
+var HasExactKeys Checker = &hasExactKeysChecker{
+	&CheckerInfo{Name: "HasExactKeys", Params: []string{"obtained", "keys"}},
+}
+
+func (checker *hasExactKeysChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	m := reflect.ValueOf(params[0])
+	if m.Kind() != reflect.Map {
+		return false, usageError("obtained value is not a map")
+	}
+	keys := reflect.ValueOf(params[1])
+	if keys.Kind() != reflect.Slice && keys.Kind() != reflect.Array {
+		return false, usageError("keys must be a slice")
+	}
+	if !keys.Type().Elem().AssignableTo(m.Type().Key()) {
+		return false, usageError(fmt.Sprintf("keys of type %s can't be keys of %s", keys.Type(), m.Type()))
+	}
+
+	expected := make(map[interface{}]bool)
+	var missing, extra []string
+	for i := 0; i < keys.Len(); i++ {
+		key := keys.Index(i)
+		expected[key.Interface()] = true
+		if !m.MapIndex(key).IsValid() {
+			missing = append(missing, goSyntax(key.Interface()))
+		}
+	}
+	for _, key := range m.MapKeys() {
+		if !expected[key.Interface()] {
+			extra = append(extra, goSyntax(key.Interface()))
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return true, ""
+	}
+	var problems []string
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		problems = append(problems, "Missing keys: "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		sort.Strings(extra)
+		problems = append(problems, "Unexpected keys: "+strings.Join(extra, ", "))
+	}
+	return false, strings.Join(problems, "\n")
+}
+
+// -----------------------------------------------------------------------
+// HappensBefore and HappensBeforeBy checkers.
+
+type happensBeforeChecker struct {
+	*CheckerInfo
+}
+
+// The HappensBefore checker verifies that the obtained time.Time is
+// strictly earlier than the later one. On failure the actual gap between
+// both times is reported.
+//
+// For example:
+//
+//     c.Assert(started, HappensBefore, finished)
+//
+var HappensBefore Checker = &happensBeforeChecker{
+	&CheckerInfo{Name: "HappensBefore", Params: []string{"obtained", "later"}},
+}
+
+func (checker *happensBeforeChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	gap, error := timeGap(params[0], params[1])
+	if error != "" {
+		return false, error
+	}
+	if gap > 0 {
+		return true, ""
+	}
+	return false, describeTimeGap(gap)
+}
+
+type happensBeforeByChecker struct {
+	*CheckerInfo
+}
+
+// The HappensBeforeBy checker verifies that the obtained time.Time
+// precedes the later one by at least the given time.Duration.
+//
+// For example:
+//
+//     c.Assert(sent, HappensBeforeBy, retried, 100*time.Millisecond)
+//
+var HappensBeforeBy Checker = &happensBeforeByChecker{
+	&CheckerInfo{Name: "HappensBeforeBy", Params: []string{"obtained", "later", "gap"}},
+}
+
+func (checker *happensBeforeByChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	gap, error := timeGap(params[0], params[1])
+	if error != "" {
+		return false, error
+	}
+	min, ok := params[2].(time.Duration)
+	if !ok {
+		return false, usageError("gap must be a time.Duration")
+	}
+	if min < 0 {
+		return false, usageError("gap must not be negative")
+	}
+	if gap > 0 && gap >= min {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s, expected it to be at least %s before", describeTimeGap(gap), min)
+}
+
+// timeGap returns how long before later the obtained time is.
+func timeGap(obtained, later interface{}) (gap time.Duration, error string) {
+	t1, ok := obtained.(time.Time)
+	if !ok {
+		return 0, usageError("obtained value is not a time.Time")
+	}
+	t2, ok := later.(time.Time)
+	if !ok {
+		return 0, usageError("later value is not a time.Time")
+	}
+	return t2.Sub(t1), ""
+}
+
+func describeTimeGap(gap time.Duration) string {
+	switch {
+	case gap == 0:
+		return "Obtained time is equal to the later one"
+	case gap < 0:
+		return fmt.Sprintf("Obtained time is %s after the later one", -gap)
+	}
+	return fmt.Sprintf("Obtained time is %s before the later one", gap)
+}
+
+// -----------------------------------------------------------------------
+// WithinDuration checker.
+
+type withinDurationChecker struct {
+	*CheckerInfo
+}
+
+// The WithinDuration checker verifies that the obtained time.Time is at
+// most the given time.Duration away from the expected one, either way.
+// Times in different locations are compared as instants, and monotonic
+// clock readings are only used when both times have one, as with Sub.
+//
+// For example:
+//
+//     c.Assert(record.Created, WithinDuration, time.Now(), 5*time.Second)
+//
+var WithinDuration Checker = &withinDurationChecker{
+	&CheckerInfo{Name: "WithinDuration", Params: []string{"obtained", "expected", "duration"}},
+}
+
+func (checker *withinDurationChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, ok := params[0].(time.Time)
+	if !ok {
+		return false, usageError("obtained value is not a time.Time")
+	}
+	expected, ok := params[1].(time.Time)
+	if !ok {
+		return false, usageError("expected value is not a time.Time")
+	}
+	max, ok := params[2].(time.Duration)
+	if !ok {
+		return false, usageError("duration must be a time.Duration")
+	}
+	if max < 0 {
+		return false, usageError("duration must not be negative")
+	}
+	diff := obtained.Sub(expected)
+	if diff >= -max && diff <= max {
+		return true, ""
+	}
+	if diff < 0 {
+		return false, fmt.Sprintf("Obtained time is %s before the expected one, more than %s", -diff, max)
+	}
+	return false, fmt.Sprintf("Obtained time is %s after the expected one, more than %s", diff, max)
+}
+
+// -----------------------------------------------------------------------
+// IsFinite, IsNaN and NotNaN checkers.
+
+type isFiniteChecker struct {
+	*CheckerInfo
+}
+
+// The IsFinite checker verifies that the obtained float32 or float64 value
+// is neither NaN nor infinite.
+//
+// For example:
+//
+//     c.Assert(mean, IsFinite)
+//
+var IsFinite Checker = &isFiniteChecker{
+	&CheckerInfo{Name: "IsFinite", Params: []string{"value"}},
+}
+
+func (checker *isFiniteChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f, error := floatValue(params[0])
+	if error != "" {
+		return false, error
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return false, fmt.Sprintf("expected a finite number, got %v", f)
+	}
+	return true, ""
+}
+
+type isNaNChecker struct {
+	*CheckerInfo
+}
+
+// The IsNaN checker verifies that the obtained float32 or float64 value is
+// NaN, which can't be verified with Equals since NaN isn't equal to itself.
+//
+// For example:
+//
+//     c.Assert(math.Sqrt(-1), IsNaN)
+//
+var IsNaN Checker = &isNaNChecker{
+	&CheckerInfo{Name: "IsNaN", Params: []string{"value"}},
+}
+
+func (checker *isNaNChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f, error := floatValue(params[0])
+	if error != "" {
+		return false, error
+	}
+	return math.IsNaN(f), ""
+}
+
+type notNaNChecker struct {
+	*CheckerInfo
+}
+
+// The NotNaN checker verifies that the obtained float32 or float64 value
+// is not NaN. It reads better than Not(IsNaN) and also rejects values which
+// aren't floats. Infinite values pass; use IsFinite to reject them too.
+//
+// For example:
+//
+//     c.Assert(ratio, NotNaN)
+//
+var NotNaN Checker = &notNaNChecker{
+	&CheckerInfo{Name: "NotNaN", Params: []string{"value"}},
+}
+
+func (checker *notNaNChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f, error := floatValue(params[0])
+	if error != "" {
+		return false, error
+	}
+	if math.IsNaN(f) {
+		return false, "expected a number, got NaN"
+	}
+	return true, ""
+}
+
+// floatValue returns the value of a float32 or float64.
+func floatValue(value interface{}) (f float64, error string) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), ""
+	case reflect.Invalid:
+		return 0, usageError("value must be a float, got nil")
+	}
+	return 0, usageError(fmt.Sprintf("value must be a float, got %s", v.Kind()))
+}
+
+// -----------------------------------------------------------------------
+// JSONEquals checker.
+
+type jsonEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The JSONEquals checker verifies that the obtained value and the expected
+// value are the same JSON document, regardless of whitespace and of the
+// order of object keys. Strings, []byte and json.RawMessage values are
+// parsed as JSON, while other values are compared as json.Marshal encodes
+// them. Numbers are compared by value, so 1 and 1.0 are equal, without
+// rounding large integers. When the documents differ, the paths where
+// they do are listed.
+//
+// For example:
+//
+//     c.Assert(body, JSONEquals, `{"id": 1, "tags": ["a", "b"]}`)
+//     c.Assert(body, JSONEquals, map[string]interface{}{"id": 1})
+//
+var JSONEquals Checker = &jsonEqualsChecker{
+	&CheckerInfo{Name: "JSONEquals", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *jsonEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, err := jsonDocument(params[0])
+	if err != nil {
+		return false, usageError("obtained value is not valid JSON: " + err.Error())
+	}
+	expected, err := jsonDocument(params[1])
+	if err != nil {
+		return false, usageError("expected value is not valid JSON: " + err.Error())
+	}
+	return documentsEqual(obtained, expected)
+}
+
+// jsonDocument returns value decoded into the generic form of a JSON
+// document, after encoding it first unless it already holds JSON. Numbers
+// are kept as json.Number, so that large integers aren't rounded.
+func jsonDocument(value interface{}) (interface{}, error) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	// Unmarshal reports invalid documents, including trailing data, as
+	// the decoder doesn't.
+	if err := json.Unmarshal(data, new(json.RawMessage)); err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// jsonNumbersEqual returns whether a and b are the same number, however
+// they're written.
+func jsonNumbersEqual(a, b json.Number) bool {
+	x, okx := new(big.Rat).SetString(string(a))
+	y, oky := new(big.Rat).SetString(string(b))
+	if !okx || !oky {
+		return a == b
+	}
+	return x.Cmp(y) == 0
+}
+
+// maxExactFloatInt is the largest integer up to which all integers are
+// held exactly by a float64.
+const maxExactFloatInt = 1 << 53
+
+// plainJSONNumbers returns value, part of a document from jsonDocument,
+// with its numbers converted into float64, or into int64 or uint64 for
+// integers which a float64 can't hold exactly.
+func plainJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if i > maxExactFloatInt || i < -maxExactFloatInt {
+				return i
+			}
+		} else if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		plain := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			plain[key] = plainJSONNumbers(elem)
+		}
+		return plain
+	case []interface{}:
+		plain := make([]interface{}, len(v))
+		for i, elem := range v {
+			plain[i] = plainJSONNumbers(elem)
+		}
+		return plain
+	}
+	return value
+}
+
+// maxDocumentDiffs is the most differences between documents listed.
+const maxDocumentDiffs = 10
+
+// documentsEqual compares two documents in their generic form, made of
+// maps, []interface{} and scalars, and lists the paths where they differ.
+func documentsEqual(obtained, expected interface{}) (result bool, error string) {
+	diffs := documentDiff("$", obtained, expected, nil)
+	if len(diffs) == 0 {
+		return true, ""
+	}
+	if len(diffs) > maxDocumentDiffs {
+		diffs = append(diffs[:maxDocumentDiffs], fmt.Sprintf("and %d more differences", len(diffs)-maxDocumentDiffs))
+	}
+	return false, "Documents differ:\n" + strings.Join(diffs, "\n")
+}
+
+// documentDiff appends to diffs a line for each path under path where
+// obtained and expected differ.
+func documentDiff(path string, obtained, expected interface{}, diffs []string) []string {
+	switch o := obtained.(type) {
+	case map[string]interface{}:
+		e, ok := expected.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(e))
+		for key := range o {
+			keys = append(keys, key)
+		}
+		for key := range e {
+			if _, ok := o[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := documentPath(path, key)
+			ov, inObtained := o[key]
+			ev, inExpected := e[key]
+			switch {
+			case !inObtained:
+				diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", keyPath, documentValue(ev)))
+			case !inExpected:
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", keyPath, documentValue(ov)))
+			default:
+				diffs = documentDiff(keyPath, ov, ev, diffs)
+			}
+		}
+		return diffs
+	case []interface{}:
+		e, ok := expected.([]interface{})
+		if !ok {
+			break
+		}
+		if len(o) != len(e) {
+			return append(diffs, fmt.Sprintf("%s: obtained %d elements, expected %d", path, len(o), len(e)))
+		}
+		for i := range o {
+			diffs = documentDiff(fmt.Sprintf("%s[%d]", path, i), o[i], e[i], diffs)
+		}
+		return diffs
+	}
+	if o, ok := obtained.(json.Number); ok {
+		if e, ok := expected.(json.Number); ok && jsonNumbersEqual(o, e) {
+			return diffs
+		}
+	}
+	if !reflect.DeepEqual(obtained, expected) {
+		diffs = append(diffs, fmt.Sprintf("%s: obtained %s, expected %s", path, documentValue(obtained), documentValue(expected)))
+	}
+	return diffs
+}
+
+var documentKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// documentPath returns the path of key in the object at path.
+func documentPath(path, key string) string {
+	if documentKeyRe.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s[%q]", path, key)
+}
+
+// documentValue returns value written out as JSON, for messages.
+func documentValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+	return string(data)
+}
+
+// -----------------------------------------------------------------------
+// JSONPath checker.
+
+type jsonPathChecker struct {
+	info    *CheckerInfo
+	path    string
+	checker Checker
+}
+
+// The JSONPath checker verifies that the given checker succeeds on the
+// value found at path in the obtained JSON document, given the remaining
+// arguments. The document is parsed as for JSONEquals, so objects are
+// map[string]interface{} and arrays []interface{}. Numbers are float64,
+// except for integers too large to be held exactly by one, which are
+// int64, or uint64 if they don't fit that either.
+// The path is made of object keys and array indexes separated by dots, as
+// in GJSON, with "#" standing for the length of an array. Dots in keys may
+// be escaped with a backslash.
+//
+// For example:
+//
+//     c.Assert(body, JSONPath("items.0.id", Equals), "abc")
+//     c.Assert(body, JSONPath("items.#", Equals), 3.0)
+//     c.Assert(body, JSONPath(`labels.app\.kubernetes\.io/name`, Equals), "web")
+//
+func JSONPath(path string, checker Checker) Checker {
+	info := *checker.Info()
+	info.Name = fmt.Sprintf("JSONPath(%q, %s)", path, info.Name)
+	info.Params = append([]string{"document"}, info.Params[1:]...)
+	return &jsonPathChecker{&info, path, checker}
+}
+
+func (checker *jsonPathChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *jsonPathChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	doc, err := jsonDocument(params[0])
+	if err != nil {
+		return false, usageError("document is not valid JSON: " + err.Error())
+	}
+	value, errStr := lookupJSONPath(doc, checker.path)
+	if errStr != "" {
+		return false, errStr
+	}
+	// The checker gets copies, as checkers may change them.
+	subParams := append([]interface{}{plainJSONNumbers(value)}, params[1:]...)
+	subNames := append([]string{}, checker.checker.Info().Params...)
+	result, error = checker.checker.Check(subParams, subNames)
+	copy(params, subParams)
+	copy(names, subNames)
+	names[0] = checker.path
+	return result, error
+}
+
+// lookupJSONPath returns the value at path in doc, a JSON document in its
+// generic form, or explains why there's none.
+func lookupJSONPath(doc interface{}, path string) (value interface{}, errStr string) {
+	value = doc
+	var done []string
+	for _, key := range splitDottedPath(path) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			elem, ok := v[key]
+			if !ok {
+				return nil, fmt.Sprintf("No key %q in the object at %s", key, dottedPath(done))
+			}
+			value = elem
+		case []interface{}:
+			if key == "#" {
+				value = float64(len(v))
+				break
+			}
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 {
+				return nil, fmt.Sprintf("Key %q is not an index of the array at %s", key, dottedPath(done))
+			}
+			if i >= len(v) {
+				return nil, fmt.Sprintf("No index %d in the array at %s, of length %d", i, dottedPath(done), len(v))
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Sprintf("No key %q in %s, which is %s", key, dottedPath(done), documentValue(v))
+		}
+		done = append(done, key)
+	}
+	return value, ""
+}
+
+// splitDottedPath splits path into its keys, at the dots not escaped with a
+// backslash.
+func splitDottedPath(path string) []string {
+	var keys []string
+	var key []byte
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			key = append(key, path[i])
+		case path[i] == '.':
+			keys = append(keys, string(key))
+			key = key[:0]
+		default:
+			key = append(key, path[i])
+		}
+	}
+	return append(keys, string(key))
+}
+
+var dottedPathEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`)
+
+// dottedPath returns the path made of keys, for messages.
+func dottedPath(keys []string) string {
+	if len(keys) == 0 {
+		return "the root"
+	}
+	escaped := make([]string, len(keys))
+	for i, key := range keys {
+		escaped[i] = dottedPathEscaper.Replace(key)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// -----------------------------------------------------------------------
+// HasPath checker.
+
+type hasPathChecker struct {
+	info    *CheckerInfo
+	path    string
+	checker Checker
+}
+
+// The HasPath checker verifies that the given checker succeeds on the
+// value found at path in the obtained value, given the remaining
+// arguments. The path is made of map keys, struct field names and slice
+// or array indexes separated by dots, as for JSONPath, and pointers and
+// interfaces along it are followed. Map keys are matched as fmt.Sprint
+// writes them out.
+//
+// For example:
+//
+//     c.Assert(pod, HasPath("Spec.Containers.0.Image", Equals), "nginx:1.25")
+//     c.Assert(config, HasPath("servers.web.port", Equals), 8080)
+//
+func HasPath(path string, checker Checker) Checker {
+	info := *checker.Info()
+	info.Name = fmt.Sprintf("HasPath(%q, %s)", path, info.Name)
+	info.Params = append([]string{"value"}, info.Params[1:]...)
+	return &hasPathChecker{&info, path, checker}
+}
+
+func (checker *hasPathChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *hasPathChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	value, errStr := lookupPath(reflect.ValueOf(params[0]), checker.path)
+	if errStr != "" {
+		return false, errStr
+	}
+	// The checker gets copies, as checkers may change them.
+	subParams := append([]interface{}{value}, params[1:]...)
+	subNames := append([]string{}, checker.checker.Info().Params...)
+	result, error = checker.checker.Check(subParams, subNames)
+	copy(params, subParams)
+	copy(names, subNames)
+	names[0] = checker.path
+	return result, error
+}
+
+// lookupPath returns the value at path in v, or explains why there's none.
+func lookupPath(v reflect.Value, path string) (value interface{}, errStr string) {
+	var done []string
+	for _, key := range splitDottedPath(path) {
+		for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+			v = v.Elem()
+		}
+		if !v.IsValid() || v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			return nil, fmt.Sprintf("No key %q in %s, which is nil", key, dottedPath(done))
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			elem := reflect.Value{}
+			for _, k := range v.MapKeys() {
+				if fmt.Sprint(k) == key {
+					elem = v.MapIndex(k)
+					break
+				}
+			}
+			if !elem.IsValid() {
+				return nil, fmt.Sprintf("No key %q in the map at %s", key, dottedPath(done))
+			}
+			v = elem
+		case reflect.Struct:
+			field, ok := v.Type().FieldByName(key)
+			if !ok {
+				return nil, fmt.Sprintf("No field %q in the %s at %s", key, v.Type(), dottedPath(done))
+			}
+			if field.PkgPath != "" {
+				return nil, fmt.Sprintf("Field %q of the %s at %s is unexported", key, v.Type(), dottedPath(done))
+			}
+			v = v.FieldByIndex(field.Index)
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 {
+				return nil, fmt.Sprintf("Key %q is not an index of the %s at %s", key, v.Type(), dottedPath(done))
+			}
+			if i >= v.Len() {
+				return nil, fmt.Sprintf("No index %d in the %s at %s, of length %d", i, v.Type(), dottedPath(done), v.Len())
+			}
+			v = v.Index(i)
+		default:
+			return nil, fmt.Sprintf("No key %q in %s, which is %s", key, dottedPath(done), goSyntax(v.Interface()))
+		}
+		done = append(done, key)
+	}
+	if !v.IsValid() {
+		return nil, ""
+	}
+	return v.Interface(), ""
+}
+
+// -----------------------------------------------------------------------
+// YAMLEquals checker.
+
+// YAMLUnmarshal decodes YAML documents for the YAMLEquals checker. So that
+// this package doesn't depend on a YAML library, it must be set to the
+// Unmarshal function of one first, such as gopkg.in/yaml.v3's:
+//
+//     check.YAMLUnmarshal = yaml.Unmarshal
+//
+var YAMLUnmarshal func(in []byte, out interface{}) error
+
+type yamlEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The YAMLEquals checker verifies that the obtained and expected strings
+// or []byte values are the same YAML document, regardless of formatting,
+// key order and anchors, which are resolved. When the documents differ,
+// the paths where they do are listed. YAMLUnmarshal must be set first.
+//
+// For example:
+//
+//     c.Assert(string(config), YAMLEquals, "name: app\nports: [80, 443]\n")
+//
+var YAMLEquals Checker = &yamlEqualsChecker{
+	&CheckerInfo{Name: "YAMLEquals", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *yamlEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	if YAMLUnmarshal == nil {
+		return false, usageError("YAMLUnmarshal must be set to decode YAML, e.g. to yaml.Unmarshal")
+	}
+	obtained, error := yamlDocument(params[0])
+	if error != "" {
+		return false, usageError("obtained value " + error)
+	}
+	expected, error := yamlDocument(params[1])
+	if error != "" {
+		return false, usageError("expected value " + error)
+	}
+	return documentsEqual(obtained, expected)
+}
+
+// yamlDocument returns value decoded with YAMLUnmarshal into the generic
+// form of a document, as used for JSON.
+func yamlDocument(value interface{}) (doc interface{}, error string) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return nil, fmt.Sprintf("must be a string or []byte, got %T", value)
+	}
+	if err := YAMLUnmarshal(data, &doc); err != nil {
+		return nil, "is not valid YAML: " + err.Error()
+	}
+	return genericDocument(doc), ""
+}
+
+// genericDocument converts the maps with keys of any type and the numbers
+// of any type which YAML libraries decode into the map[string]interface{}
+// and json.Number values that JSON documents are made of, so that values
+// which look the same compare equal, and large integers exactly.
+func genericDocument(doc interface{}) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = genericDocument(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = genericDocument(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = genericDocument(value)
+		}
+		return v
+	}
+	value := reflect.ValueOf(doc)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return json.Number(strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f := value.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			// JSON has no such numbers.
+			return f
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, value.Type().Bits()))
+	}
+	return doc
+}
+
+// -----------------------------------------------------------------------
+// ProtoEquals checker.
+
+// ProtoEqual compares protocol buffer messages for the ProtoEquals checker.
+// So that this package doesn't depend on a protobuf library, it must be
+// set first, such as to a function calling google.golang.org/protobuf's
+// proto.Equal:
+//
+//     check.ProtoEqual = func(a, b interface{}) bool {
+//         return proto.Equal(a.(proto.Message), b.(proto.Message))
+//     }
+//
+var ProtoEqual func(a, b interface{}) bool
+
+// protoStateFields holds the names of the fields of generated messages
+// which hold their internal state rather than their content.
+var protoStateFields = map[string]bool{
+	"state":                true,
+	"sizeCache":            true,
+	"unknownFields":        true,
+	"XXX_NoUnkeyedLiteral": true,
+	"XXX_unrecognized":     true,
+	"XXX_sizecache":        true,
+}
+
+type protoEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The ProtoEquals checker verifies that the obtained and expected protocol
+// buffer messages are equal as ProtoEqual tells, which must be set first.
+// When they aren't, the path to the first field where they differ is
+// reported, skipping the internal state of generated messages, or the
+// messages are said to differ in their unknown fields if no other field
+// does.
+//
+// For example:
+//
+//     c.Assert(resp, ProtoEquals, &pb.User{Id: 1, Name: "alice"})
+//
+var ProtoEquals Checker = &protoEqualsChecker{
+	&CheckerInfo{Name: "ProtoEquals", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *protoEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	if ProtoEqual == nil {
+		return false, usageError("ProtoEqual must be set to compare messages, e.g. to a func calling proto.Equal")
+	}
+	if ProtoEqual(params[0], params[1]) {
+		return true, ""
+	}
+	d := &deepCompare{ignoredFields: protoStateFields}
+	if diff := d.diff(params[0], params[1]); diff != "" {
+		return false, diff
+	}
+	return false, "Messages differ in their unknown fields"
+}
+
+// -----------------------------------------------------------------------
+// XMLEquals checker.
+
+type xmlEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The XMLEquals checker verifies that the obtained value and the expected
+// value are the same XML document, regardless of the order of attributes,
+// of the prefixes bound to namespaces, of comments and of the whitespace
+// around text and between elements. Strings and []byte values are parsed
+// as XML, while other values are compared as xml.Marshal encodes them.
+// When the documents differ, the paths of the elements where they do are
+// listed.
+//
+// For example:
+//
+//     c.Assert(body, XMLEquals, `<user id="1"><name>alice</name></user>`)
+//
+var XMLEquals Checker = &xmlEqualsChecker{
+	&CheckerInfo{Name: "XMLEquals", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *xmlEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, err := xmlDocument(params[0])
+	if err != nil {
+		return false, usageError("obtained value is not valid XML: " + err.Error())
+	}
+	expected, err := xmlDocument(params[1])
+	if err != nil {
+		return false, usageError("expected value is not valid XML: " + err.Error())
+	}
+	diffs := xmlDiff("/"+obtained.name.Local, obtained, expected, nil)
+	if len(diffs) == 0 {
+		return true, ""
+	}
+	if len(diffs) > maxDocumentDiffs {
+		diffs = append(diffs[:maxDocumentDiffs], fmt.Sprintf("and %d more differences", len(diffs)-maxDocumentDiffs))
+	}
+	return false, "Documents differ:\n" + strings.Join(diffs, "\n")
+}
+
+// xmlNode is an element of an XML document, or the text in one when it
+// has no name.
+type xmlNode struct {
+	name     xml.Name
+	attrs    map[xml.Name]string
+	children []*xmlNode
+	text     string
+}
+
+// xmlDocument returns the root element of value, after encoding it first
+// unless it already holds XML.
+func xmlDocument(value interface{}) (*xmlNode, error) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		var err error
+		if data, err = xml.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var root *xmlNode
+	var open []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: make(map[xml.Name]string)}
+			for _, attr := range t.Attr {
+				// Namespace declarations only bind prefixes.
+				if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					continue
+				}
+				node.attrs[attr.Name] = attr.Value
+			}
+			if len(open) > 0 {
+				parent := open[len(open)-1]
+				parent.children = append(parent.children, node)
+			} else if root != nil {
+				return nil, errors.New("more than one root element")
+			} else {
+				root = node
+			}
+			open = append(open, node)
+		case xml.EndElement:
+			open = open[:len(open)-1]
+		case xml.CharData:
+			if len(open) == 0 {
+				continue
+			}
+			parent := open[len(open)-1]
+			if n := len(parent.children); n > 0 && parent.children[n-1].name.Local == "" {
+				parent.children[n-1].text += string(t)
+			} else {
+				parent.children = append(parent.children, &xmlNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	root.trimText()
+	return root, nil
+}
+
+// trimText trims the whitespace around the text of node and of the
+// elements in it, dropping the text which is only whitespace.
+func (node *xmlNode) trimText() {
+	children := node.children[:0]
+	for _, child := range node.children {
+		if child.name.Local == "" {
+			if child.text = strings.TrimSpace(child.text); child.text == "" {
+				continue
+			}
+		} else {
+			child.trimText()
+		}
+		children = append(children, child)
+	}
+	node.children = children
+}
+
+// String describes node, for messages.
+func (node *xmlNode) String() string {
+	if node.name.Local == "" {
+		return fmt.Sprintf("text %q", node.text)
+	}
+	return "element " + xmlName(node.name)
+}
+
+// xmlName returns name with its namespace, if it has one.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+// xmlDiff appends to diffs a line for each difference between the
+// elements obtained and expected, and the elements in them, found at path.
+func xmlDiff(path string, obtained, expected *xmlNode, diffs []string) []string {
+	if obtained.name != expected.name {
+		return append(diffs, fmt.Sprintf("%s: obtained %s, expected %s", path, obtained, expected))
+	}
+	if obtained.name.Local == "" {
+		if obtained.text != expected.text {
+			diffs = append(diffs, fmt.Sprintf("%s: obtained %q, expected %q", path, obtained.text, expected.text))
+		}
+		return diffs
+	}
+	names := make([]xml.Name, 0, len(obtained.attrs)+len(expected.attrs))
+	for name := range obtained.attrs {
+		names = append(names, name)
+	}
+	for name := range expected.attrs {
+		if _, ok := obtained.attrs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return xmlName(names[i]) < xmlName(names[j]) })
+	for _, name := range names {
+		o, inObtained := obtained.attrs[name]
+		e, inExpected := expected.attrs[name]
+		attrPath := path + "/@" + xmlName(name)
+		switch {
+		case !inExpected:
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %q", attrPath, o))
+		case !inObtained:
+			diffs = append(diffs, fmt.Sprintf("%s: missing, expected %q", attrPath, e))
+		case o != e:
+			diffs = append(diffs, fmt.Sprintf("%s: obtained %q, expected %q", attrPath, o, e))
+		}
+	}
+	for i := 0; i < len(obtained.children) || i < len(expected.children); i++ {
+		switch {
+		case i >= len(expected.children):
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", path, obtained.children[i]))
+		case i >= len(obtained.children):
+			diffs = append(diffs, fmt.Sprintf("%s: missing %s", path, expected.children[i]))
+		default:
+			diffs = xmlDiff(xmlChildPath(path, obtained.children, i), obtained.children[i], expected.children[i], diffs)
+		}
+	}
+	return diffs
+}
+
+// xmlChildPath returns the path of the i-th of children, elements of the
+// element at path, in the style of XPath.
+func xmlChildPath(path string, children []*xmlNode, i int) string {
+	name := children[i].name
+	if name.Local == "" {
+		return path + "/text()"
+	}
+	position, count := 0, 0
+	for j, child := range children {
+		if child.name == name {
+			count++
+			if j <= i {
+				position++
+			}
+		}
+	}
+	if count > 1 {
+		return fmt.Sprintf("%s/%s[%d]", path, name.Local, position)
+	}
+	return path + "/" + name.Local
+}
+
+// -----------------------------------------------------------------------
+// Contains checker.
+
+type containsChecker struct {
+	*CheckerInfo
+}
+
+// The Contains checker verifies that the obtained container holds the
+// element: that a string contains it as a substring, that a slice or array
+// has an element deep-equal to it, or that a map has it as a key.
+//
+// For example:
+//
+//     c.Assert(output, Contains, "done")
+//     c.Assert(names, Contains, "alice")
+//     c.Assert(config, Contains, "port")
+//
+var Contains Checker = &containsChecker{
+	&CheckerInfo{Name: "Contains", Params: []string{"container", "element"}, Negated: "Container contains {element}"},
+}
+
+func (checker *containsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	container := reflect.ValueOf(params[0])
+	switch container.Kind() {
+	case reflect.String:
+		element, ok := params[1].(string)
+		if !ok {
+			return false, usageError("element must be a string to look for in a string")
+		}
+		return strings.Contains(container.String(), element), ""
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < container.Len(); i++ {
+			if reflect.DeepEqual(container.Index(i).Interface(), params[1]) {
+				return true, ""
+			}
+		}
+		return false, ""
+	case reflect.Map:
+		key, error := mapKey(container, params[1])
+		if error != "" {
+			return false, error
+		}
+		return container.MapIndex(key).IsValid(), ""
+	}
+	return false, usageError("container must be a string, slice, array or map")
+}
+
+// mapKey returns key as a key of the map m.
+func mapKey(m reflect.Value, key interface{}) (v reflect.Value, error string) {
+	keyType := m.Type().Key()
+	if key == nil {
+		switch keyType.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Chan:
+			return reflect.Zero(keyType), ""
+		}
+		return v, usageError(fmt.Sprintf("nil can't be a key of %s", m.Type()))
+	}
+	v = reflect.ValueOf(key)
+	if !v.Type().AssignableTo(keyType) {
+		return v, usageError(fmt.Sprintf("%s can't be a key of %s", v.Type(), m.Type()))
+	}
+	return v, ""
+}
+
+// -----------------------------------------------------------------------
+// ElementsMatch checker.
+
+type elementsMatchChecker struct {
+	*CheckerInfo
+}
+
+// The ElementsMatch checker verifies that the obtained and expected slices
+// or arrays hold the same elements, compared with DeepEquals, in any
+// order. Repeated elements must be repeated as many times in both. On
+// failure, the missing and unexpected elements are listed separately.
+//
+// For example:
+//
+//     c.Assert(names, ElementsMatch, []string{"bob", "alice"})
+//
+var ElementsMatch Checker = &elementsMatchChecker{
+	&CheckerInfo{Name: "ElementsMatch", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *elementsMatchChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained := reflect.ValueOf(params[0])
+	if obtained.Kind() != reflect.Slice && obtained.Kind() != reflect.Array {
+		return false, usageError("obtained value must be a slice or array")
+	}
+	expected := reflect.ValueOf(params[1])
+	if expected.Kind() != reflect.Slice && expected.Kind() != reflect.Array {
+		return false, usageError("expected value must be a slice or array")
+	}
+	extra, missing := unmatchedElements(obtained, expected)
+	if len(missing) == 0 && len(extra) == 0 {
+		return true, ""
+	}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "Missing elements: "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, "Unexpected elements: "+strings.Join(extra, ", "))
+	}
+	return false, strings.Join(problems, "\n")
+}
+
+// unmatchedElements pairs each element of the slice or array a with a
+// deep-equal one of b, and returns those left over in either, formatted.
+func unmatchedElements(a, b reflect.Value) (extra, missing []string) {
+	matched := make([]bool, b.Len())
+	for i := 0; i < a.Len(); i++ {
+		element := a.Index(i).Interface()
+		found := false
+		for j := 0; j < b.Len(); j++ {
+			if !matched[j] && reflect.DeepEqual(element, b.Index(j).Interface()) {
+				matched[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			extra = append(extra, goSyntax(element))
+		}
+	}
+	for j := 0; j < b.Len(); j++ {
+		if !matched[j] {
+			missing = append(missing, goSyntax(b.Index(j).Interface()))
+		}
+	}
+	return extra, missing
+}
+
+// -----------------------------------------------------------------------
+// UniqueElements checker.
+
+type uniqueElementsChecker struct {
+	*CheckerInfo
+}
+
+// The UniqueElements checker verifies that no two elements of the obtained
+// slice or array are deep-equal. On failure, each duplicated element is
+// listed with the indices it's found at.
+//
+// For example:
+//
+//     c.Assert(ids, UniqueElements)
+//
+var UniqueElements Checker = &uniqueElementsChecker{
+	&CheckerInfo{Name: "UniqueElements", Params: []string{"obtained"}},
+}
+
+func (checker *uniqueElementsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained := reflect.ValueOf(params[0])
+	if obtained.Kind() != reflect.Slice && obtained.Kind() != reflect.Array {
+		return false, usageError("obtained value must be a slice or array")
+	}
+	var problems []string
+	counted := make([]bool, obtained.Len())
+	for i := 0; i < obtained.Len(); i++ {
+		if counted[i] {
+			continue
+		}
+		element := obtained.Index(i).Interface()
+		indices := []string{strconv.Itoa(i)}
+		for j := i + 1; j < obtained.Len(); j++ {
+			if !counted[j] && reflect.DeepEqual(element, obtained.Index(j).Interface()) {
+				counted[j] = true
+				indices = append(indices, strconv.Itoa(j))
+			}
+		}
+		if len(indices) > 1 {
+			problems = append(problems, fmt.Sprintf("Duplicated element %s at indices %s", goSyntax(element), strings.Join(indices, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return false, strings.Join(problems, "\n")
+	}
+	return true, ""
+}
+
+// -----------------------------------------------------------------------
+// IsSubsetOf and ContainsAll checkers.
+
+type isSubsetOfChecker struct {
+	*CheckerInfo
+}
+
+// The IsSubsetOf checker verifies that every element of the obtained slice
+// or array is deep-equal to some element of the superset, or that every
+// key of the obtained map is in the superset map with a deep-equal value.
+// The offending elements or entries are listed on failure.
+//
+// For example:
+//
+//     c.Assert(granted, IsSubsetOf, []string{"read", "write", "admin"})
+//     c.Assert(labels, IsSubsetOf, map[string]string{"app": "web", "env": "prod"})
+//
+var IsSubsetOf Checker = &isSubsetOfChecker{
+	&CheckerInfo{Name: "IsSubsetOf", Params: []string{"obtained", "superset"}},
+}
+
+func (checker *isSubsetOfChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	offending, error := notInSuperset(params[0], params[1], "obtained value", "superset")
+	if error != "" {
+		return false, error
+	}
+	if len(offending) > 0 {
+		return false, "Not in the superset: " + strings.Join(offending, ", ")
+	}
+	return true, ""
+}
+
+type containsAllChecker struct {
+	*CheckerInfo
+}
+
+// The ContainsAll checker verifies that the obtained slice or array has
+// an element deep-equal to each of the given ones, or that the obtained
+// map has each of the given entries. It's the reverse of IsSubsetOf, and
+// the missing elements or entries are listed on failure.
+//
+// For example:
+//
+//     c.Assert(granted, ContainsAll, []string{"read", "write"})
+//     c.Assert(headers, ContainsAll, map[string]string{"Content-Type": "text/plain"})
+//
+var ContainsAll Checker = &containsAllChecker{
+	&CheckerInfo{Name: "ContainsAll", Params: []string{"obtained", "elements"}},
+}
+
+func (checker *containsAllChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	missing, error := notInSuperset(params[1], params[0], "elements", "obtained value")
+	if error != "" {
+		return false, error
+	}
+	if len(missing) > 0 {
+		return false, "Missing: " + strings.Join(missing, ", ")
+	}
+	return true, ""
+}
+
+// notInSuperset returns the elements of the slice or array subset which
+// aren't in the slice or array superset, or the entries of the map subset
+// which the map superset doesn't have, formatted. The names of both values
+// are used in errors about their types.
+func notInSuperset(subset, superset interface{}, subsetName, supersetName string) (offending []string, error string) {
+	sub := reflect.ValueOf(subset)
+	super := reflect.ValueOf(superset)
+	switch sub.Kind() {
+	case reflect.Slice, reflect.Array:
+		if super.Kind() != reflect.Slice && super.Kind() != reflect.Array {
+			return nil, usageError(supersetName + " must be a slice or array")
+		}
+	elements:
+		for i := 0; i < sub.Len(); i++ {
+			element := sub.Index(i).Interface()
+			for j := 0; j < super.Len(); j++ {
+				if reflect.DeepEqual(element, super.Index(j).Interface()) {
+					continue elements
+				}
+			}
+			offending = append(offending, goSyntax(element))
+		}
+		return offending, ""
+	case reflect.Map:
+		if super.Kind() != reflect.Map {
+			return nil, usageError(supersetName + " must be a map")
+		}
+		if !sub.Type().Key().AssignableTo(super.Type().Key()) {
+			return nil, usageError(fmt.Sprintf("keys of %s can't be keys of %s", sub.Type(), super.Type()))
+		}
+		for _, key := range sub.MapKeys() {
+			value := sub.MapIndex(key).Interface()
+			other := super.MapIndex(key)
+			if !other.IsValid() {
+				offending = append(offending, fmt.Sprintf("%s: %s", goSyntax(key.Interface()), goSyntax(value)))
+			} else if !reflect.DeepEqual(value, other.Interface()) {
+				offending = append(offending, fmt.Sprintf("%s: %s (%s has %s)",
+					goSyntax(key.Interface()), goSyntax(value), supersetName, goSyntax(other.Interface())))
+			}
+		}
+		sort.Strings(offending)
+		return offending, ""
+	}
+	return nil, usageError(subsetName + " must be a slice, array or map")
+}
+
+// -----------------------------------------------------------------------
+// HasKey, HasValue and HasEntry checkers.
+
+type hasKeyChecker struct {
+	*CheckerInfo
+}
+
+// The HasKey checker verifies that the obtained map has the given key. On
+// failure, the keys it does have are listed.
+//
+// For example:
+//
+//     c.Assert(headers, HasKey, "Authorization")
+//
+var HasKey Checker = &hasKeyChecker{
+	&CheckerInfo{Name: "HasKey", Params: []string{"obtained", "key"}, Negated: "Obtained map has key {key}"},
+}
+
+func (checker *hasKeyChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	m := reflect.ValueOf(params[0])
+	if m.Kind() != reflect.Map {
+		return false, usageError("obtained value is not a map")
+	}
+	key, error := mapKey(m, params[1])
+	if error != "" {
+		return false, error
+	}
+	if m.MapIndex(key).IsValid() {
+		return true, ""
+	}
+	return false, fmt.Sprintf("Key %s not found, the map has: %s", goSyntax(params[1]), mapKeys(m))
+}
+
+type hasValueChecker struct {
+	*CheckerInfo
+}
+
+// The HasValue checker verifies that the obtained map has a value which
+// is deep-equal to the given one, under any key.
+//
+// For example:
+//
+//     c.Assert(owners, HasValue, "alice")
+//
+var HasValue Checker = &hasValueChecker{
+	&CheckerInfo{Name: "HasValue", Params: []string{"obtained", "value"}},
+}
+
+func (checker *hasValueChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	m := reflect.ValueOf(params[0])
+	if m.Kind() != reflect.Map {
+		return false, usageError("obtained value is not a map")
+	}
+	iter := m.MapRange()
+	for iter.Next() {
+		if reflect.DeepEqual(iter.Value().Interface(), params[1]) {
+			return true, ""
+		}
+	}
+	return false, ""
+}
+
+type hasEntryChecker struct {
+	*CheckerInfo
+}
+
+// The HasEntry checker verifies that the obtained map has the given key,
+// with a value deep-equal to the given one. On failure, it tells whether
+// the key is missing or which value it has instead.
+//
+// For example:
+//
+//     c.Assert(headers, HasEntry, "Content-Type", "application/json")
+//
+var HasEntry Checker = &hasEntryChecker{
+	&CheckerInfo{Name: "HasEntry", Params: []string{"obtained", "key", "value"}},
+}
+
+func (checker *hasEntryChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	m := reflect.ValueOf(params[0])
+	if m.Kind() != reflect.Map {
+		return false, usageError("obtained value is not a map")
+	}
+	key, error := mapKey(m, params[1])
+	if error != "" {
+		return false, error
+	}
+	value := m.MapIndex(key)
+	if !value.IsValid() {
+		return false, fmt.Sprintf("Key %s not found, the map has: %s", goSyntax(params[1]), mapKeys(m))
+	}
+	if reflect.DeepEqual(value.Interface(), params[2]) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("Key %s has value %s", goSyntax(params[1]), goSyntax(value.Interface()))
+}
+
+// mapKeys returns the keys of m, formatted and sorted.
+func mapKeys(m reflect.Value) string {
+	if m.Len() == 0 {
+		return "no keys"
+	}
+	keys := make([]string, 0, m.Len())
+	for _, key := range m.MapKeys() {
+		keys = append(keys, goSyntax(key.Interface()))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// -----------------------------------------------------------------------
+// GreaterThan, GreaterOrEqual, LessThan and LessOrEqual checkers.
+
+type orderChecker struct {
+	*CheckerInfo
+	accept func(cmp int) bool
+}
+
+// The GreaterThan checker verifies that the obtained value is greater
+// than the given one. Values may be numbers of any kind, compared exactly
+// even across kinds, time.Duration values or time.Time values, but both
+// must be numbers, both durations or both times.
+//
+// For example:
+//
+//     c.Assert(len(items), GreaterThan, 0)
+//     c.Assert(finished, GreaterThan, started)
+//
+var GreaterThan Checker = &orderChecker{
+	&CheckerInfo{Name: "GreaterThan", Params: []string{"obtained", "bound"}},
+	func(cmp int) bool { return cmp > 0 },
+}
+
+// The GreaterOrEqual checker verifies that the obtained value is greater
+// than or equal to the given one. See GreaterThan for the values allowed.
+//
+// For example:
+//
+//     c.Assert(retries, GreaterOrEqual, 1)
+//
+var GreaterOrEqual Checker = &orderChecker{
+	&CheckerInfo{Name: "GreaterOrEqual", Params: []string{"obtained", "bound"}},
+	func(cmp int) bool { return cmp >= 0 },
+}
+
+// The LessThan checker verifies that the obtained value is less than the
+// given one. See GreaterThan for the values allowed.
+//
+// For example:
+//
+//     c.Assert(elapsed, LessThan, time.Second)
+//
+var LessThan Checker = &orderChecker{
+	&CheckerInfo{Name: "LessThan", Params: []string{"obtained", "bound"}},
+	func(cmp int) bool { return cmp < 0 },
+}
+
+// The LessOrEqual checker verifies that the obtained value is less than or
+// equal to the given one. See GreaterThan for the values allowed.
+//
+// For example:
+//
+//     c.Assert(ratio, LessOrEqual, 1.0)
+//
+var LessOrEqual Checker = &orderChecker{
+	&CheckerInfo{Name: "LessOrEqual", Params: []string{"obtained", "bound"}},
+	func(cmp int) bool { return cmp <= 0 },
+}
+
+func (checker *orderChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	cmp, error := compareOrdered(params[0], params[1])
+	if error != "" {
+		return false, error
+	}
+	return checker.accept(cmp), ""
+}
+
+// compareOrdered returns -1, 0 or 1 as a is less than, equal to or greater
+// than b, which must both be numbers, both time.Duration values or both
+// time.Time values. Numbers are compared exactly, even when one is signed
+// and the other isn't, or one is an integer and the other a float.
+func compareOrdered(a, b interface{}) (cmp int, error string) {
+	_, aTime := a.(time.Time)
+	_, bTime := b.(time.Time)
+	_, aDuration := a.(time.Duration)
+	_, bDuration := b.(time.Duration)
+	if aTime != bTime || aDuration != bDuration {
+		return 0, usageError(fmt.Sprintf("can't compare %T with %T", a, b))
+	}
+	if aTime {
+		ta, tb := a.(time.Time), b.(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, ""
+		case ta.After(tb):
+			return 1, ""
+		}
+		return 0, ""
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	ka, kb := numberKind(va), numberKind(vb)
+	if ka == reflect.Invalid || kb == reflect.Invalid {
+		return 0, usageError(fmt.Sprintf("can't compare %T with %T", a, b))
+	}
+	switch {
+	case ka == reflect.Int && kb == reflect.Int:
+		return compareInts(va.Int(), vb.Int()), ""
+	case ka == reflect.Uint && kb == reflect.Uint:
+		return compareUints(va.Uint(), vb.Uint()), ""
+	case ka == reflect.Int && kb == reflect.Uint:
+		if va.Int() < 0 {
+			return -1, ""
+		}
+		return compareUints(uint64(va.Int()), vb.Uint()), ""
+	case ka == reflect.Uint && kb == reflect.Int:
+		if vb.Int() < 0 {
+			return 1, ""
+		}
+		return compareUints(va.Uint(), uint64(vb.Int())), ""
+	}
+	fa, _ := toFloat64(a)
+	fb, _ := toFloat64(b)
+	switch {
+	case math.IsNaN(fa) || math.IsNaN(fb):
+		return 0, usageError("can't compare NaN")
+	case ka != reflect.Float64:
+		return -compareFloatInteger(fb, va), ""
+	case kb != reflect.Float64:
+		return compareFloatInteger(fa, vb), ""
+	case fa < fb:
+		return -1, ""
+	case fa > fb:
+		return 1, ""
+	}
+	return 0, ""
+}
+
+// numberKind returns reflect.Int, reflect.Uint or reflect.Float64 for
+// signed integers, unsigned integers and floats, or reflect.Invalid.
+func numberKind(v reflect.Value) reflect.Kind {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	}
+	return reflect.Invalid
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUints(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// -----------------------------------------------------------------------
+// Between and StrictlyBetween checkers.
+
+type betweenChecker struct {
+	*CheckerInfo
+	exclusive bool
+}
+
+// The Between checker verifies that the obtained value lies within the
+// inclusive range from low to high. Like for GreaterThan, values may be
+// numbers of any kind, time.Duration values or time.Time values.
+//
+// For example:
+//
+//     c.Assert(latency, Between, 10*time.Millisecond, 50*time.Millisecond)
+//
+var Between Checker = &betweenChecker{
+	&CheckerInfo{Name: "Between", Params: []string{"obtained", "low", "high"}},
+	false,
+}
+
+// The StrictlyBetween checker verifies that the obtained value lies within
+// the exclusive range from low to high, so that it's equal to neither.
+//
+// For example:
+//
+//     c.Assert(probability, StrictlyBetween, 0.0, 1.0)
+//
+var StrictlyBetween Checker = &betweenChecker{
+	&CheckerInfo{Name: "StrictlyBetween", Params: []string{"obtained", "low", "high"}},
+	true,
+}
+
+func (checker *betweenChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	bounds, error := compareOrdered(params[1], params[2])
+	if error != "" {
+		return false, error
+	}
+	if bounds > 0 {
+		return false, usageError("low must not be greater than high")
+	}
+	low, error := compareOrdered(params[0], params[1])
+	if error != "" {
+		return false, error
+	}
+	high, _ := compareOrdered(params[0], params[2])
+	if checker.exclusive && low > 0 && high < 0 || !checker.exclusive && low >= 0 && high <= 0 {
+		return true, ""
+	}
+	if checker.exclusive {
+		return false, fmt.Sprintf("%v is not in (%v, %v)", params[0], params[1], params[2])
+	}
+	return false, fmt.Sprintf("%v is not in [%v, %v]", params[0], params[1], params[2])
+}
+
+// -----------------------------------------------------------------------
+// IsSorted, IsSortedDescending and IsSortedBy checkers.
+
+type isSortedChecker struct {
+	*CheckerInfo
+	descending bool
+}
+
+// The IsSorted checker verifies that the elements of the obtained slice or
+// array are in ascending order, with equal elements allowed next to each
+// other. Elements may be strings, or anything GreaterThan accepts. The
+// first pair of elements out of order is reported on failure.
+//
+// For example:
+//
+//     c.Assert(names, IsSorted)
+//
+var IsSorted Checker = &isSortedChecker{
+	&CheckerInfo{Name: "IsSorted", Params: []string{"obtained"}},
+	false,
+}
+
+// The IsSortedDescending checker verifies that the elements of the
+// obtained slice or array are in descending order, like IsSorted does for
+// ascending order.
+//
+// For example:
+//
+//     c.Assert(scores, IsSortedDescending)
+//
+var IsSortedDescending Checker = &isSortedChecker{
+	&CheckerInfo{Name: "IsSortedDescending", Params: []string{"obtained"}},
+	true,
+}
+
+func (checker *isSortedChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained := reflect.ValueOf(params[0])
+	if obtained.Kind() != reflect.Slice && obtained.Kind() != reflect.Array {
+		return false, usageError("obtained value must be a slice or array")
+	}
+	for i := 1; i < obtained.Len(); i++ {
+		a, b := obtained.Index(i-1).Interface(), obtained.Index(i).Interface()
+		var cmp int
+		if sa, ok := a.(string); ok {
+			sb, ok := b.(string)
+			if !ok {
+				return false, usageError(fmt.Sprintf("can't compare %T with %T", a, b))
+			}
+			cmp = strings.Compare(sa, sb)
+		} else if cmp, error = compareOrdered(a, b); error != "" {
+			return false, error
+		}
+		if checker.descending && cmp < 0 || !checker.descending && cmp > 0 {
+			return false, outOfOrder(obtained, i)
+		}
+	}
+	return true, ""
+}
+
+type isSortedByChecker struct {
+	*CheckerInfo
+}
+
+// The IsSortedBy checker verifies that the elements of the obtained slice
+// or array are in the order given by less, a func(a, b T) bool reporting
+// whether a must come before b, as for sort.Slice. The elements must be
+// assignable to T. The first pair of elements out of order is reported on
+// failure.
+//
+// For example:
+//
+//     c.Assert(users, IsSortedBy, func(a, b User) bool { return a.Age < b.Age })
+//
+var IsSortedBy Checker = &isSortedByChecker{
+	&CheckerInfo{Name: "IsSortedBy", Params: []string{"obtained", "less"}},
+}
+
+func (checker *isSortedByChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained := reflect.ValueOf(params[0])
+	if obtained.Kind() != reflect.Slice && obtained.Kind() != reflect.Array {
+		return false, usageError("obtained value must be a slice or array")
+	}
+	less := reflect.ValueOf(params[1])
+	if less.Kind() != reflect.Func || less.IsNil() || less.Type().NumIn() != 2 || less.Type().IsVariadic() ||
+		less.Type().In(0) != less.Type().In(1) || less.Type().NumOut() != 1 || less.Type().Out(0) != boolType {
+		return false, usageError("less must be a func(a, b T) bool")
+	}
+	if !obtained.Type().Elem().AssignableTo(less.Type().In(0)) {
+		return false, usageError(fmt.Sprintf("%s can't be passed to %s", obtained.Type().Elem(), less.Type()))
+	}
+	for i := 1; i < obtained.Len(); i++ {
+		if less.Call([]reflect.Value{obtained.Index(i), obtained.Index(i - 1)})[0].Bool() {
+			return false, outOfOrder(obtained, i)
+		}
+	}
+	return true, ""
+}
+
+// outOfOrder describes the elements at i-1 and i of the slice or array v,
+// which are out of order.
+func outOfOrder(v reflect.Value, i int) string {
+	return fmt.Sprintf("Elements %d and %d are out of order: %s, %s",
+		i-1, i, goSyntax(v.Index(i-1).Interface()), goSyntax(v.Index(i).Interface()))
+}
+
+// -----------------------------------------------------------------------
+// HasPrefix and HasSuffix checkers.
+
+type hasPrefixChecker struct {
+	*CheckerInfo
+}
+
+// The HasPrefix checker verifies that the obtained string, []byte or
+// fmt.Stringer value starts with the given prefix. On failure, the start
+// of the obtained value of the same length is reported.
+//
+// For example:
+//
+//     c.Assert(url, HasPrefix, "https://")
+//
+var HasPrefix Checker = &hasPrefixChecker{
+	&CheckerInfo{Name: "HasPrefix", Params: []string{"obtained", "prefix"}},
+}
+
+func (checker *hasPrefixChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	s, prefix, error := affixParams(params[0], params[1], "prefix")
+	if error != "" {
+		return false, error
+	}
+	if strings.HasPrefix(s, prefix) {
+		return true, ""
+	}
+	head := s
+	if len(head) > len(prefix) {
+		head = head[:len(prefix)]
+	}
+	return false, fmt.Sprintf("Obtained value starts with %q", head)
+}
+
+type hasSuffixChecker struct {
+	*CheckerInfo
+}
+
+// The HasSuffix checker verifies that the obtained string, []byte or
+// fmt.Stringer value ends with the given suffix. On failure, the end of
+// the obtained value of the same length is reported.
+//
+// For example:
+//
+//     c.Assert(path, HasSuffix, ".go")
+//
+var HasSuffix Checker = &hasSuffixChecker{
+	&CheckerInfo{Name: "HasSuffix", Params: []string{"obtained", "suffix"}},
+}
+
+func (checker *hasSuffixChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	s, suffix, error := affixParams(params[0], params[1], "suffix")
+	if error != "" {
+		return false, error
+	}
+	if strings.HasSuffix(s, suffix) {
+		return true, ""
+	}
+	tail := s
+	if len(tail) > len(suffix) {
+		tail = tail[len(tail)-len(suffix):]
+	}
+	return false, fmt.Sprintf("Obtained value ends with %q", tail)
+}
+
+// affixParams returns the obtained value of HasPrefix or HasSuffix as a
+// string, along with the affix it's given.
+func affixParams(obtained, affix interface{}, name string) (s, a string, error string) {
+	switch v := obtained.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		return "", "", usageError("obtained value must be a string, []byte or fmt.Stringer")
+	}
+	a, ok := affix.(string)
+	if !ok {
+		return "", "", usageError(name + " must be a string")
+	}
+	return s, a, ""
+}
+
+// -----------------------------------------------------------------------
+// Satisfies checker.
+
+type satisfiesChecker struct {
+	*CheckerInfo
+}
+
+// The Satisfies checker verifies that the given predicate returns true for
+// the obtained value, so that one-off conditions don't need a Checker of
+// their own. The predicate is a func(T) bool, or a func(T) (bool, string)
+// which also explains why the value doesn't satisfy it. The obtained value
+// must be assignable to T.
+//
+// For example:
+//
+//     c.Assert(user, Satisfies, func(u User) bool { return u.Active })
+//
+var Satisfies Checker = &satisfiesChecker{
+	&CheckerInfo{Name: "Satisfies", Params: []string{"obtained", "predicate"}},
+}
+
+var boolType = reflect.TypeOf(false)
+var stringType = reflect.TypeOf("")
+
+func (checker *satisfiesChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f := reflect.ValueOf(params[1])
+	if f.Kind() != reflect.Func || f.IsNil() || f.Type().NumIn() != 1 || f.Type().IsVariadic() ||
+		f.Type().NumOut() < 1 || f.Type().NumOut() > 2 || f.Type().Out(0) != boolType ||
+		f.Type().NumOut() == 2 && f.Type().Out(1) != stringType {
+		return false, usageError("predicate must be a func(T) bool or a func(T) (bool, string)")
+	}
+	in := f.Type().In(0)
+	var arg reflect.Value
+	if params[0] == nil {
+		switch in.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			arg = reflect.Zero(in)
+		default:
+			return false, usageError(fmt.Sprintf("nil can't be passed to %s", f.Type()))
+		}
+	} else {
+		arg = reflect.ValueOf(params[0])
+		if !arg.Type().AssignableTo(in) {
+			return false, usageError(fmt.Sprintf("%s can't be passed to %s", arg.Type(), f.Type()))
+		}
+	}
+	out := f.Call([]reflect.Value{arg})
+	if len(out) == 2 && !out[0].Bool() {
+		return false, out[1].String()
+	}
+	return out[0].Bool(), ""
+}
+
+// -----------------------------------------------------------------------
+// Eventually and Consistently checkers.
+
+// pollChecker calls a function repeatedly, checking the value it returns
+// with another checker, until that checker gives the result which ends
+// polling or the time is up.
+type pollChecker struct {
+	info     *CheckerInfo
+	checker  Checker
+	duration time.Duration
+	interval time.Duration
+	// stopOn is the result of the checker which ends polling early: a
+	// success for Eventually, and a failure for Consistently.
+	stopOn bool
+}
+
+func newPollChecker(name string, checker Checker, duration, interval time.Duration, stopOn bool) *pollChecker {
+	info := *checker.Info()
+	info.Name = name + "(" + info.Name + ")"
+	info.Params = append([]string{"function"}, info.Params[1:]...)
+	return &pollChecker{&info, checker, duration, interval, stopOn}
+}
+
+// The Eventually checker calls the provided zero-argument function, which
+// returns a single value, until the given checker succeeds on the value
+// it returns, waiting interval between calls. If the checker still fails
+// once timeout has elapsed, the last value obtained is reported along with
+// why the checker failed on it. The function is always called at least
+// once.
+//
+// For example:
+//
+//     c.Assert(func() int { return queue.Len() }, Eventually(Equals, 5*time.Second, 100*time.Millisecond), 0)
+//
+func Eventually(checker Checker, timeout, interval time.Duration) Checker {
+	return newPollChecker("Eventually", checker, timeout, interval, true)
+}
+
+// The Consistently checker calls the provided zero-argument function, which
+// returns a single value, every interval for the given duration, and
+// verifies that the given checker succeeds on the value it returns every
+// time. As soon as the checker fails, the value obtained is reported along
+// with why the checker failed on it.
+//
+// For example:
+//
+//     c.Assert(func() bool { return conn.Alive() }, Consistently(Equals, time.Second, 50*time.Millisecond), true)
+//
+func Consistently(checker Checker, duration, interval time.Duration) Checker {
+	return newPollChecker("Consistently", checker, duration, interval, false)
+}
+
+func (checker *pollChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *pollChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f := reflect.ValueOf(params[0])
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 || f.Type().NumOut() != 1 {
+		return false, usageError("Function must take zero arguments and return one value")
+	}
+	deadline := time.Now().Add(checker.duration)
+	for attempts := 1; ; attempts++ {
+		// The checker gets copies, as checkers may change them.
+		subParams := append([]interface{}{f.Call(nil)[0].Interface()}, params[1:]...)
+		subNames := append([]string{}, checker.checker.Info().Params...)
+		ok, subError := checker.checker.Check(subParams, subNames)
+		ok = ok && subError == ""
+		wait := time.Until(deadline)
+		if ok == checker.stopOn || wait <= 0 {
+			if ok {
+				return true, ""
+			}
+			copy(params, subParams)
+			copy(names, subNames)
+			names[0] = "last " + names[0]
+			if checker.stopOn {
+				error = fmt.Sprintf("Not satisfied after %s (%d attempts)", checker.duration, attempts)
+			} else {
+				error = fmt.Sprintf("Not satisfied anymore after %d attempts", attempts)
+			}
+			if subError != "" {
+				error += ": " + subError
+			}
+			return false, error
+		}
+		if checker.interval < wait {
+			wait = checker.interval
+		}
+		time.Sleep(wait)
+	}
+}
+
+// -----------------------------------------------------------------------
+// Receives and Closes checkers.
+
+type receivesChecker struct {
+	info    *CheckerInfo
+	checker Checker
+	timeout time.Duration
+}
+
+// The Receives checker verifies that a value is received from the obtained
+// channel within timeout and, unless checker is nil, that checker succeeds
+// on it, given the remaining arguments. The value received is reported
+// when the checker fails on it. Note that the value is consumed.
+//
+// For example:
+//
+//     c.Assert(done, Receives(nil, time.Second))
+//     c.Assert(results, Receives(Equals, time.Second), "ok")
+//
+func Receives(checker Checker, timeout time.Duration) Checker {
+	info := &CheckerInfo{Name: "Receives", Params: []string{"channel"}}
+	if checker != nil {
+		info.Name = "Receives(" + checker.Info().Name + ")"
+		info.Params = append(info.Params, checker.Info().Params[1:]...)
+	}
+	return &receivesChecker{info, checker, timeout}
+}
+
+func (checker *receivesChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *receivesChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	value, ok, timedOut, error := receive(params[0], checker.timeout)
+	if error != "" {
+		return false, error
+	}
+	if timedOut {
+		return false, fmt.Sprintf("Nothing received within %s", checker.timeout)
+	}
+	if !ok {
+		return false, "Channel closed before a value was received"
+	}
+	if checker.checker == nil {
+		return true, ""
+	}
+	// The checker gets copies, as checkers may change them.
+	subParams := append([]interface{}{value.Interface()}, params[1:]...)
+	subNames := append([]string{}, checker.checker.Info().Params...)
+	result, error = checker.checker.Check(subParams, subNames)
+	if !result {
+		copy(params, subParams)
+		copy(names, subNames)
+		names[0] = "received"
+	}
+	return result, error
+}
+
+type closesChecker struct {
+	info    *CheckerInfo
+	timeout time.Duration
+}
+
+// The Closes checker verifies that the obtained channel is closed within
+// timeout, without any value being received from it first. Note that a
+// value received is consumed.
+//
+// For example:
+//
+//     c.Assert(worker.Done(), Closes(time.Second))
+//
+func Closes(timeout time.Duration) Checker {
+	return &closesChecker{&CheckerInfo{Name: "Closes", Params: []string{"channel"}}, timeout}
+}
+
+func (checker *closesChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *closesChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	value, ok, timedOut, error := receive(params[0], checker.timeout)
+	if error != "" {
+		return false, error
+	}
+	if timedOut {
+		return false, fmt.Sprintf("Channel not closed within %s", checker.timeout)
+	}
+	if ok {
+		return false, fmt.Sprintf("Received %s rather than the channel being closed", goSyntax(value.Interface()))
+	}
+	return true, ""
+}
+
+// receive receives from the given channel, waiting up to timeout. Like a
+// receive statement, it returns whether the value was sent rather than the
+// zero value of a closed channel.
+func receive(channel interface{}, timeout time.Duration) (value reflect.Value, ok, timedOut bool, errStr string) {
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		return reflect.Value{}, false, false, usageError("obtained value must be a channel which may be received from")
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	chosen, value, ok := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+	})
+	return value, ok, chosen == 1, ""
+}
+
+// -----------------------------------------------------------------------
+// CompletesWithin checker.
+
+type completesWithinChecker struct {
+	*CheckerInfo
+}
+
+// The CompletesWithin checker verifies that the provided zero-argument
+// function returns within the given duration. A panic of the function is
+// recovered and fails the check, reporting the value it panicked with.
+// Note that when the function takes too long, the check fails as soon as
+// the duration has elapsed, leaving the function running.
+//
+// The function runs on a goroutine of its own, so it must not use the C
+// of the test: c.Assert and c.FailNow stop the goroutine they're called
+// from rather than the test. The check fails if the function is stopped
+// that way, as by runtime.Goexit.
+//
+// For example:
+//
+//     c.Assert(func() { cache.Warm() }, CompletesWithin, 50*time.Millisecond)
+//
+var CompletesWithin Checker = &completesWithinChecker{
+	&CheckerInfo{Name: "CompletesWithin", Params: []string{"function", "duration"}},
+}
+
+func (checker *completesWithinChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	f := reflect.ValueOf(params[0])
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 0 {
+		return false, usageError("Function must take zero arguments")
+	}
+	max, ok := params[1].(time.Duration)
+	if !ok {
+		return false, usageError("duration must be a time.Duration")
+	}
+	if max < 0 {
+		return false, usageError("duration must not be negative")
+	}
+	type outcome struct {
+		panicked bool
+		exited   bool
+		value    interface{}
+	}
+	done := make(chan outcome, 1)
+	started := time.Now()
+	go func() {
+		returned := false
+		defer func() {
+			if returned {
+				return
+			}
+			// Without a panic to recover from, the function was stopped
+			// by runtime.Goexit.
+			value := recover()
+			done <- outcome{panicked: value != nil, exited: value == nil, value: value}
+		}()
+		f.Call(nil)
+		returned = true
+		done <- outcome{}
+	}()
+	timer := time.NewTimer(max)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		elapsed := time.Since(started)
+		if o.panicked {
+			params[0] = o.value
+			names[0] = "panic"
+			return false, fmt.Sprintf("Function panicked after %s", elapsed)
+		}
+		if o.exited {
+			return false, fmt.Sprintf("Function was stopped by runtime.Goexit after %s, as by a failed c.Assert", elapsed)
+		}
+		if elapsed > max {
+			return false, fmt.Sprintf("Function completed in %s, more than %s", elapsed, max)
+		}
+		return true, ""
+	case <-timer.C:
+		return false, fmt.Sprintf("Function has not completed within %s", max)
+	}
+}
+
+// -----------------------------------------------------------------------
+// IsCanceled, NotCanceled, HasCause and HasDeadlineBetween checkers.
+
+// contextCause returns the cause of a done context. It's context.Cause
+// from Go 1.20 on, and the error of the context before.
+var contextCause = func(ctx context.Context) error { return ctx.Err() }
+
+type canceledChecker struct {
+	*CheckerInfo
+	canceled bool
+}
+
+// The IsCanceled checker verifies that the obtained context.Context is
+// done, whether it was canceled or its deadline has passed.
+//
+// For example:
+//
+//     c.Assert(ctx, IsCanceled)
+//
+var IsCanceled Checker = &canceledChecker{
+	&CheckerInfo{Name: "IsCanceled", Params: []string{"context"}},
+	true,
+}
+
+// The NotCanceled checker verifies that the obtained context.Context is not
+// done yet, reporting its error if it is.
+//
+// For example:
+//
+//     c.Assert(ctx, NotCanceled)
+//
+var NotCanceled Checker = &canceledChecker{
+	&CheckerInfo{Name: "NotCanceled", Params: []string{"context"}},
+	false,
+}
+
+func (checker *canceledChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	ctx, ok := params[0].(context.Context)
+	if !ok {
+		return false, usageError("obtained value must be a context.Context")
+	}
+	err := ctx.Err()
+	if (err != nil) == checker.canceled {
+		return true, ""
+	}
+	if checker.canceled {
+		return false, "Context is not canceled"
+	}
+	return false, "Context is canceled: " + err.Error()
+}
+
+type hasCauseChecker struct {
+	info    *CheckerInfo
+	checker Checker
+}
+
+// The HasCause checker verifies that the obtained context.Context is done
+// and that the given checker succeeds on the cause of its cancellation,
+// given the remaining arguments. Before Go 1.20, which introduced causes,
+// the checker is given the error of the context instead.
+//
+// For example:
+//
+//     c.Assert(ctx, HasCause(ErrorIs), ErrShutdown)
+//     c.Assert(ctx, HasCause(ErrorMatches), "worker .* stopped")
+//
+func HasCause(checker Checker) Checker {
+	info := *checker.Info()
+	info.Name = "HasCause(" + info.Name + ")"
+	info.Params = append([]string{"context"}, info.Params[1:]...)
+	return &hasCauseChecker{&info, checker}
+}
+
+func (checker *hasCauseChecker) Info() *CheckerInfo {
+	return checker.info
+}
+
+func (checker *hasCauseChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	ctx, ok := params[0].(context.Context)
+	if !ok {
+		return false, usageError("obtained value must be a context.Context")
+	}
+	if ctx.Err() == nil {
+		return false, "Context is not canceled"
+	}
+	// The checker gets copies, as checkers may change them.
+	subParams := append([]interface{}{contextCause(ctx)}, params[1:]...)
+	subNames := append([]string{}, checker.checker.Info().Params...)
+	result, error = checker.checker.Check(subParams, subNames)
+	copy(params, subParams)
+	copy(names, subNames)
+	names[0] = "cause"
+	return result, error
+}
+
+type hasDeadlineBetweenChecker struct {
+	*CheckerInfo
+}
+
+// The HasDeadlineBetween checker verifies that the obtained context.Context
+// has a deadline, which lies within the inclusive range from low to high.
+//
+// For example:
+//
+//     c.Assert(ctx, HasDeadlineBetween, start.Add(time.Second), time.Now().Add(time.Second))
+//
+var HasDeadlineBetween Checker = &hasDeadlineBetweenChecker{
+	&CheckerInfo{Name: "HasDeadlineBetween", Params: []string{"context", "low", "high"}},
+}
+
+func (checker *hasDeadlineBetweenChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	ctx, ok := params[0].(context.Context)
+	if !ok {
+		return false, usageError("obtained value must be a context.Context")
+	}
+	if _, ok := params[1].(time.Time); !ok {
+		return false, usageError("low must be a time.Time")
+	}
+	if _, ok := params[2].(time.Time); !ok {
+		return false, usageError("high must be a time.Time")
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return false, "Context has no deadline"
+	}
+	params[0] = deadline
+	names[0] = "deadline"
+	return Between.Check(params, names)
+}
+
+// -----------------------------------------------------------------------
+// HasStatus, HasHeader, BodyMatches and BodyJSONEquals checkers.
+
+// maxResponseBody is the most bytes of a response body shown when an HTTP
+// response checker fails.
+const maxResponseBody = 512
+
+// responseRecorder is satisfied by *httptest.ResponseRecorder.
+type responseRecorder interface {
+	Result() *http.Response
+}
+
+// httpResponse returns the *http.Response, or the result of the
+// *httptest.ResponseRecorder, given to an HTTP response checker, along with
+// its body. The body is read in full and then restored, so that it may be
+// read again.
+func httpResponse(value interface{}) (resp *http.Response, body []byte, errStr string) {
+	switch v := value.(type) {
+	case *http.Response:
+		resp = v
+	case responseRecorder:
+		resp = v.Result()
+	}
+	if resp == nil {
+		return nil, nil, usageError("obtained value must be an *http.Response or an *httptest.ResponseRecorder")
+	}
+	if resp.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, usageError("can't read the response body: " + err.Error())
+		}
+	}
+	return resp, body, ""
+}
+
+// describeResponse replaces the obtained response in params with a summary
+// of it for the log: its status, the given headers and its body, truncated.
+func describeResponse(params []interface{}, names []string, resp *http.Response, body []byte, headers ...string) {
+	summary := resp.Status
+	if summary == "" {
+		summary = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	summary += "\n"
+	for _, header := range headers {
+		for _, value := range resp.Header.Values(header) {
+			summary += http.CanonicalHeaderKey(header) + ": " + value + "\n"
+		}
+	}
+	if len(body) > maxResponseBody {
+		summary += fmt.Sprintf("\n%s... (%d more bytes)", body[:maxResponseBody], len(body)-maxResponseBody)
+	} else {
+		summary += "\n" + string(body)
+	}
+	params[0] = summary
+	names[0] = "response"
+}
+
+type hasStatusChecker struct {
+	*CheckerInfo
+}
+
+// The HasStatus checker verifies that the obtained *http.Response or
+// *httptest.ResponseRecorder has the given status code. On failure, its
+// status, content type and truncated body are shown.
+//
+// For example:
+//
+//     c.Assert(recorder, HasStatus, http.StatusOK)
+//
+var HasStatus Checker = &hasStatusChecker{
+	&CheckerInfo{Name: "HasStatus", Params: []string{"obtained", "status"}},
+}
+
+func (checker *hasStatusChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	status, ok := params[1].(int)
+	if !ok {
+		return false, usageError("status must be an int")
+	}
+	resp, body, error := httpResponse(params[0])
+	if error != "" {
+		return false, error
+	}
+	if resp.StatusCode == status {
+		return true, ""
+	}
+	describeResponse(params, names, resp, body, "Content-Type")
+	return false, ""
+}
+
+type hasHeaderChecker struct {
+	*CheckerInfo
+}
+
+// The HasHeader checker verifies that the obtained *http.Response or
+// *httptest.ResponseRecorder has the given header, with the given value
+// among its values. On failure, the values it has for the header are
+// shown along with its status and truncated body.
+//
+// For example:
+//
+//     c.Assert(resp, HasHeader, "Content-Type", "application/json")
+//
+var HasHeader Checker = &hasHeaderChecker{
+	&CheckerInfo{Name: "HasHeader", Params: []string{"obtained", "header", "value"}},
+}
+
+func (checker *hasHeaderChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	header, ok := params[1].(string)
+	if !ok {
+		return false, usageError("header must be a string")
+	}
+	value, ok := params[2].(string)
+	if !ok {
+		return false, usageError("value must be a string")
+	}
+	resp, body, error := httpResponse(params[0])
+	if error != "" {
+		return false, error
+	}
+	for _, v := range resp.Header.Values(header) {
+		if v == value {
+			return true, ""
+		}
+	}
+	describeResponse(params, names, resp, body, header)
+	return false, ""
+}
+
+type bodyMatchesChecker struct {
+	*CheckerInfo
+}
+
+// The BodyMatches checker verifies that the body of the obtained
+// *http.Response or *httptest.ResponseRecorder matches the regular
+// expression provided, in full. On failure, the response status, content
+// type and truncated body are shown.
+//
+// For example:
+//
+//     c.Assert(resp, BodyMatches, `(?s).*"id": ?42.*`)
+//
+var BodyMatches Checker = &bodyMatchesChecker{
+	&CheckerInfo{Name: "BodyMatches", Params: []string{"obtained", "regex"}},
+}
+
+func (checker *bodyMatchesChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	resp, body, error := httpResponse(params[0])
+	if error != "" {
+		return false, error
+	}
+	result, error = matches(string(body), params[1])
+	if !result {
+		describeResponse(params, names, resp, body, "Content-Type")
+	}
+	return result, error
+}
+
+type bodyJSONEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The BodyJSONEquals checker verifies that the body of the obtained
+// *http.Response or *httptest.ResponseRecorder is the same JSON document
+// as the expected value, as for JSONEquals. On failure, the paths where
+// they differ are listed, and the response status, content type and
+// truncated body are shown.
+//
+// For example:
+//
+//     c.Assert(recorder, BodyJSONEquals, `{"id": 42, "name": "alice"}`)
+//
+var BodyJSONEquals Checker = &bodyJSONEqualsChecker{
+	&CheckerInfo{Name: "BodyJSONEquals", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *bodyJSONEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	resp, body, error := httpResponse(params[0])
+	if error != "" {
+		return false, error
+	}
+	expected, err := jsonDocument(params[1])
+	if err != nil {
+		return false, usageError("expected value is not valid JSON: " + err.Error())
+	}
+	obtained, err := jsonDocument(body)
+	if err != nil {
+		result, error = false, "response body is not valid JSON: "+err.Error()
+	} else {
+		result, error = documentsEqual(obtained, expected)
+	}
+	if !result {
+		describeResponse(params, names, resp, body, "Content-Type")
+	}
+	return result, error
+}
+
+// -----------------------------------------------------------------------
+// FileExists, HasFileMode, FileEquals, FileContains and FileMatches checkers.
+
+// maxFileContent is the most bytes of a file shown when a file content
+// checker fails.
+const maxFileContent = 512
+
+type fileExistsChecker struct {
+	*CheckerInfo
+}
+
+// The FileExists checker verifies that something exists at the obtained
+// path, be it a file, a directory or anything else. Symbolic links are
+// followed.
+//
+// For example:
+//
+//     c.Assert(filepath.Join(dir, "out.txt"), FileExists)
+//
+var FileExists Checker = &fileExistsChecker{
+	&CheckerInfo{Name: "FileExists", Params: []string{"path"}},
+}
+
+func (checker *fileExistsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	path, ok := params[0].(string)
+	if !ok {
+		return false, usageError("path must be a string")
+	}
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, ""
+	}
+	if err != nil {
+		return false, usageError(err.Error())
+	}
+	return true, ""
+}
+
+type hasFileModeChecker struct {
+	*CheckerInfo
+}
+
+// The HasFileMode checker verifies that the file at the obtained path has
+// the given os.FileMode. Only the permission bits are compared, unless the
+// mode given has type bits set, such as os.ModeDir, in which case the
+// whole mode is. Symbolic links are followed.
+//
+// For example:
+//
+//     c.Assert(keyPath, HasFileMode, os.FileMode(0600))
+//     c.Assert(dir, HasFileMode, os.ModeDir|0755)
+//
+var HasFileMode Checker = &hasFileModeChecker{
+	&CheckerInfo{Name: "HasFileMode", Params: []string{"path", "mode"}},
+}
+
+func (checker *hasFileModeChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	path, ok := params[0].(string)
+	if !ok {
+		return false, usageError("path must be a string")
+	}
+	mode, ok := params[1].(os.FileMode)
+	if !ok {
+		return false, usageError("mode must be an os.FileMode")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, usageError(err.Error())
+	}
+	obtained := info.Mode()
+	if mode&os.ModeType == 0 {
+		obtained = obtained.Perm()
+	}
+	if obtained != mode {
+		return false, "Mode is " + info.Mode().String()
+	}
+	return true, ""
+}
+
+type fileEqualsChecker struct {
+	*CheckerInfo
+}
+
+// The FileEquals checker verifies that the content of the file at the
+// obtained path is exactly the expected string or []byte. When multi-line
+// contents differ, a unified diff of their lines is shown.
+//
+// For example:
+//
+//     c.Assert(filepath.Join(dir, "config.ini"), FileEquals, "[main]\nname = test\n")
+//
+var FileEquals Checker = &fileEqualsChecker{
+	&CheckerInfo{Name: "FileEquals", Params: []string{"path", "expected"}},
+}
+
+func (checker *fileEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	var expected string
+	switch v := params[1].(type) {
+	case string:
+		expected = v
+	case []byte:
+		expected = string(v)
+	default:
+		return false, usageError("expected value must be a string or []byte")
+	}
+	content, error := fileContent(params[0])
+	if error != "" {
+		return false, error
+	}
+	if content == expected {
+		return true, ""
+	}
+	if diff := valueDiff(content, expected); diff != "" {
+		return false, "Difference (-content +expected):\n...     " + strings.Replace(diff, "\n", "\n...     ", -1)
+	}
+	return false, describeContent(content)
+}
+
+type fileContainsChecker struct {
+	*CheckerInfo
+}
+
+// The FileContains checker verifies that the content of the file at the
+// obtained path contains the given substring. On failure, the content is
+// shown, truncated.
+//
+// For example:
+//
+//     c.Assert(logPath, FileContains, "server started")
+//
+var FileContains Checker = &fileContainsChecker{
+	&CheckerInfo{Name: "FileContains", Params: []string{"path", "substring"}},
+}
+
+func (checker *fileContainsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	substring, ok := params[1].(string)
+	if !ok {
+		return false, usageError("substring must be a string")
+	}
+	content, error := fileContent(params[0])
+	if error != "" {
+		return false, error
+	}
+	if strings.Contains(content, substring) {
+		return true, ""
+	}
+	return false, describeContent(content)
+}
+
+type fileMatchesChecker struct {
+	*CheckerInfo
+}
+
+// The FileMatches checker verifies that the content of the file at the
+// obtained path matches the regular expression provided, in full, as for
+// Matches. On failure, the content is shown, truncated.
+//
+// For example:
+//
+//     c.Assert(pidPath, FileMatches, `[0-9]+\n`)
+//
+var FileMatches Checker = &fileMatchesChecker{
+	&CheckerInfo{Name: "FileMatches", Params: []string{"path", "regex"}},
+}
+
+func (checker *fileMatchesChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	content, error := fileContent(params[0])
+	if error != "" {
+		return false, error
+	}
+	result, error = matches(content, params[1])
+	if !result && error == "" {
+		error = describeContent(content)
+	}
+	return result, error
+}
+
+// fileContent returns the content of the file at the given path.
+func fileContent(path interface{}) (content string, errStr string) {
+	name, ok := path.(string)
+	if !ok {
+		return "", usageError("path must be a string")
+	}
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", usageError(err.Error())
+	}
+	return string(data), ""
+}
+
+// describeContent returns the content of a file for the log, truncated.
+func describeContent(content string) string {
+	if len(content) > maxFileContent {
+		return fmt.Sprintf("Content is %q... (%d more bytes)", content[:maxFileContent], len(content)-maxFileContent)
+	}
+	return fmt.Sprintf("Content is %q", content)
+}
+
+// -----------------------------------------------------------------------
+// DirEquals and DirEqualsWithModes checkers.
+
+type dirEqualsChecker struct {
+	*CheckerInfo
+	modes bool
+}
+
+// The DirEquals checker verifies that the directory tree at the obtained
+// path holds the same files and directories as the one at the expected
+// path, with the same contents. Symbolic links must point to the same
+// targets. On failure, the missing, extra and differing entries are listed,
+// with paths relative to the roots.
+//
+// For example:
+//
+//     c.Assert(outDir, DirEquals, "testdata/golden")
+//
+var DirEquals Checker = &dirEqualsChecker{
+	&CheckerInfo{Name: "DirEquals", Params: []string{"obtained", "expected"}},
+	false,
+}
+
+// The DirEqualsWithModes checker verifies that two directory trees are the
+// same, as DirEquals does, and that their entries have the same modes.
+//
+// For example:
+//
+//     c.Assert(outDir, DirEqualsWithModes, "testdata/golden")
+//
+var DirEqualsWithModes Checker = &dirEqualsChecker{
+	&CheckerInfo{Name: "DirEqualsWithModes", Params: []string{"obtained", "expected"}},
+	true,
+}
+
+func (checker *dirEqualsChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	obtained, error := dirTree(params[0], "obtained")
+	if error != "" {
+		return false, error
+	}
+	expected, error := dirTree(params[1], "expected")
+	if error != "" {
+		return false, error
+	}
+	var missing, extra, differing []string
+	for _, path := range sortedKeys(expected) {
+		if _, ok := obtained[path]; !ok {
+			missing = append(missing, path)
+		}
+	}
+	for _, path := range sortedKeys(obtained) {
+		want, ok := expected[path]
+		if !ok {
+			extra = append(extra, path)
+			continue
+		}
+		if difference := obtained[path].differsFrom(want, checker.modes); difference != "" {
+			differing = append(differing, path+" ("+difference+")")
+		}
+	}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "Missing: "+strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		problems = append(problems, "Extra: "+strings.Join(extra, ", "))
+	}
+	if len(differing) > 0 {
+		problems = append(problems, "Differing: "+strings.Join(differing, ", "))
+	}
+	if len(problems) > 0 {
+		return false, strings.Join(problems, "\n")
+	}
+	return true, ""
+}
+
+// dirEntry is a file, directory or other entry of a directory tree.
+type dirEntry struct {
+	path string
+	info os.FileInfo
+}
+
+// differsFrom tells how the entry differs from the expected one, or returns
+// an empty string if it doesn't.
+func (entry dirEntry) differsFrom(expected dirEntry, modes bool) string {
+	obtainedType, expectedType := entry.info.Mode()&os.ModeType, expected.info.Mode()&os.ModeType
+	if obtainedType != expectedType {
+		return fmt.Sprintf("%s, expected %s", entryKind(obtainedType), entryKind(expectedType))
+	}
+	if modes && entry.info.Mode() != expected.info.Mode() {
+		return fmt.Sprintf("mode %s, expected %s", entry.info.Mode(), expected.info.Mode())
+	}
+	switch {
+	case obtainedType&os.ModeSymlink != 0:
+		obtainedTarget, err1 := os.Readlink(entry.path)
+		expectedTarget, err2 := os.Readlink(expected.path)
+		if err1 != nil || err2 != nil || obtainedTarget != expectedTarget {
+			return fmt.Sprintf("link to %q, expected %q", obtainedTarget, expectedTarget)
+		}
+	case obtainedType == 0:
+		if entry.info.Size() != expected.info.Size() {
+			return "content"
+		}
+		obtainedContent, err1 := ioutil.ReadFile(entry.path)
+		expectedContent, err2 := ioutil.ReadFile(expected.path)
+		if err1 != nil || err2 != nil || !bytes.Equal(obtainedContent, expectedContent) {
+			return "content"
+		}
+	}
+	return ""
+}
+
+// entryKind names the kind of directory entry with the given mode type.
+func entryKind(modeType os.FileMode) string {
+	switch {
+	case modeType == 0:
+		return "file"
+	case modeType&os.ModeDir != 0:
+		return "directory"
+	case modeType&os.ModeSymlink != 0:
+		return "symbolic link"
+	}
+	return "special file"
+}
+
+// dirTree returns the entries of the directory tree at the given path, by
+// their slash-separated path relative to it. The root itself is left out.
+func dirTree(root interface{}, name string) (entries map[string]dirEntry, errStr string) {
+	dir, ok := root.(string)
+	if !ok {
+		return nil, usageError(name + " path must be a string")
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, usageError(err.Error())
+	}
+	if !info.IsDir() {
+		return nil, usageError(name + " path is not a directory")
+	}
+	entries = make(map[string]dirEntry)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			entries[filepath.ToSlash(rel)] = dirEntry{path, info}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, usageError(err.Error())
+	}
+	return entries, ""
+}
+
+// sortedKeys returns the keys of entries, sorted.
+func sortedKeys(entries map[string]dirEntry) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// -----------------------------------------------------------------------
+// IsUUID, IsEmail, IsURL and IsBase64 checkers.
+
+// formatChecker verifies that the obtained string is in some format.
+type formatChecker struct {
+	*CheckerInfo
+	// problem tells why the string isn't in the format, or returns an
+	// empty string if it is.
+	problem func(s string) string
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// The IsUUID checker verifies that the obtained string is a UUID in its
+// canonical form, such as "123e4567-e89b-12d3-a456-426614174000", in
+// either case.
+//
+// For example:
+//
+//     c.Assert(user.ID, IsUUID)
+//
+var IsUUID Checker = &formatChecker{
+	&CheckerInfo{Name: "IsUUID", Params: []string{"value"}},
+	func(s string) string {
+		if !uuidRegexp.MatchString(s) {
+			return "Value is not a UUID"
+		}
+		return ""
+	},
+}
+
+// The IsEmail checker verifies that the obtained string is an email
+// address, such as "alice@example.com", as defined by RFC 5322. A display
+// name or angle brackets aren't allowed.
+//
+// For example:
+//
+//     c.Assert(user.Email, IsEmail)
+//
+var IsEmail Checker = &formatChecker{
+	&CheckerInfo{Name: "IsEmail", Params: []string{"value"}},
+	func(s string) string {
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return "Value is not an email address: " + err.Error()
+		}
+		if addr.Name != "" || addr.Address != s {
+			return "Value is not a bare email address"
+		}
+		return ""
+	},
+}
+
+// The IsURL checker verifies that the obtained string is an absolute URL,
+// with a scheme and a host, such as "https://example.com/path".
+//
+// For example:
+//
+//     c.Assert(resp.Location, IsURL)
+//
+var IsURL Checker = &formatChecker{
+	&CheckerInfo{Name: "IsURL", Params: []string{"value"}},
+	func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil {
+			return "Value is not a URL: " + err.Error()
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return "Value is not an absolute URL"
+		}
+		return ""
+	},
+}
+
+// The IsBase64 checker verifies that the obtained string is valid
+// standard base64, as defined by RFC 4648, with padding.
+//
+// For example:
+//
+//     c.Assert(payload.Signature, IsBase64)
+//
+var IsBase64 Checker = &formatChecker{
+	&CheckerInfo{Name: "IsBase64", Params: []string{"value"}},
+	func(s string) string {
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return "Value is not base64: " + err.Error()
+		}
+		return ""
+	},
+}
+
+func (checker *formatChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	s, ok := params[0].(string)
+	if !ok {
+		return false, usageError("Value must be a string")
+	}
+	if problem := checker.problem(s); problem != "" {
+		return false, problem
+	}
+	return true, ""
+}
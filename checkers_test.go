@@ -1,10 +1,26 @@
 package check_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
+	"fmt"
 	"github.com/masukomi/check"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type CheckersS struct{}
@@ -75,8 +91,56 @@ func (s *CheckersS) TestNotNil(c *check.C) {
 func (s *CheckersS) TestNot(c *check.C) {
 	testInfo(c, check.Not(check.IsNil), "Not(IsNil)", []string{"value"})
 
-	testCheck(c, check.Not(check.IsNil), false, "", nil)
+	testCheck(c, check.Not(check.IsNil), false, "Value is nil", nil)
 	testCheck(c, check.Not(check.IsNil), true, "", "a")
+
+	// The explanation of a failure is dropped once it becomes a success.
+	testCheck(c, check.Not(check.DeepEquals), true, "", "a\nb", "a\nc")
+	testCheck(c, check.Not(check.HasLen), true, "", []int{1, 2, 3}, 2)
+
+	// Errors about values the checker can't check at all are kept.
+	testCheck(c, check.Not(check.HasLen), false, "obtained value type has no length", 42, 2)
+	testCheck(c, check.Not(check.ErrorMatches), false, "Value is not an error", "BOOM", "BOOM")
+
+	// Failures are explained with the Negated message of the checker, with
+	// parameters replaced by their values.
+	testCheck(c, check.Not(check.Equals), false, "Obtained value is equal to 42", 42, 42)
+	testCheck(c, check.Not(check.Matches), false, "Value matches \"a.c\"", "abc", "a.c")
+	testCheck(c, check.Not(check.ErrorMatches), false, "Error matches \"BOOM\"", errors.New("BOOM"), "BOOM")
+	testCheck(c, check.Not(check.HasKey), false, "Obtained map has key \"a\"", map[string]int{"a": 1}, "a")
+	testCheck(c, check.Not(check.GreaterThan), false, "GreaterThan succeeded, and it must not", 2, 1)
+	testCheck(c, check.Not(check.Not(check.IsNil)), false, "Not(IsNil) succeeded, and it must not", "a")
+	custom := &MyChecker{result: true, info: &check.CheckerInfo{Name: "Custom", Params: []string{"x"}, Negated: "{x} and {y}"}}
+	testCheck(c, check.Not(custom), false, "\"custom\" and {y}", "custom")
+}
+
+func (s *CheckersS) TestAnd(c *check.C) {
+	and := check.And(check.GreaterThan, check.LessOrEqual)
+	testInfo(c, and, "And(GreaterThan, LessOrEqual)", []string{"obtained", "bound", "bound"})
+
+	testCheck(c, and, true, "", 80, 0, 65535)
+	testCheck(c, and, false, "GreaterThan failed", 0, 0, 65535)
+	testCheck(c, and, false, "LessOrEqual failed", 65536, 0, 65535)
+	testCheck(c, and, false, "GreaterThan failed: can't compare string with int", "80", 0, 65535)
+
+	// Checkers changing their parameters don't affect the others.
+	params, _ := testCheck(c, check.And(check.ErrorMatches, check.ErrorIs), true, "", io.EOF, "EOF", io.EOF)
+	c.Assert(params[0], check.Equals, io.EOF)
+
+	testCheck(c, check.And(), true, "", 1)
+}
+
+func (s *CheckersS) TestOr(c *check.C) {
+	or := check.Or(check.IsNil, check.ErrorIs)
+	testInfo(c, or, "Or(IsNil, ErrorIs)", []string{"obtained", "target"})
+
+	testCheck(c, or, true, "", nil, io.EOF)
+	testCheck(c, or, true, "", fmt.Errorf("reading: %w", io.EOF), io.EOF)
+	testCheck(c, or, false, "IsNil failed\nErrorIs failed", io.ErrUnexpectedEOF, io.EOF)
+	testCheck(c, check.Or(check.Not(check.IsNil), check.HasLen), false,
+		"Not(IsNil) failed: Value is nil\nHasLen failed: obtained value type has no length", nil, 1)
+
+	testCheck(c, check.Or(), false, "", 1)
 }
 
 type simpleStruct struct {
@@ -108,6 +172,56 @@ func (s *CheckersS) TestEquals(c *check.C) {
 	testCheck(c, check.Equals, false, "", &simpleStruct{1}, &simpleStruct{2})
 }
 
+func (s *CheckersS) TestNumEquals(c *check.C) {
+	testInfo(c, check.NumEquals, "NumEquals", []string{"obtained", "expected"})
+
+	testCheck(c, check.NumEquals, true, "", 5, int64(5))
+	testCheck(c, check.NumEquals, true, "", float64(5), 5)
+	testCheck(c, check.NumEquals, true, "", uint8(5), int32(5))
+	testCheck(c, check.NumEquals, true, "", float32(0.5), 0.5)
+	testCheck(c, check.NumEquals, true, "", uint64(1<<63), float64(1<<63))
+	testCheck(c, check.NumEquals, true, "", int64(-1<<63), float64(-1<<63))
+	testCheck(c, check.NumEquals, false, "", 5, int64(6))
+	testCheck(c, check.NumEquals, false, "", 5.5, 5)
+	testCheck(c, check.NumEquals, false, "", -1, uint(1<<64-1))
+	testCheck(c, check.NumEquals, false, "", math.Inf(1), int64(1<<62))
+	testCheck(c, check.NumEquals, false, "", math.NaN(), math.NaN())
+	testCheck(c, check.NumEquals, false, "", float64(1<<63), int64(-1<<63))
+
+	// Integers which no float64 holds exactly aren't equal to their
+	// nearest float.
+	testCheck(c, check.NumEquals, false, "", int64(1<<53+1), float64(1<<53))
+
+	// error states
+
+	testCheck(c, check.NumEquals, false, "obtained value must be a number", "5", 5)
+	testCheck(c, check.NumEquals, false, "expected value must be a number", 5, nil)
+}
+
+func (s *CheckersS) TestSameInstance(c *check.C) {
+	testInfo(c, check.SameInstance, "SameInstance", []string{"obtained", "expected"})
+
+	first, second := &simpleStruct{1}, &simpleStruct{1}
+	testCheck(c, check.SameInstance, true, "", first, first)
+	m := map[string]int{}
+	testCheck(c, check.SameInstance, true, "", m, m)
+	ch := make(chan int)
+	testCheck(c, check.SameInstance, true, "", ch, ch)
+	testCheck(c, check.SameInstance, true, "", (*simpleStruct)(nil), (*simpleStruct)(nil))
+
+	_, message := check.SameInstance.Check([]interface{}{first, second}, []string{"obtained", "expected"})
+	c.Assert(message, check.Matches, "Obtained 0x[0-9a-f]+ and expected 0x[0-9a-f]+ are different instances")
+	testCheck(c, check.SameInstance, false, "Obtained map[string]int and expected map[string]bool can't be the same instance",
+		m, map[string]bool{})
+	testCheck(c, check.Not(check.SameInstance), true, "", first, second)
+
+	// error states
+
+	testCheck(c, check.SameInstance, false, "obtained value must be a pointer, map or channel", simpleStruct{1}, first)
+	testCheck(c, check.SameInstance, false, "expected value must be a pointer, map or channel", first, nil)
+	testCheck(c, check.SameInstance, false, "obtained value must be a pointer, map or channel", []int{}, []int{})
+}
+
 func (s *CheckersS) TestDeepEquals(c *check.C) {
 	testInfo(c, check.DeepEquals, "DeepEquals", []string{"obtained", "expected"})
 
@@ -134,6 +248,132 @@ func (s *CheckersS) TestDeepEquals(c *check.C) {
 	testCheck(c, check.DeepEquals, false, "", &simpleStruct{1}, &simpleStruct{2})
 }
 
+// foldedName is compared case-insensitively by a registered comparer.
+type foldedName string
+
+type foldedRecord struct {
+	Names  []foldedName
+	ByID   map[int]foldedName
+	hidden foldedName
+}
+
+func (s *CheckersS) TestRegisterComparer(c *check.C) {
+	c.Cleanup(check.RegisterComparer(func(a, b foldedName) bool { return strings.EqualFold(string(a), string(b)) }))
+
+	testCheck(c, check.DeepEquals, true, "", foldedName("Bob"), foldedName("BOB"))
+	testCheck(c, check.DeepEquals, false, "", foldedName("Bob"), foldedName("Alice"))
+	testCheck(c, check.DeepEquals, true, "",
+		foldedRecord{Names: []foldedName{"alice"}, ByID: map[int]foldedName{1: "bob"}},
+		foldedRecord{Names: []foldedName{"ALICE"}, ByID: map[int]foldedName{1: "Bob"}})
+	testCheck(c, check.DeepEqualsIgnoring(), false, "mismatch at .Names[0]: obtained \"alice\", expected \"carol\"",
+		foldedRecord{Names: []foldedName{"alice"}}, foldedRecord{Names: []foldedName{"carol"}})
+
+	// Comparers aren't used for unexported fields.
+	testCheck(c, check.DeepEqualsIgnoring(), false, "mismatch at .hidden: obtained \"a\", expected \"A\"",
+		foldedRecord{hidden: "a"}, foldedRecord{hidden: "A"})
+
+	// Other values are compared as before.
+	testCheck(c, check.DeepEquals, true, "", []int{1, 2}, []int{1, 2})
+	testCheck(c, check.DeepEquals, false, "", []int{1, 2}, []int{1, 3})
+
+	// Unregistering a comparer restores the one it replaced.
+	unregister := check.RegisterComparer(func(a, b foldedName) bool { return a == b })
+	testCheck(c, check.DeepEquals, false, "", foldedName("Bob"), foldedName("BOB"))
+	unregister()
+	testCheck(c, check.DeepEquals, true, "", foldedName("Bob"), foldedName("BOB"))
+
+	// error states
+
+	c.Assert(func() { check.RegisterComparer(func(a foldedName) bool { return true }) }, check.PanicMatches,
+		`comparer must be a func\(a, b T\) bool, not func\(check_test.foldedName\) bool`)
+	c.Assert(func() { check.RegisterComparer(nil) }, check.PanicMatches,
+		`comparer must be a func\(a, b T\) bool, not <nil>`)
+}
+
+type largeStruct struct {
+	Name  string
+	Items []string
+	Attrs map[string]int
+}
+
+func (s *CheckersS) TestDeepEqualsDiff(c *check.C) {
+	obtained := largeStruct{"a rather long name", []string{"one", "two", "three"}, map[string]int{"b": 2, "a": 1}}
+	expected := largeStruct{"a rather long name", []string{"one", "2", "three"}, map[string]int{"a": 1, "b": 2}}
+	testCheck(c, check.DeepEquals, false, "Difference (-obtained +expected):\n"+
+		"...     @@ -2,7 +2,7 @@\n"+
+		"...          Name: \"a rather long name\",\n"+
+		"...          Items: []string{\n"+
+		"...              \"one\",\n"+
+		"...     -        \"two\",\n"+
+		"...     +        \"2\",\n"+
+		"...              \"three\",\n"+
+		"...          },\n"+
+		"...          Attrs: map[string]int{",
+		obtained, expected)
+
+	testCheck(c, check.DeepEquals, false, "Difference (-obtained +expected):\n"+
+		"...     @@ -1,3 +1,3 @@\n"+
+		"...      \"one\\n\"\n"+
+		"...     -\"two\\n\"\n"+
+		"...     +\"2\\n\"\n"+
+		"...      \"three\"",
+		"one\ntwo\nthree", "one\n2\nthree")
+
+	// Short values and values of different types are just shown.
+	testCheck(c, check.DeepEquals, false, "", "a", "b")
+	testCheck(c, check.DeepEquals, false, "", obtained, &expected)
+}
+
+// hexID is written out in hex by a registered formatter.
+type hexID uint32
+
+type hexRecord struct {
+	Owner  hexID
+	Labels []string
+	hidden hexID
+}
+
+func (s *CheckersS) TestRegisterFormatter(c *check.C) {
+	c.Cleanup(check.RegisterFormatter(func(id hexID) string { return fmt.Sprintf("hexID(%#x)", uint32(id)) }))
+
+	labels := []string{"a rather long label", "and another one to make the value long"}
+	obtained := hexRecord{Owner: 255, Labels: labels, hidden: 1}
+	expected := hexRecord{Owner: 16, Labels: labels, hidden: 1}
+	testCheck(c, check.DeepEquals, false, "Difference (-obtained +expected):\n"+
+		"...     @@ -1,5 +1,5 @@\n"+
+		"...      check_test.hexRecord{\n"+
+		"...     -    Owner: hexID(0xff),\n"+
+		"...     +    Owner: hexID(0x10),\n"+
+		"...          Labels: []string{\n"+
+		"...              \"a rather long label\",\n"+
+		"...              \"and another one to make the value long\",",
+		obtained, expected)
+
+	// Formatters aren't used for unexported fields.
+	testCheck(c, check.DeepEquals, false, "Difference (-obtained +expected):\n"+
+		"...     @@ -4,5 +4,5 @@\n"+
+		"...              \"a rather long label\",\n"+
+		"...              \"and another one to make the value long\",\n"+
+		"...          },\n"+
+		"...     -    hidden: 0x1,\n"+
+		"...     +    hidden: 0x2,\n"+
+		"...      }",
+		obtained, hexRecord{Owner: 255, Labels: labels, hidden: 2})
+
+	// Formatters are used for the values quoted in failure explanations.
+	testCheck(c, check.DeepEqualsIgnoring(), false, "mismatch at .Owner: obtained hexID(0xff), expected hexID(0x10)",
+		hexRecord{Owner: 255}, hexRecord{Owner: 16})
+	testCheck(c, check.HasKey, false, "Key hexID(0x1) not found, the map has: hexID(0x2)",
+		map[hexID]bool{2: true}, hexID(1))
+
+	// error states
+
+	c.Assert(func() { check.RegisterFormatter(func(id hexID) int { return 0 }) }, check.PanicMatches,
+		`formatter must be a func\(v T\) string, not func\(check_test.hexID\) int`)
+	c.Assert(func() { check.RegisterFormatter(nil) }, check.PanicMatches,
+		`formatter must be a func\(v T\) string, not <nil>`)
+}
+
 func (s *CheckersS) TestHasLen(c *check.C) {
 	testInfo(c, check.HasLen, "HasLen", []string{"obtained", "n"})
 
@@ -145,6 +385,88 @@ func (s *CheckersS) TestHasLen(c *check.C) {
 	testCheck(c, check.HasLen, false, "obtained value type has no length", nil, 2)
 }
 
+func (s *CheckersS) TestLenAtLeast(c *check.C) {
+	testInfo(c, check.LenAtLeast, "LenAtLeast", []string{"obtained", "min"})
+
+	testCheck(c, check.LenAtLeast, true, "", []int{1, 2}, 2)
+	testCheck(c, check.LenAtLeast, true, "", "abc", 0)
+	testCheck(c, check.LenAtLeast, false, "obtained length = 0", map[string]int{}, 1)
+
+	// error states
+
+	testCheck(c, check.LenAtLeast, false, "min must be an int", "abc", "2")
+	testCheck(c, check.LenAtLeast, false, "obtained value type has no length", 42, 1)
+}
+
+func (s *CheckersS) TestLenAtMost(c *check.C) {
+	testInfo(c, check.LenAtMost, "LenAtMost", []string{"obtained", "max"})
+
+	testCheck(c, check.LenAtMost, true, "", []int{1, 2}, 2)
+	testCheck(c, check.LenAtMost, true, "", "", 0)
+	testCheck(c, check.LenAtMost, false, "obtained length = 3", [3]int{}, 2)
+
+	// error states
+
+	testCheck(c, check.LenAtMost, false, "max must be an int", "abc", nil)
+	testCheck(c, check.LenAtMost, false, "max must not be negative", "abc", -1)
+	testCheck(c, check.LenAtMost, false, "obtained value type has no length", nil, 1)
+}
+
+func (s *CheckersS) TestLenBetween(c *check.C) {
+	testInfo(c, check.LenBetween, "LenBetween", []string{"obtained", "min", "max"})
+
+	testCheck(c, check.LenBetween, true, "", "password", 8, 64)
+	testCheck(c, check.LenBetween, true, "", []string{"a"}, 1, 1)
+	testCheck(c, check.LenBetween, false, "obtained length = 6", "secret", 8, 64)
+	testCheck(c, check.LenBetween, false, "obtained length = 2", map[int]bool{1: true, 2: false}, 0, 1)
+
+	// error states
+
+	testCheck(c, check.LenBetween, false, "min must be an int", "abc", 1.0, 2)
+	testCheck(c, check.LenBetween, false, "max must be an int", "abc", 1, 2.0)
+	testCheck(c, check.LenBetween, false, "min must not be greater than max", "abc", 2, 1)
+	testCheck(c, check.LenBetween, false, "max must not be negative", "abc", -5, -1)
+}
+
+func (s *CheckersS) TestErrorIs(c *check.C) {
+	testInfo(c, check.ErrorIs, "ErrorIs", []string{"value", "target"})
+
+	target := errors.New("target")
+	testCheck(c, check.ErrorIs, false, "Error value is nil", nil, target)
+	testCheck(c, check.ErrorIs, false, "Value is not an error", 1, target)
+	testCheck(c, check.ErrorIs, false, "Target is not an error", target, "target")
+	testCheck(c, check.ErrorIs, true, "", target, target)
+	testCheck(c, check.ErrorIs, true, "", fmt.Errorf("context: %w", target), target)
+	testCheck(c, check.ErrorIs, false, "", errors.New("target"), target)
+}
+
+type pathError struct {
+	path string
+}
+
+func (e *pathError) Error() string { return "bad path " + e.path }
+
+func (s *CheckersS) TestErrorAs(c *check.C) {
+	testInfo(c, check.ErrorAs, "ErrorAs", []string{"value", "target"})
+
+	var target *pathError
+	testCheck(c, check.ErrorAs, false, "Error value is nil", nil, &target)
+	testCheck(c, check.ErrorAs, false, "Value is not an error", 1, &target)
+	testCheck(c, check.ErrorAs, false, "Target must be a non-nil pointer", errors.New("some error"), target)
+	testCheck(c, check.ErrorAs, false, "Target must be a non-nil pointer", errors.New("some error"), nil)
+	testCheck(c, check.ErrorAs, false, "Target must point to an interface or to a type implementing error",
+		errors.New("some error"), new(string))
+	testCheck(c, check.ErrorAs, false, "", errors.New("some error"), &target)
+	c.Assert(target, check.IsNil)
+
+	testCheck(c, check.ErrorAs, true, "", fmt.Errorf("context: %w", &pathError{"/missing"}), &target)
+	c.Assert(target, check.NotNil)
+	c.Assert(target.path, check.Equals, "/missing")
+
+	var iface interface{ Error() string }
+	testCheck(c, check.ErrorAs, true, "", errors.New("some error"), &iface)
+}
+
 func (s *CheckersS) TestErrorMatches(c *check.C) {
 	testInfo(c, check.ErrorMatches, "ErrorMatches", []string{"value", "regex"})
 
@@ -159,6 +481,28 @@ func (s *CheckersS) TestErrorMatches(c *check.C) {
 	c.Assert(names[0], check.Equals, "error")
 }
 
+func (s *CheckersS) TestErrorContains(c *check.C) {
+	testInfo(c, check.ErrorContains, "ErrorContains", []string{"value", "substring"})
+
+	err := errors.New("listen tcp: port 8080 in use")
+	testCheck(c, check.ErrorContains, true, "", err, "8080 in use")
+	testCheck(c, check.ErrorContains, true, "", err, regexp.MustCompile(`port [0-9]+`))
+	testCheck(c, check.ErrorContains, false, "", err, "8081")
+	testCheck(c, check.ErrorContains, false, "", err, regexp.MustCompile(`^port`))
+
+	// Verify params mutation
+	params, names := testCheck(c, check.ErrorContains, false, "", err, "denied")
+	c.Assert(params[0], check.Equals, "listen tcp: port 8080 in use")
+	c.Assert(names[0], check.Equals, "error")
+
+	// error states
+
+	testCheck(c, check.ErrorContains, false, "Error value is nil", nil, "in use")
+	testCheck(c, check.ErrorContains, false, "Error value is nil", error(nil), "in use")
+	testCheck(c, check.ErrorContains, false, "Value is not an error", "port 8080 in use", "in use")
+	testCheck(c, check.ErrorContains, false, "Substring must be a string or a *regexp.Regexp", err, 8080)
+}
+
 func (s *CheckersS) TestMatches(c *check.C) {
 	testInfo(c, check.Matches, "Matches", []string{"value", "regex"})
 
@@ -174,11 +518,33 @@ func (s *CheckersS) TestMatches(c *check.C) {
 	testCheck(c, check.Matches, true, "", reflect.ValueOf("abc"), "a.c")
 	testCheck(c, check.Matches, false, "", reflect.ValueOf("abc"), "a.d")
 
+	// []byte values accepted
+	testCheck(c, check.Matches, true, "", []byte("abc"), "a.c")
+	testCheck(c, check.Matches, false, "", []byte("abc"), "a.d")
+
 	// Some error conditions.
-	testCheck(c, check.Matches, false, "Obtained value is not a string and has no .String()", 1, "a.c")
+	testCheck(c, check.Matches, false, "Obtained value is not a string or []byte and has no .String()", 1, "a.c")
 	testCheck(c, check.Matches, false, "Can't compile regex: error parsing regexp: missing closing ]: `[c$`", "abc", "a[c")
 }
 
+func (s *CheckersS) TestContainsMatch(c *check.C) {
+	testInfo(c, check.ContainsMatch, "ContainsMatch", []string{"value", "regex"})
+
+	logOutput := "INFO starting\nERROR request timeout\nINFO done\n"
+	testCheck(c, check.ContainsMatch, true, "", logOutput, "ERROR .*timeout")
+	testCheck(c, check.ContainsMatch, true, "", logOutput, "^ERROR .*timeout$")
+	testCheck(c, check.ContainsMatch, true, "", []byte(logOutput), "^INFO done$")
+	testCheck(c, check.ContainsMatch, true, "", reflect.ValueOf("abc"), "b")
+	testCheck(c, check.ContainsMatch, false, "", logOutput, "^timeout")
+	testCheck(c, check.ContainsMatch, false, "", logOutput, "WARN")
+
+	// error states
+
+	testCheck(c, check.ContainsMatch, false, "Regex must be a string", "abc", 1)
+	testCheck(c, check.ContainsMatch, false, "Obtained value is not a string or []byte and has no .String()", 1, "1")
+	testCheck(c, check.ContainsMatch, false, "Can't compile regex: error parsing regexp: missing closing ]: `[c`", "abc", "a[c")
+}
+
 func (s *CheckersS) TestPanics(c *check.C) {
 	testInfo(c, check.Panics, "Panics", []string{"function", "expected"})
 
@@ -234,6 +600,27 @@ func (s *CheckersS) TestPanicMatches(c *check.C) {
 	testCheck(c, check.PanicMatches, false, "Panic value is not a string or an error", func() { panic(nil) }, "")
 }
 
+func (s *CheckersS) TestPanicsWith(c *check.C) {
+	panicsWithIs := check.PanicsWith(check.ErrorIs)
+	testInfo(c, panicsWithIs, "PanicsWith(ErrorIs)", []string{"function", "target"})
+
+	errSyntax := errors.New("syntax error")
+	testCheck(c, panicsWithIs, true, "", func() { panic(fmt.Errorf("line 3: %w", errSyntax)) }, errSyntax)
+	testCheck(c, check.PanicsWith(check.FitsTypeOf), true, "", func() { panic(&pathError{"/tmp"}) }, &pathError{})
+	testCheck(c, check.PanicsWith(check.Equals), true, "", func() bool { panic(42) }, 42)
+
+	// Verify params/names mutation
+	params, names := testCheck(c, check.PanicsWith(check.Equals), false, "", func() { panic("KABOOM") }, "BOOM")
+	c.Assert(params[0], check.Equals, "KABOOM")
+	c.Assert(names[0], check.Equals, "panic")
+	testCheck(c, panicsWithIs, false, "Value is not an error", func() { panic("BOOM") }, errSyntax)
+
+	// error states
+
+	testCheck(c, panicsWithIs, false, "Function has not panicked", func() {}, errSyntax)
+	testCheck(c, panicsWithIs, false, "Function must take zero arguments", func(int) {}, errSyntax)
+}
+
 func (s *CheckersS) TestSliceIncludes(c *check.C) {
 	testInfo(c, check.SliceIncludes, "SliceIncludes", []string{"aSlice", "aThing"})
 	letters := []string{"A", "B", "C", "D"}
@@ -249,6 +636,28 @@ func (s *CheckersS) TestSliceIncludes(c *check.C) {
 	testCheck(c, check.SliceIncludes, false, "SliceIncludes given a non-slice type: not a slice", "not a slice", 1)
 }
 
+func (s *CheckersS) TestNotPanics(c *check.C) {
+	testInfo(c, check.NotPanics, "NotPanics", []string{"function"})
+
+	testCheck(c, check.NotPanics, true, "", func() {})
+	testCheck(c, check.NotPanics, true, "", func() bool { return false })
+
+	info := check.NotPanics.Info()
+	params := []interface{}{func() { panic("BOOM") }}
+	names := append([]string{}, info.Params...)
+	result, error := check.NotPanics.Check(params, names)
+	c.Assert(result, check.Equals, false)
+	c.Assert(error, check.Matches, "Function panicked at:\n"+
+		"\\.\\.\\.     .*checkers_test.go:[0-9]+\n\\.\\.\\.       in CheckersS.TestNotPanics.func[0-9]+")
+	c.Assert(params[0], check.Equals, "BOOM")
+	c.Assert(names[0], check.Equals, "panic")
+
+	// error states
+
+	testCheck(c, check.NotPanics, false, "Function must take zero arguments", 1)
+	testCheck(c, check.NotPanics, false, "Function must take zero arguments", func(int) {})
+}
+
 func (s *CheckersS) TestDoesntPanic(c *check.C) {
 	testInfo(c, check.DoesntPanic, "DoesntPanic", []string{"function"})
 
@@ -256,7 +665,14 @@ func (s *CheckersS) TestDoesntPanic(c *check.C) {
 	// Basic checks
 	//testCheck(c, check.IsTrue, false, "", false)
 
-	testCheck(c, check.DoesntPanic, false, "BOOM", func() bool { panic("BOOM") })
+	params := []interface{}{func() bool { panic("BOOM") }}
+	names := []string{"function"}
+	result, error := check.DoesntPanic.Check(params, names)
+	c.Assert(result, check.Equals, false)
+	c.Assert(error, check.Matches, "Function panicked at:\n"+
+		"\\.\\.\\.     .*checkers_test.go:[0-9]+\n\\.\\.\\.       in CheckersS.TestDoesntPanic.func[0-9]+")
+	c.Assert(params[0], check.Equals, "BOOM")
+	c.Assert(names[0], check.Equals, "panic")
 	testCheck(c, check.DoesntPanic, false, "Function must take zero arguments", 1)
 
 	testCheck(c, check.DoesntPanic, true, "", func() bool { return false })
@@ -300,6 +716,41 @@ func (s *CheckersS) TestImplements(c *check.C) {
 	testCheck(c, check.Implements, false, "", interface{}(nil), &e)
 }
 
+type celsius float64
+
+func (s *CheckersS) TestAssignableTo(c *check.C) {
+	testInfo(c, check.AssignableTo, "AssignableTo", []string{"obtained", "type"})
+
+	var e error
+	var stringer fmt.Stringer
+	testCheck(c, check.AssignableTo, true, "", errors.New(""), &e)
+	testCheck(c, check.AssignableTo, true, "", 1, reflect.TypeOf(0))
+	testCheck(c, check.AssignableTo, true, "", []int{1}, reflect.TypeOf([]int(nil)))
+	testCheck(c, check.AssignableTo, false, "Obtained type *errors.errorString is not assignable to fmt.Stringer", errors.New(""), &stringer)
+	testCheck(c, check.AssignableTo, false, "Obtained type float64 is not assignable to check_test.celsius", 1.5, reflect.TypeOf(celsius(0)))
+
+	// error states
+
+	testCheck(c, check.AssignableTo, false, "Obtained value is nil, which has no type", nil, &e)
+	testCheck(c, check.AssignableTo, false, "type must be a reflect.Type or a pointer to a variable of the type", 1, 0)
+	testCheck(c, check.AssignableTo, false, "type must be a reflect.Type or a pointer to a variable of the type", 1, nil)
+}
+
+func (s *CheckersS) TestConvertibleTo(c *check.C) {
+	testInfo(c, check.ConvertibleTo, "ConvertibleTo", []string{"obtained", "type"})
+
+	var e error
+	testCheck(c, check.ConvertibleTo, true, "", 1.5, reflect.TypeOf(celsius(0)))
+	testCheck(c, check.ConvertibleTo, true, "", 65, reflect.TypeOf(""))
+	testCheck(c, check.ConvertibleTo, true, "", errors.New(""), &e)
+	testCheck(c, check.ConvertibleTo, false, "Obtained type string is not convertible to int", "1", reflect.TypeOf(0))
+
+	// error states
+
+	testCheck(c, check.ConvertibleTo, false, "Obtained value is nil, which has no type", nil, reflect.TypeOf(0))
+	testCheck(c, check.ConvertibleTo, false, "type must be a reflect.Type or a pointer to a variable of the type", 1, "int")
+}
+
 func (s *CheckersS) TestIsTrue(c *check.C) {
 	testInfo(c, check.IsTrue, "IsTrue", []string{"obtained"})
 
@@ -347,3 +798,1497 @@ func (s *CheckersS) TestBetweenFloats(c *check.C) {
 	testCheck(c, check.BetweenFloats, false, "low must be a float64", 2.0, 1, 1.6)
 	testCheck(c, check.BetweenFloats, false, "high must be a float64", 2.0, 0.5, 1)
 }
+
+type fieldsMatchInner struct {
+	Port int
+}
+
+type fieldsMatchStruct struct {
+	Name  string
+	Tags  []string
+	inner int
+	*fieldsMatchInner
+}
+
+func (s *CheckersS) TestFieldsMatch(c *check.C) {
+	testInfo(c, check.FieldsMatch, "FieldsMatch", []string{"obtained", "fields"})
+
+	value := fieldsMatchStruct{Name: "x", Tags: []string{"a"}, fieldsMatchInner: &fieldsMatchInner{8080}}
+
+	testCheck(c, check.FieldsMatch, true, "", value, map[string]interface{}{"Name": "x", "Port": 8080})
+	testCheck(c, check.FieldsMatch, true, "", &value, map[string]interface{}{"Tags": []string{"a"}})
+	testCheck(c, check.FieldsMatch, true, "", value, map[string]interface{}{})
+	testCheck(c, check.FieldsMatch, false, "Mismatched fields:\nName: obtained \"x\", expected \"y\"",
+		value, map[string]interface{}{"Name": "y", "Port": 8080})
+	testCheck(c, check.FieldsMatch, false, "Mismatched fields:\nName: obtained \"x\", expected \"y\"\nPort: obtained 8080, expected 80",
+		value, map[string]interface{}{"Name": "y", "Port": 80})
+
+	// error states
+
+	testCheck(c, check.FieldsMatch, false, "check_test.fieldsMatchStruct has no field named \"Bogus\"",
+		value, map[string]interface{}{"Bogus": 1})
+	testCheck(c, check.FieldsMatch, false, "field \"inner\" of check_test.fieldsMatchStruct is unexported",
+		value, map[string]interface{}{"inner": 1})
+	testCheck(c, check.FieldsMatch, false, "field \"Port\" of check_test.fieldsMatchStruct is behind a nil embedded pointer",
+		fieldsMatchStruct{}, map[string]interface{}{"Port": 1})
+	testCheck(c, check.FieldsMatch, false, "obtained value is not a struct or pointer to struct",
+		42, map[string]interface{}{})
+	testCheck(c, check.FieldsMatch, false, "fields must be a map[string]interface{}",
+		value, map[string]int{"Port": 8080})
+}
+
+type approxPoint struct {
+	X, Y float64
+}
+
+type approxShape struct {
+	Name   string
+	Points []approxPoint
+	Meta   map[string]float64
+	Center *approxPoint
+}
+
+func (s *CheckersS) TestApproxDeepEquals(c *check.C) {
+	testInfo(c, check.ApproxDeepEquals, "ApproxDeepEquals", []string{"obtained", "expected", "tolerance"})
+
+	shape := func(x float64) approxShape {
+		return approxShape{
+			Name:   "tri",
+			Points: []approxPoint{{0, 0}, {1, 0}, {x, 1}},
+			Meta:   map[string]float64{"area": 0.5},
+			Center: &approxPoint{0.5, 1.0 / 3},
+		}
+	}
+
+	testCheck(c, check.ApproxDeepEquals, true, "", 0.1+0.2, 0.3, 1e-9)
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at top level: obtained 0.1, expected 0.3", 0.1, 0.3, 1e-9)
+	testCheck(c, check.ApproxDeepEquals, true, "", shape(0.5), shape(0.5+1e-12), 1e-9)
+	testCheck(c, check.ApproxDeepEquals, true, "", []float32{1, 2}, []float32{1.001, 2}, 0.01)
+	testCheck(c, check.ApproxDeepEquals, true, "", complex(1, 1), complex(1, 1.0001), 0.001)
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at .Points[2].X: obtained 0.5, expected 0.6",
+		shape(0.5), shape(0.6), 1e-9)
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at .Name: obtained \"tri\", expected \"quad\"",
+		shape(0.5), approxShape{Name: "quad"}, 1e-9)
+
+	a, b := shape(0.5), shape(0.5)
+	b.Meta["area"] = 0.6
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at .Meta[\"area\"]: obtained 0.5, expected 0.6", a, b, 1e-9)
+	b.Meta = map[string]float64{"size": 0.5}
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at .Meta[\"area\"]: key missing from expected value", a, b, 1e-9)
+	b = shape(0.5)
+	b.Points = b.Points[:2]
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at .Points: obtained length 3, expected length 2", a, b, 1e-9)
+	b = shape(0.5)
+	b.Center = nil
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at .Center: obtained &check_test.approxPoint{X:0.5, Y:0.3333333333333333}, expected (*check_test.approxPoint)(nil)", a, b, 1e-9)
+	testCheck(c, check.ApproxDeepEquals, false, "mismatch at top level: obtained type int, expected type float64", 1, 1.0, 1e-9)
+	testCheck(c, check.ApproxDeepEquals, true, "", nil, nil, 1e-9)
+
+	// error states
+
+	testCheck(c, check.ApproxDeepEquals, false, "tolerance must be a float64", 1.0, 1.0, 1)
+	testCheck(c, check.ApproxDeepEquals, false, "tolerance must not be negative", 1.0, 1.0, -1.0)
+}
+
+type multiError []error
+
+func (e multiError) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e multiError) Unwrap() []error {
+	return e
+}
+
+func (s *CheckersS) TestErrorChain(c *check.C) {
+	testInfo(c, check.ErrorChain, "ErrorChain", []string{"value", "expected"})
+
+	root := errors.New("root")
+	middle := fmt.Errorf("middle: %w", root)
+	outer := fmt.Errorf("outer: %w", middle)
+
+	testCheck(c, check.ErrorChain, true, "", outer, []string{"outer", "middle", "root"})
+	testCheck(c, check.ErrorChain, true, "", outer, []string{"outer: middle: root", "middle: root", "root"})
+	testCheck(c, check.ErrorChain, true, "", root, []string{"root"})
+	testCheck(c, check.ErrorChain, false, "Error chain diverges at link 1: obtained \"middle\", expected \"inner\"",
+		outer, []string{"outer", "inner", "root"})
+	testCheck(c, check.ErrorChain, false, "Error chain has 3 links, expected 2", outer, []string{"outer", "middle"})
+	testCheck(c, check.ErrorChain, false, "Error chain has 3 links, expected 4", outer, []string{"outer", "middle", "root", "deeper"})
+
+	// Multi-errors are traversed depth-first.
+	multi := fmt.Errorf("top: %w", multiError{middle, errors.New("other")})
+	testCheck(c, check.ErrorChain, true, "", multi, []string{"top", "middle: root; other", "middle", "root", "other"})
+
+	// Verify params mutation
+	params, names := testCheck(c, check.ErrorChain, false, "Error chain has 3 links, expected 1", outer, []string{"outer"})
+	c.Assert(params[0], check.DeepEquals, []string{"outer: middle: root", "middle: root", "root"})
+	c.Assert(names[0], check.Equals, "chain")
+
+	// error states
+
+	testCheck(c, check.ErrorChain, false, "Error value is nil", nil, []string{"root"})
+	testCheck(c, check.ErrorChain, false, "Value is not an error", 1, []string{"root"})
+	testCheck(c, check.ErrorChain, false, "expected must be a []string", root, "root")
+}
+
+func (s *CheckersS) TestHasErrorCount(c *check.C) {
+	testInfo(c, check.HasErrorCount, "HasErrorCount", []string{"value", "count"})
+
+	first, second := errors.New("first"), errors.New("second")
+	// multiError stands for the errors joined by errors.Join.
+	joined := multiError{fmt.Errorf("wrapped: %w", first), second, multiError{errors.New("third")}}
+	testCheck(c, check.HasErrorCount, true, "", joined, 3)
+	testCheck(c, check.HasErrorCount, true, "", fmt.Errorf("top: %w", joined), 3)
+	testCheck(c, check.HasErrorCount, true, "", first, 1)
+	testCheck(c, check.HasErrorCount, true, "", nil, 0)
+	testCheck(c, check.HasErrorCount, true, "", error(nil), 0)
+
+	params, names := testCheck(c, check.HasErrorCount, false, "Error has 2 errors, expected 1", multiError{first, second}, 1)
+	c.Assert(params[0], check.DeepEquals, []string{"first; second", "first", "second"})
+	c.Assert(names[0], check.Equals, "chain")
+
+	// error states
+
+	testCheck(c, check.HasErrorCount, false, "Value is not an error", "first", 1)
+	testCheck(c, check.HasErrorCount, false, "count must be an int", first, "1")
+}
+
+func (s *CheckersS) TestContainsError(c *check.C) {
+	containsMatch := check.ContainsError(check.ErrorMatches)
+	testInfo(c, containsMatch, "ContainsError(ErrorMatches)", []string{"value", "regex"})
+
+	required := fmt.Errorf("validating: %w", multiError{errors.New("field name is required"), &pathError{"/tmp"}})
+	testCheck(c, containsMatch, true, "", required, "field .* is required")
+	testCheck(c, containsMatch, true, "", required, "validating: .*")
+	testCheck(c, check.ContainsError(check.FitsTypeOf), true, "", required, &pathError{})
+	testCheck(c, check.ContainsError(check.Equals), true, "", io.EOF, io.EOF)
+
+	params, names := testCheck(c, containsMatch, false, "None of the 4 errors of the chain satisfies ErrorMatches",
+		required, "field .* is too long")
+	c.Assert(params[0], check.DeepEquals, []string{"validating: field name is required; bad path /tmp",
+		"field name is required; bad path /tmp", "field name is required", "bad path /tmp"})
+	c.Assert(names[0], check.Equals, "chain")
+
+	// error states
+
+	testCheck(c, containsMatch, false, "Error value is nil", nil, "")
+	testCheck(c, containsMatch, false, "Value is not an error", "field name is required", "")
+}
+
+func (s *CheckersS) TestWithinPercent(c *check.C) {
+	testInfo(c, check.WithinPercent, "WithinPercent", []string{"obtained", "expected", "percent"})
+
+	testCheck(c, check.WithinPercent, true, "", 105, 100, 10.0)
+	testCheck(c, check.WithinPercent, true, "", 90, 100, 10)
+	testCheck(c, check.WithinPercent, true, "", uint8(95), 100.0, 5.0)
+	testCheck(c, check.WithinPercent, true, "", -105.0, -100.0, 10.0)
+	testCheck(c, check.WithinPercent, false, "Expected 100 ± 10% (between 90 and 110), obtained 111",
+		111, 100, 10.0)
+	testCheck(c, check.WithinPercent, true, "", 1050*time.Millisecond, time.Second, 10.0)
+	testCheck(c, check.WithinPercent, false, "Expected 1s ± 10% (between 900ms and 1.1s), obtained 1.2s",
+		1200*time.Millisecond, time.Second, 10.0)
+
+	// Zero only matches zero.
+	testCheck(c, check.WithinPercent, true, "", 0, 0, 10.0)
+	testCheck(c, check.WithinPercent, false, "Expected 0 ± 10% (between 0 and 0), obtained 1e-09",
+		1e-9, 0, 10.0)
+
+	// error states
+
+	testCheck(c, check.WithinPercent, false, "obtained value is not a number", "1", 1, 10.0)
+	testCheck(c, check.WithinPercent, false, "expected value is not a number", 1, "1", 10.0)
+	testCheck(c, check.WithinPercent, false, "percent must be a number", 1, 1, "10")
+	testCheck(c, check.WithinPercent, false, "percent must not be negative", 1, 1, -10.0)
+}
+
+func (s *CheckersS) TestIsZero(c *check.C) {
+	testInfo(c, check.IsZero, "IsZero", []string{"value"})
+
+	testCheck(c, check.IsZero, true, "", nil)
+	testCheck(c, check.IsZero, true, "", 0)
+	testCheck(c, check.IsZero, true, "", "")
+	testCheck(c, check.IsZero, true, "", (*int)(nil))
+	testCheck(c, check.IsZero, true, "", ([]int)(nil))
+	testCheck(c, check.IsZero, true, "", approxPoint{})
+	testCheck(c, check.IsZero, true, "", time.Time{})
+
+	testCheck(c, check.IsZero, false, "", 1)
+	testCheck(c, check.IsZero, false, "", "a")
+	testCheck(c, check.IsZero, false, "", []int{})
+	testCheck(c, check.IsZero, false, "Non-zero fields: Y", approxPoint{Y: 1})
+	testCheck(c, check.IsZero, false, "Non-zero fields: X, Y", approxPoint{X: 1, Y: 2})
+	testCheck(c, check.IsZero, false, "", &approxPoint{})
+	testCheck(c, check.IsZero, false, "", time.Unix(1, 0))
+}
+
+func (s *CheckersS) TestNotZero(c *check.C) {
+	testInfo(c, check.NotZero, "NotZero", []string{"value"})
+
+	testCheck(c, check.NotZero, true, "", 1)
+	testCheck(c, check.NotZero, true, "", "a")
+	testCheck(c, check.NotZero, true, "", approxPoint{X: 1})
+
+	testCheck(c, check.NotZero, false, "", 0)
+	testCheck(c, check.NotZero, false, "", approxPoint{})
+	testCheck(c, check.NotZero, false, "Value is a nil interface, which is zero", nil)
+}
+
+func (s *CheckersS) TestHasExactKeys(c *check.C) {
+	testInfo(c, check.HasExactKeys, "HasExactKeys", []string{"obtained", "keys"})
+
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+
+	testCheck(c, check.HasExactKeys, true, "", m, []string{"c", "a", "b"})
+	testCheck(c, check.HasExactKeys, true, "", map[string]int{}, []string{})
+	testCheck(c, check.HasExactKeys, true, "", map[int]bool{1: true}, []int{1})
+	testCheck(c, check.HasExactKeys, false, "Missing keys: \"d\"", m, []string{"a", "b", "c", "d"})
+	testCheck(c, check.HasExactKeys, false, "Unexpected keys: \"b\", \"c\"", m, []string{"a"})
+	testCheck(c, check.HasExactKeys, false, "Missing keys: \"d\"\nUnexpected keys: \"c\"", m, []string{"a", "b", "d"})
+
+	// error states
+
+	testCheck(c, check.HasExactKeys, false, "obtained value is not a map", []string{"a"}, []string{"a"})
+	testCheck(c, check.HasExactKeys, false, "keys must be a slice", m, "a")
+	testCheck(c, check.HasExactKeys, false, "keys of type []int can't be keys of map[string]int", m, []int{1})
+}
+
+func (s *CheckersS) TestHappensBefore(c *check.C) {
+	testInfo(c, check.HappensBefore, "HappensBefore", []string{"obtained", "later"})
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(250 * time.Millisecond)
+
+	testCheck(c, check.HappensBefore, true, "", t0, t1)
+	testCheck(c, check.HappensBefore, false, "Obtained time is equal to the later one", t0, t0)
+	testCheck(c, check.HappensBefore, false, "Obtained time is 250ms after the later one", t1, t0)
+
+	// error states
+
+	testCheck(c, check.HappensBefore, false, "obtained value is not a time.Time", "now", t1)
+	testCheck(c, check.HappensBefore, false, "later value is not a time.Time", t0, 5)
+}
+
+func (s *CheckersS) TestHappensBeforeBy(c *check.C) {
+	testInfo(c, check.HappensBeforeBy, "HappensBeforeBy", []string{"obtained", "later", "gap"})
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(250 * time.Millisecond)
+
+	testCheck(c, check.HappensBeforeBy, true, "", t0, t1, 100*time.Millisecond)
+	testCheck(c, check.HappensBeforeBy, true, "", t0, t1, 250*time.Millisecond)
+	testCheck(c, check.HappensBeforeBy, false,
+		"Obtained time is 250ms before the later one, expected it to be at least 1s before", t0, t1, time.Second)
+	testCheck(c, check.HappensBeforeBy, false,
+		"Obtained time is 250ms after the later one, expected it to be at least 0s before", t1, t0, time.Duration(0))
+
+	// error states
+
+	testCheck(c, check.HappensBeforeBy, false, "obtained value is not a time.Time", t0.Unix(), t1, time.Second)
+	testCheck(c, check.HappensBeforeBy, false, "gap must be a time.Duration", t0, t1, 100)
+	testCheck(c, check.HappensBeforeBy, false, "gap must not be negative", t0, t1, -time.Second)
+}
+
+func (s *CheckersS) TestWithinDuration(c *check.C) {
+	testInfo(c, check.WithinDuration, "WithinDuration", []string{"obtained", "expected", "duration"})
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(250 * time.Millisecond)
+
+	testCheck(c, check.WithinDuration, true, "", t0, t1, time.Second)
+	testCheck(c, check.WithinDuration, true, "", t1, t0, 250*time.Millisecond)
+	testCheck(c, check.WithinDuration, false,
+		"Obtained time is 250ms before the expected one, more than 100ms", t0, t1, 100*time.Millisecond)
+	testCheck(c, check.WithinDuration, false,
+		"Obtained time is 250ms after the expected one, more than 0s", t1, t0, time.Duration(0))
+
+	// The same instant in another location.
+	testCheck(c, check.WithinDuration, true, "", t0, t0.In(time.FixedZone("UTC+5", 5*3600)), time.Duration(0))
+
+	// Times with and without a monotonic clock reading.
+	now := time.Now()
+	testCheck(c, check.WithinDuration, true, "", now, now.Round(0), time.Duration(0))
+
+	// error states
+
+	testCheck(c, check.WithinDuration, false, "obtained value is not a time.Time", t0.Unix(), t1, time.Second)
+	testCheck(c, check.WithinDuration, false, "expected value is not a time.Time", t0, nil, time.Second)
+	testCheck(c, check.WithinDuration, false, "duration must be a time.Duration", t0, t1, 100)
+	testCheck(c, check.WithinDuration, false, "duration must not be negative", t0, t1, -time.Second)
+}
+
+func (s *CheckersS) TestIsFinite(c *check.C) {
+	testInfo(c, check.IsFinite, "IsFinite", []string{"value"})
+
+	testCheck(c, check.IsFinite, true, "", 1.5)
+	testCheck(c, check.IsFinite, true, "", float32(-2))
+	testCheck(c, check.IsFinite, false, "expected a finite number, got +Inf", math.Inf(1))
+	testCheck(c, check.IsFinite, false, "expected a finite number, got -Inf", float32(math.Inf(-1)))
+	testCheck(c, check.IsFinite, false, "expected a finite number, got NaN", math.NaN())
+
+	// error states
+
+	testCheck(c, check.IsFinite, false, "value must be a float, got int", 1)
+	testCheck(c, check.IsFinite, false, "value must be a float, got nil", nil)
+}
+
+func (s *CheckersS) TestIsNaN(c *check.C) {
+	testInfo(c, check.IsNaN, "IsNaN", []string{"value"})
+
+	testCheck(c, check.IsNaN, true, "", math.NaN())
+	testCheck(c, check.IsNaN, true, "", float32(math.NaN()))
+	testCheck(c, check.IsNaN, false, "", 1.5)
+	testCheck(c, check.IsNaN, false, "", math.Inf(1))
+
+	// error states
+
+	testCheck(c, check.IsNaN, false, "value must be a float, got string", "NaN")
+}
+
+func (s *CheckersS) TestNotNaN(c *check.C) {
+	testInfo(c, check.NotNaN, "NotNaN", []string{"value"})
+
+	testCheck(c, check.NotNaN, true, "", 1.5)
+	testCheck(c, check.NotNaN, true, "", math.Inf(-1))
+	testCheck(c, check.NotNaN, false, "expected a number, got NaN", math.NaN())
+
+	// error states
+
+	testCheck(c, check.NotNaN, false, "value must be a float, got int64", int64(1))
+}
+
+func (s *CheckersS) TestJSONEquals(c *check.C) {
+	testInfo(c, check.JSONEquals, "JSONEquals", []string{"obtained", "expected"})
+
+	// Formatting and key order don't matter.
+	testCheck(c, check.JSONEquals, true, "", `{"a": 1, "b": [true, null]}`, `{"b":[true,null],"a":1.0}`)
+	testCheck(c, check.JSONEquals, true, "", []byte(`{"a": 1}`), json.RawMessage(`{"a":1}`))
+	testCheck(c, check.JSONEquals, true, "", `{"id": 1, "tags": ["x"]}`, map[string]interface{}{"tags": []string{"x"}, "id": 1})
+	testCheck(c, check.JSONEquals, true, "", struct {
+		ID int `json:"id"`
+	}{1}, `{"id": 1}`)
+
+	// Differences are listed by path.
+	testCheck(c, check.JSONEquals, false, "Documents differ:\n"+
+		"$.a: obtained 1, expected 2\n"+
+		"$.b[1]: obtained \"x\", expected \"y\"\n"+
+		"$[\"c d\"]: missing, expected {\"e\":true}\n"+
+		"$.f: unexpected null\n"+
+		"$.g: obtained 2 elements, expected 1",
+		`{"a": 1, "b": ["w", "x"], "f": null, "g": [1, 2]}`,
+		`{"a": 2, "b": ["w", "y"], "c d": {"e": true}, "g": [1]}`)
+	testCheck(c, check.JSONEquals, false, "Documents differ:\n$: obtained {}, expected []", "{}", "[]")
+
+	// Large integers aren't rounded.
+	testCheck(c, check.JSONEquals, true, "", `{"id": 9007199254740993}`, `{"id": 9007199254740993.0}`)
+	testCheck(c, check.JSONEquals, true, "", `[1e2]`, []int{100})
+	testCheck(c, check.JSONEquals, false, "Documents differ:\n$.id: obtained 9007199254740993, expected 9007199254740992",
+		`{"id": 9007199254740993}`, map[string]interface{}{"id": uint64(9007199254740992)})
+
+	// error states
+
+	testCheck(c, check.JSONEquals, false, "obtained value is not valid JSON: unexpected end of JSON input", "{", "{}")
+	testCheck(c, check.JSONEquals, false, "expected value is not valid JSON: invalid character 'x' looking for beginning of value", "{}", "x")
+	testCheck(c, check.JSONEquals, false, "obtained value is not valid JSON: json: unsupported type: chan int", make(chan int), "{}")
+}
+
+func (s *CheckersS) TestJSONPath(c *check.C) {
+	testInfo(c, check.JSONPath("items.0.id", check.Equals), `JSONPath("items.0.id", Equals)`, []string{"document", "expected"})
+
+	doc := `{"items": [{"id": "abc", "n": 2}], "labels": {"app.kind": "web"}}`
+	testCheck(c, check.JSONPath("items.0.id", check.Equals), true, "", doc, "abc")
+	testCheck(c, check.JSONPath("items.0.n", check.Equals), true, "", []byte(doc), 2.0)
+	testCheck(c, check.JSONPath("items.#", check.Equals), true, "", doc, 1.0)
+	testCheck(c, check.JSONPath(`labels.app\.kind`, check.Equals), true, "", doc, "web")
+	testCheck(c, check.JSONPath("items", check.HasLen), true, "", doc, 1)
+	testCheck(c, check.JSONPath("id", check.Equals), true, "", map[string]interface{}{"id": 1}, 1.0)
+	testCheck(c, check.JSONPath("id", check.Equals), true, "", `{"id": 9007199254740993}`, int64(9007199254740993))
+	testCheck(c, check.JSONPath("id", check.Equals), true, "", `{"id": 18446744073709551615}`, uint64(18446744073709551615))
+	testCheck(c, check.JSONPath("items.0", check.DeepEquals), true, "", doc, map[string]interface{}{"id": "abc", "n": 2.0})
+
+	// The value found is shown when the checker fails.
+	params, names := testCheck(c, check.JSONPath("items.0.id", check.Equals), false, "", doc, "xyz")
+	c.Assert(params, check.DeepEquals, []interface{}{"abc", "xyz"})
+	c.Assert(names, check.DeepEquals, []string{"items.0.id", "expected"})
+
+	// Paths which aren't in the document.
+	testCheck(c, check.JSONPath("items.1.id", check.Equals), false, "No index 1 in the array at items, of length 1", doc, "abc")
+	testCheck(c, check.JSONPath("items.x", check.Equals), false, `Key "x" is not an index of the array at items`, doc, "abc")
+	testCheck(c, check.JSONPath("labels.app.kind", check.Equals), false, `No key "app" in the object at labels`, doc, "web")
+	testCheck(c, check.JSONPath("items.0.id.x", check.Equals), false, `No key "x" in items.0.id, which is "abc"`, doc, "abc")
+	testCheck(c, check.JSONPath("id", check.Equals), false, `No key "id" in the root, which is 1`, "1", "abc")
+
+	// error states
+
+	testCheck(c, check.JSONPath("id", check.Equals), false, "document is not valid JSON: unexpected end of JSON input", "{", "abc")
+}
+
+type pathContainer struct {
+	Image string
+	Ports []int
+}
+
+type pathPod struct {
+	Spec struct {
+		Containers []*pathContainer
+	}
+	Labels map[string]interface{}
+	Owner  *pathPod
+	secret string
+}
+
+func (s *CheckersS) TestHasPath(c *check.C) {
+	testInfo(c, check.HasPath("spec.image", check.Equals), `HasPath("spec.image", Equals)`, []string{"value", "expected"})
+
+	pod := &pathPod{Labels: map[string]interface{}{"app.kind": "web", "tier": []string{"front"}}}
+	pod.Spec.Containers = []*pathContainer{{Image: "nginx", Ports: []int{80, 443}}}
+	testCheck(c, check.HasPath("Spec.Containers.0.Image", check.Equals), true, "", pod, "nginx")
+	testCheck(c, check.HasPath("Spec.Containers.0.Ports.1", check.Equals), true, "", pod, 443)
+	testCheck(c, check.HasPath(`Labels.app\.kind`, check.Equals), true, "", pod, "web")
+	testCheck(c, check.HasPath("Labels.tier.0", check.Equals), true, "", pod, "front")
+	testCheck(c, check.HasPath("Owner", check.IsNil), true, "", pod)
+	testCheck(c, check.HasPath("1.2", check.Equals), true, "", map[int][]string{1: {"a", "b", "c"}}, "c")
+
+	// The value found is shown when the checker fails.
+	params, names := testCheck(c, check.HasPath("Spec.Containers.0.Image", check.Equals), false, "", pod, "httpd")
+	c.Assert(params, check.DeepEquals, []interface{}{"nginx", "httpd"})
+	c.Assert(names, check.DeepEquals, []string{"Spec.Containers.0.Image", "expected"})
+
+	// Paths which aren't in the value.
+	testCheck(c, check.HasPath("Spec.Containers.1.Image", check.Equals), false,
+		"No index 1 in the []*check_test.pathContainer at Spec.Containers, of length 1", pod, "nginx")
+	testCheck(c, check.HasPath("Spec.Containers.x", check.Equals), false,
+		`Key "x" is not an index of the []*check_test.pathContainer at Spec.Containers`, pod, "nginx")
+	testCheck(c, check.HasPath("Spec.Name", check.Equals), false,
+		`No field "Name" in the struct { Containers []*check_test.pathContainer } at Spec`, pod, "nginx")
+	testCheck(c, check.HasPath("Labels.app", check.Equals), false, `No key "app" in the map at Labels`, pod, "web")
+	testCheck(c, check.HasPath("Owner.Labels", check.IsNil), false, `No key "Labels" in Owner, which is nil`, pod)
+	testCheck(c, check.HasPath("Spec.Containers.0.Image.x", check.Equals), false,
+		`No key "x" in Spec.Containers.0.Image, which is "nginx"`, pod, "nginx")
+	testCheck(c, check.HasPath("x", check.IsNil), false, `No key "x" in the root, which is nil`, nil)
+
+	// error states
+
+	testCheck(c, check.HasPath("secret", check.Equals), false,
+		`Field "secret" of the check_test.pathPod at the root is unexported`, pod, "")
+}
+
+func (s *CheckersS) TestYAMLEquals(c *check.C) {
+	testInfo(c, check.YAMLEquals, "YAMLEquals", []string{"obtained", "expected"})
+
+	defer func(unmarshal func([]byte, interface{}) error) { check.YAMLUnmarshal = unmarshal }(check.YAMLUnmarshal)
+	check.YAMLUnmarshal = nil
+	testCheck(c, check.YAMLEquals, false, "YAMLUnmarshal must be set to decode YAML, e.g. to yaml.Unmarshal", "a: 1", "a: 1")
+
+	// JSON is YAML, so it can stand for a YAML library here.
+	check.YAMLUnmarshal = json.Unmarshal
+	testCheck(c, check.YAMLEquals, true, "", `{"a": 1, "b": [true]}`, []byte(`{"b": [true], "a": 1}`))
+	testCheck(c, check.YAMLEquals, false, "Documents differ:\n$.b[0]: obtained true, expected false",
+		`{"a": 1, "b": [true]}`, `{"a": 1, "b": [false]}`)
+
+	// Like gopkg.in/yaml.v2, libraries may decode maps with keys of any type.
+	check.YAMLUnmarshal = func(in []byte, out interface{}) error {
+		*out.(*interface{}) = map[interface{}]interface{}{
+			"name": string(in), 1: []interface{}{int64(2), float32(3)},
+		}
+		return nil
+	}
+	testCheck(c, check.YAMLEquals, true, "", "app", "app")
+	testCheck(c, check.YAMLEquals, false, "Documents differ:\n$.name: obtained \"app\", expected \"db\"", "app", "db")
+
+	// Like YAML libraries, decode integers as int64 or uint64 and other
+	// numbers as float64. Large integers aren't rounded.
+	check.YAMLUnmarshal = func(in []byte, out interface{}) error {
+		decoder := json.NewDecoder(bytes.NewReader(in))
+		decoder.UseNumber()
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			return err
+		}
+		for key, value := range doc {
+			n := value.(json.Number)
+			if i, err := n.Int64(); err == nil {
+				doc[key] = i
+			} else if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+				doc[key] = u
+			} else {
+				doc[key], _ = n.Float64()
+			}
+		}
+		*out.(*interface{}) = doc
+		return nil
+	}
+	testCheck(c, check.YAMLEquals, true, "", `{"id": 9007199254740993}`, `{"id": 9007199254740993}`)
+	testCheck(c, check.YAMLEquals, true, "", `{"id": 18446744073709551615}`, `{"id": 18446744073709551615}`)
+	testCheck(c, check.YAMLEquals, true, "", `{"n": 1e2}`, `{"n": 100}`)
+	testCheck(c, check.YAMLEquals, false, "Documents differ:\n$.id: obtained 9007199254740993, expected 9007199254740992",
+		`{"id": 9007199254740993}`, `{"id": 9007199254740992}`)
+	testCheck(c, check.YAMLEquals, false, "Documents differ:\n$.id: obtained 18446744073709551615, expected 18446744073709551614",
+		`{"id": 18446744073709551615}`, `{"id": 18446744073709551614}`)
+
+	// error states
+
+	check.YAMLUnmarshal = json.Unmarshal
+	testCheck(c, check.YAMLEquals, false, "obtained value must be a string or []byte, got int", 1, "1")
+	testCheck(c, check.YAMLEquals, false, "expected value is not valid YAML: unexpected end of JSON input", "{}", "{")
+}
+
+// protoMessage is laid out like the messages protoc-gen-go generates.
+type protoMessage struct {
+	state         struct{ messageInfo *int }
+	sizeCache     int32
+	unknownFields []byte
+
+	Name  string
+	Child *protoMessage
+}
+
+// protoEqual stands for proto.Equal, which compares unknown fields too.
+func protoEqual(a, b interface{}) bool {
+	x, y := a.(*protoMessage), b.(*protoMessage)
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Name == y.Name && string(x.unknownFields) == string(y.unknownFields) && protoEqual(x.Child, y.Child)
+}
+
+func (s *CheckersS) TestProtoEquals(c *check.C) {
+	testInfo(c, check.ProtoEquals, "ProtoEquals", []string{"obtained", "expected"})
+
+	defer func(equal func(a, b interface{}) bool) { check.ProtoEqual = equal }(check.ProtoEqual)
+	check.ProtoEqual = nil
+	testCheck(c, check.ProtoEquals, false, "ProtoEqual must be set to compare messages, e.g. to a func calling proto.Equal",
+		&protoMessage{}, &protoMessage{})
+
+	check.ProtoEqual = protoEqual
+	testCheck(c, check.ProtoEquals, true, "", &protoMessage{Name: "a", sizeCache: 3}, &protoMessage{Name: "a"})
+	testCheck(c, check.ProtoEquals, false, "mismatch at .Child.Name: obtained \"b\", expected \"c\"",
+		&protoMessage{Name: "a", Child: &protoMessage{Name: "b", sizeCache: 3}},
+		&protoMessage{Name: "a", Child: &protoMessage{Name: "c"}})
+	testCheck(c, check.ProtoEquals, false, "Messages differ in their unknown fields",
+		&protoMessage{Name: "a", unknownFields: []byte{8, 1}}, &protoMessage{Name: "a"})
+}
+
+func (s *CheckersS) TestAlmostEquals(c *check.C) {
+	testInfo(c, check.AlmostEquals, "AlmostEquals", []string{"obtained", "expected", "epsilon"})
+
+	// Absolute tolerance.
+	testCheck(c, check.AlmostEquals, true, "", 3.1415926, 3.14159, 1e-5)
+	testCheck(c, check.AlmostEquals, true, "", 0.1+0.2, 0.3, 1e-9)
+	testCheck(c, check.AlmostEquals, true, "", float32(1.5), 1, 0.5)
+	testCheck(c, check.AlmostEquals, false, "Difference of 0.5 exceeds the tolerance", 1.0, 1.5, 0.25)
+
+	// Relative tolerance.
+	testCheck(c, check.AlmostEquals, true, "", 6.0221e23, 6.0222e23, check.Tolerance{Rel: 1e-4})
+	testCheck(c, check.AlmostEquals, false, "Difference of 1 exceeds the tolerance", 99.0, 100.0, check.Tolerance{Rel: 1e-3})
+
+	// Either tolerance is enough.
+	testCheck(c, check.AlmostEquals, true, "", 0.0, 1e-12, check.Tolerance{Abs: 1e-9, Rel: 1e-6})
+	testCheck(c, check.AlmostEquals, true, "", 1e9, 1e9+1, check.Tolerance{Abs: 1e-9, Rel: 1e-6})
+
+	// Special values.
+	testCheck(c, check.AlmostEquals, true, "", math.Inf(1), math.Inf(1), 0)
+	testCheck(c, check.AlmostEquals, false, "Difference of NaN exceeds the tolerance", math.NaN(), math.NaN(), 1.0)
+
+	// error states
+
+	testCheck(c, check.AlmostEquals, false, "obtained value is not a number", "1", 1.0, 0.1)
+	testCheck(c, check.AlmostEquals, false, "expected value is not a number", 1.0, nil, 0.1)
+	testCheck(c, check.AlmostEquals, false, "epsilon must be a number or a Tolerance", 1.0, 1.0, "0.1")
+	testCheck(c, check.AlmostEquals, false, "epsilon must not be negative", 1.0, 1.0, check.Tolerance{Rel: -1})
+}
+
+func (s *CheckersS) TestSliceAlmostEquals(c *check.C) {
+	testInfo(c, check.SliceAlmostEquals, "SliceAlmostEquals", []string{"obtained", "expected", "epsilon"})
+
+	testCheck(c, check.SliceAlmostEquals, true, "", []float64{0, 0.7071, 1}, []float64{0, 0.707, 1}, 1e-3)
+	testCheck(c, check.SliceAlmostEquals, true, "", [2]float32{1, 2}, []int{1, 2}, 0)
+	testCheck(c, check.SliceAlmostEquals, true, "", []float64{1e9}, []float64{1e9 + 1}, check.Tolerance{Rel: 1e-6})
+	testCheck(c, check.SliceAlmostEquals, true, "", []float64(nil), []float64{}, 0.1)
+	testCheck(c, check.SliceAlmostEquals, false, "1 of 3 elements differ:\n[1]: obtained 0.75, expected 0.5, difference 0.25",
+		[]float64{0, 0.75, 1}, []float64{0, 0.5, 1}, 0.1)
+	testCheck(c, check.SliceAlmostEquals, false, "7 of 7 elements differ:\n"+
+		"[0]: obtained 1, expected 0, difference 1\n[1]: obtained 1, expected 0, difference 1\n"+
+		"[2]: obtained 1, expected 0, difference 1\n[3]: obtained 1, expected 0, difference 1\n"+
+		"[4]: obtained 1, expected 0, difference 1\n... and 2 more",
+		[]int{1, 1, 1, 1, 1, 1, 1}, make([]int, 7), 0.5)
+	testCheck(c, check.SliceAlmostEquals, false, "Obtained length 2, expected length 3", []float64{1, 2}, []float64{1, 2, 3}, 0.1)
+
+	// error states
+
+	testCheck(c, check.SliceAlmostEquals, false, "obtained value must be a slice or array", 1.0, []float64{1}, 0.1)
+	testCheck(c, check.SliceAlmostEquals, false, "expected value must be a slice or array", []float64{1}, 1.0, 0.1)
+	testCheck(c, check.SliceAlmostEquals, false, "epsilon must be a number or a Tolerance", []float64{1}, []float64{1}, "0.1")
+	testCheck(c, check.SliceAlmostEquals, false, "epsilon must not be negative", []float64{1}, []float64{1}, -0.1)
+	testCheck(c, check.SliceAlmostEquals, false, "obtained element 1 is not a number", []interface{}{1, "2"}, []float64{1, 2}, 0.1)
+	testCheck(c, check.SliceAlmostEquals, false, "expected element 0 is not a number", []float64{1}, []string{"1"}, 0.1)
+}
+
+func (s *CheckersS) TestXMLEquals(c *check.C) {
+	testInfo(c, check.XMLEquals, "XMLEquals", []string{"obtained", "expected"})
+
+	// Attribute order, whitespace, comments and prefixes don't matter.
+	testCheck(c, check.XMLEquals, true, "", `<a x="1" y="2"><b> text </b></a>`, "<a y=\"2\" x=\"1\">\n  <!-- note -->\n  <b>text</b>\n</a>")
+	testCheck(c, check.XMLEquals, true, "",
+		`<s:Envelope xmlns:s="urn:soap"><s:Body>ok</s:Body></s:Envelope>`,
+		[]byte(`<Envelope xmlns="urn:soap"><Body><![CDATA[ok]]></Body></Envelope>`))
+	testCheck(c, check.XMLEquals, true, "", struct {
+		XMLName xml.Name `xml:"user"`
+		ID      int      `xml:"id,attr"`
+	}{ID: 1}, `<user id="1"></user>`)
+
+	// Differences are listed by path.
+	testCheck(c, check.XMLEquals, false, "Documents differ:\n"+
+		"/a/@id: obtained \"1\", expected \"2\"\n"+
+		"/a/@new: missing, expected \"x\"\n"+
+		"/a/@old: unexpected \"y\"\n"+
+		"/a/b[2]/text(): obtained \"u\", expected \"v\"\n"+
+		"/a/c: obtained element c, expected element d\n"+
+		"/a: missing element e",
+		`<a id="1" old="y"><b>t</b><b>u</b><c/></a>`,
+		`<a id="2" new="x"><b>t</b><b>v</b><d/><e/></a>`)
+	testCheck(c, check.XMLEquals, false, "Documents differ:\n/a: unexpected text \"x\"", "<a>x</a>", "<a/>")
+	testCheck(c, check.XMLEquals, false, "Documents differ:\n/Body: obtained element {urn:a}Body, expected element {urn:b}Body",
+		`<Body xmlns="urn:a"/>`, `<Body xmlns="urn:b"/>`)
+
+	// error states
+
+	testCheck(c, check.XMLEquals, false, "obtained value is not valid XML: XML syntax error on line 1: unexpected EOF", "<a>", "<a/>")
+	testCheck(c, check.XMLEquals, false, "expected value is not valid XML: no root element", "<a/>", "")
+	testCheck(c, check.XMLEquals, false, "expected value is not valid XML: more than one root element", "<a/>", "<a/><a/>")
+	testCheck(c, check.XMLEquals, false, "obtained value is not valid XML: xml: unsupported type: chan int", make(chan int), "<a/>")
+}
+
+func (s *CheckersS) TestContains(c *check.C) {
+	testInfo(c, check.Contains, "Contains", []string{"container", "element"})
+
+	// Strings.
+	testCheck(c, check.Contains, true, "", "hello world", "o w")
+	testCheck(c, check.Contains, true, "", "hello", "")
+	testCheck(c, check.Contains, false, "", "hello", "bye")
+
+	// Slices and arrays.
+	testCheck(c, check.Contains, true, "", []string{"a", "b"}, "b")
+	testCheck(c, check.Contains, true, "", [2][]int{{1}, {2, 3}}, []int{2, 3})
+	testCheck(c, check.Contains, true, "", []interface{}{1, nil}, nil)
+	testCheck(c, check.Contains, false, "", []int{1, 2}, 3)
+	testCheck(c, check.Contains, false, "", []int64{1, 2}, 1)
+
+	// Maps.
+	testCheck(c, check.Contains, true, "", map[string]int{"a": 1}, "a")
+	testCheck(c, check.Contains, false, "", map[string]int{"a": 1}, "b")
+	testCheck(c, check.Contains, true, "", map[interface{}]bool{nil: true}, nil)
+
+	// error states
+
+	testCheck(c, check.Contains, false, "container must be a string, slice, array or map", 42, 4)
+	testCheck(c, check.Contains, false, "element must be a string to look for in a string", "42", 4)
+	testCheck(c, check.Contains, false, "int can't be a key of map[string]int", map[string]int{"a": 1}, 1)
+	testCheck(c, check.Contains, false, "nil can't be a key of map[string]int", map[string]int{"a": 1}, nil)
+}
+
+func (s *CheckersS) TestElementsMatch(c *check.C) {
+	testInfo(c, check.ElementsMatch, "ElementsMatch", []string{"obtained", "expected"})
+
+	testCheck(c, check.ElementsMatch, true, "", []string{"a", "b", "c"}, []string{"c", "a", "b"})
+	testCheck(c, check.ElementsMatch, true, "", []int{}, [0]int{})
+	testCheck(c, check.ElementsMatch, true, "", [][]int{{1}, {2}}, []interface{}{[]int{2}, []int{1}})
+	testCheck(c, check.ElementsMatch, false, "Missing elements: \"c\"", []string{"a", "b"}, []string{"b", "c", "a"})
+	testCheck(c, check.ElementsMatch, false, "Unexpected elements: 1", []int{1, 1, 2}, []int{2, 1})
+	testCheck(c, check.ElementsMatch, false, "Missing elements: 3, 4\nUnexpected elements: 1",
+		[]int{1, 2}, []int{2, 3, 4})
+
+	// error states
+
+	testCheck(c, check.ElementsMatch, false, "obtained value must be a slice or array", "ab", []string{"a", "b"})
+	testCheck(c, check.ElementsMatch, false, "expected value must be a slice or array", []string{"a", "b"}, nil)
+}
+
+func (s *CheckersS) TestUniqueElements(c *check.C) {
+	testInfo(c, check.UniqueElements, "UniqueElements", []string{"obtained"})
+
+	testCheck(c, check.UniqueElements, true, "", []int{1, 2, 3})
+	testCheck(c, check.UniqueElements, true, "", []string(nil))
+	testCheck(c, check.UniqueElements, true, "", [2][]int{{1}, {2}})
+	testCheck(c, check.UniqueElements, false, "Duplicated element 1 at indices 0, 2", []int{1, 2, 1})
+	testCheck(c, check.UniqueElements, false,
+		"Duplicated element \"a\" at indices 0, 2, 3\nDuplicated element \"b\" at indices 1, 4",
+		[]string{"a", "b", "a", "a", "b", "c"})
+	testCheck(c, check.UniqueElements, false, "Duplicated element []int{1} at indices 0, 1", [][]int{{1}, {1}})
+
+	// error states
+
+	testCheck(c, check.UniqueElements, false, "obtained value must be a slice or array", map[int]int{1: 1})
+}
+
+func (s *CheckersS) TestIsSubsetOf(c *check.C) {
+	testInfo(c, check.IsSubsetOf, "IsSubsetOf", []string{"obtained", "superset"})
+
+	// Slices and arrays.
+	testCheck(c, check.IsSubsetOf, true, "", []string{"b", "a"}, []string{"a", "b", "c"})
+	testCheck(c, check.IsSubsetOf, true, "", []int{}, [1]int{1})
+	testCheck(c, check.IsSubsetOf, false, "Not in the superset: \"d\", \"e\"", []string{"a", "d", "e"}, []string{"a", "b"})
+
+	// Maps.
+	testCheck(c, check.IsSubsetOf, true, "", map[string]int{"a": 1}, map[string]int{"a": 1, "b": 2})
+	testCheck(c, check.IsSubsetOf, false, "Not in the superset: \"a\": 1 (superset has 2), \"c\": 3",
+		map[string]int{"a": 1, "c": 3}, map[string]int{"a": 2, "b": 2})
+
+	// error states
+
+	testCheck(c, check.IsSubsetOf, false, "obtained value must be a slice, array or map", "a", "abc")
+	testCheck(c, check.IsSubsetOf, false, "superset must be a slice or array", []string{"a"}, "abc")
+	testCheck(c, check.IsSubsetOf, false, "superset must be a map", map[string]int{}, []string{})
+	testCheck(c, check.IsSubsetOf, false, "keys of map[int]int can't be keys of map[string]int",
+		map[int]int{}, map[string]int{})
+}
+
+func (s *CheckersS) TestContainsAll(c *check.C) {
+	testInfo(c, check.ContainsAll, "ContainsAll", []string{"obtained", "elements"})
+
+	// Slices and arrays.
+	testCheck(c, check.ContainsAll, true, "", []string{"a", "b", "c"}, []string{"c", "a"})
+	testCheck(c, check.ContainsAll, false, "Missing: 3", [2]int{1, 2}, []int{2, 3})
+
+	// Maps.
+	testCheck(c, check.ContainsAll, true, "", map[string]int{"a": 1, "b": 2}, map[string]int{"b": 2})
+	testCheck(c, check.ContainsAll, false, "Missing: \"b\": 3 (obtained value has 2)",
+		map[string]int{"a": 1, "b": 2}, map[string]int{"b": 3})
+
+	// error states
+
+	testCheck(c, check.ContainsAll, false, "elements must be a slice, array or map", []string{"a"}, "a")
+	testCheck(c, check.ContainsAll, false, "obtained value must be a slice or array", "abc", []string{"a"})
+}
+
+func (s *CheckersS) TestHasKey(c *check.C) {
+	testInfo(c, check.HasKey, "HasKey", []string{"obtained", "key"})
+
+	headers := map[string]string{"Accept": "*/*", "Authorization": "secret"}
+	testCheck(c, check.HasKey, true, "", headers, "Authorization")
+	testCheck(c, check.HasKey, false, "Key \"Host\" not found, the map has: \"Accept\", \"Authorization\"", headers, "Host")
+	testCheck(c, check.HasKey, false, "Key 1 not found, the map has: no keys", map[int]bool{}, 1)
+
+	// error states
+
+	testCheck(c, check.HasKey, false, "obtained value is not a map", []string{"a"}, 0)
+	testCheck(c, check.HasKey, false, "int can't be a key of map[string]string", headers, 1)
+}
+
+func (s *CheckersS) TestHasValue(c *check.C) {
+	testInfo(c, check.HasValue, "HasValue", []string{"obtained", "value"})
+
+	testCheck(c, check.HasValue, true, "", map[string]string{"a": "alice"}, "alice")
+	testCheck(c, check.HasValue, true, "", map[int][]int{1: {2}}, []int{2})
+	testCheck(c, check.HasValue, false, "", map[string]string{"a": "alice"}, "bob")
+
+	// error states
+
+	testCheck(c, check.HasValue, false, "obtained value is not a map", []string{"alice"}, "alice")
+}
+
+func (s *CheckersS) TestHasEntry(c *check.C) {
+	testInfo(c, check.HasEntry, "HasEntry", []string{"obtained", "key", "value"})
+
+	headers := map[string]string{"Content-Type": "text/plain"}
+	testCheck(c, check.HasEntry, true, "", headers, "Content-Type", "text/plain")
+	testCheck(c, check.HasEntry, false, "Key \"Content-Type\" has value \"text/plain\"",
+		headers, "Content-Type", "application/json")
+	testCheck(c, check.HasEntry, false, "Key \"Accept\" not found, the map has: \"Content-Type\"",
+		headers, "Accept", "*/*")
+
+	// error states
+
+	testCheck(c, check.HasEntry, false, "obtained value is not a map", "Content-Type", "Content-Type", "text/plain")
+	testCheck(c, check.HasEntry, false, "int can't be a key of map[string]string", headers, 1, "text/plain")
+}
+
+func (s *CheckersS) TestOrderCheckers(c *check.C) {
+	testInfo(c, check.GreaterThan, "GreaterThan", []string{"obtained", "bound"})
+	testInfo(c, check.GreaterOrEqual, "GreaterOrEqual", []string{"obtained", "bound"})
+	testInfo(c, check.LessThan, "LessThan", []string{"obtained", "bound"})
+	testInfo(c, check.LessOrEqual, "LessOrEqual", []string{"obtained", "bound"})
+
+	testCheck(c, check.GreaterThan, true, "", 2, 1)
+	testCheck(c, check.GreaterThan, false, "", 1, 1)
+	testCheck(c, check.GreaterOrEqual, true, "", 1, 1)
+	testCheck(c, check.GreaterOrEqual, false, "", 0, 1)
+	testCheck(c, check.LessThan, true, "", 0, 1)
+	testCheck(c, check.LessThan, false, "", 1, 1)
+	testCheck(c, check.LessOrEqual, true, "", 1, 1)
+	testCheck(c, check.LessOrEqual, false, "", 2, 1)
+
+	// Numbers of different kinds.
+	testCheck(c, check.GreaterThan, true, "", uint8(2), int64(1))
+	testCheck(c, check.GreaterThan, true, "", uint64(math.MaxUint64), -1)
+	testCheck(c, check.LessThan, true, "", -1, uint(0))
+	testCheck(c, check.LessThan, true, "", int64(math.MaxInt64-1), int64(math.MaxInt64))
+	testCheck(c, check.LessThan, true, "", 1, 1.5)
+	testCheck(c, check.GreaterOrEqual, true, "", float32(2), 2)
+	// Integers and floats are compared without rounding the integer.
+	testCheck(c, check.GreaterThan, true, "", int64(1<<53+1), float64(1<<53))
+	testCheck(c, check.LessThan, true, "", float64(1<<53), int64(1<<53+1))
+	testCheck(c, check.LessThan, true, "", uint64(math.MaxUint64), float64(1<<64))
+	testCheck(c, check.GreaterThan, true, "", -0.5, -1)
+	testCheck(c, check.LessThan, true, "", math.Inf(-1), int64(math.MinInt64))
+
+	// Durations and times.
+	testCheck(c, check.LessThan, true, "", 500*time.Millisecond, time.Second)
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCheck(c, check.GreaterThan, true, "", t0.Add(time.Nanosecond), t0)
+	testCheck(c, check.LessOrEqual, true, "", t0, t0.In(time.FixedZone("UTC+5", 5*3600)))
+
+	// error states
+
+	testCheck(c, check.GreaterThan, false, "can't compare time.Duration with int", time.Second, 1)
+	testCheck(c, check.GreaterThan, false, "can't compare time.Time with int", t0, 1)
+	testCheck(c, check.GreaterThan, false, "can't compare string with string", "b", "a")
+	testCheck(c, check.GreaterThan, false, "can't compare <nil> with int", nil, 1)
+	testCheck(c, check.GreaterThan, false, "can't compare NaN", math.NaN(), 1)
+}
+
+func (s *CheckersS) TestBetween(c *check.C) {
+	testInfo(c, check.Between, "Between", []string{"obtained", "low", "high"})
+	testInfo(c, check.StrictlyBetween, "StrictlyBetween", []string{"obtained", "low", "high"})
+
+	testCheck(c, check.Between, true, "", 2, 1, 3)
+	testCheck(c, check.Between, true, "", 1, 1, 3)
+	testCheck(c, check.Between, true, "", 3.0, 1, uint(3))
+	testCheck(c, check.Between, false, "4 is not in [1, 3]", 4, 1, 3)
+	testCheck(c, check.StrictlyBetween, true, "", 0.5, 0.0, 1.0)
+	testCheck(c, check.StrictlyBetween, false, "1 is not in (0, 1)", 1.0, 0.0, 1.0)
+	testCheck(c, check.Between, false, "5s is not in [10ms, 50ms]", 5*time.Second, 10*time.Millisecond, 50*time.Millisecond)
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCheck(c, check.Between, true, "", t0, t0.Add(-time.Hour), t0)
+	testCheck(c, check.StrictlyBetween, false, "2020-01-01 00:00:00 +0000 UTC is not in "+
+		"(2019-12-31 23:00:00 +0000 UTC, 2020-01-01 00:00:00 +0000 UTC)", t0, t0.Add(-time.Hour), t0)
+
+	// error states
+
+	testCheck(c, check.Between, false, "low must not be greater than high", 2, 3, 1)
+	testCheck(c, check.Between, false, "can't compare time.Duration with int", time.Second, 1, 3)
+	testCheck(c, check.Between, false, "can't compare int with time.Duration", 1, 0, time.Second)
+}
+
+func (s *CheckersS) TestIsSorted(c *check.C) {
+	testInfo(c, check.IsSorted, "IsSorted", []string{"obtained"})
+	testInfo(c, check.IsSortedDescending, "IsSortedDescending", []string{"obtained"})
+
+	testCheck(c, check.IsSorted, true, "", []int{1, 2, 2, 3})
+	testCheck(c, check.IsSorted, true, "", []string{"alice", "bob"})
+	testCheck(c, check.IsSorted, true, "", []interface{}{1, 1.5, uint(2)})
+	testCheck(c, check.IsSorted, true, "", [0]int{})
+	testCheck(c, check.IsSorted, false, "Elements 1 and 2 are out of order: 3, 2", []int{1, 3, 2, 4})
+	testCheck(c, check.IsSorted, false, "Elements 0 and 1 are out of order: \"bob\", \"alice\"", []string{"bob", "alice"})
+	testCheck(c, check.IsSortedDescending, true, "", [3]time.Duration{time.Second, time.Second, time.Millisecond})
+	testCheck(c, check.IsSortedDescending, false, "Elements 0 and 1 are out of order: 1, 2", []float64{1, 2})
+
+	// error states
+
+	testCheck(c, check.IsSorted, false, "obtained value must be a slice or array", "abc")
+	testCheck(c, check.IsSorted, false, "can't compare string with int", []interface{}{"a", 1})
+	testCheck(c, check.IsSorted, false, "can't compare struct {} with struct {}", []struct{}{{}, {}})
+}
+
+func (s *CheckersS) TestIsSortedBy(c *check.C) {
+	testInfo(c, check.IsSortedBy, "IsSortedBy", []string{"obtained", "less"})
+
+	byX := func(a, b approxPoint) bool { return a.X < b.X }
+	testCheck(c, check.IsSortedBy, true, "", []approxPoint{{X: 1}, {X: 1, Y: 1}, {X: 2}}, byX)
+	testCheck(c, check.IsSortedBy, true, "", []approxPoint(nil), byX)
+	testCheck(c, check.IsSortedBy, false,
+		"Elements 0 and 1 are out of order: check_test.approxPoint{X:2, Y:0}, check_test.approxPoint{X:1, Y:0}",
+		[]approxPoint{{X: 2}, {X: 1}}, byX)
+
+	// error states
+
+	testCheck(c, check.IsSortedBy, false, "obtained value must be a slice or array", approxPoint{}, byX)
+	testCheck(c, check.IsSortedBy, false, "less must be a func(a, b T) bool", []int{1}, func(a int) bool { return true })
+	testCheck(c, check.IsSortedBy, false, "less must be a func(a, b T) bool", []int{1}, nil)
+	testCheck(c, check.IsSortedBy, false, "int can't be passed to func(check_test.approxPoint, check_test.approxPoint) bool", []int{1}, byX)
+}
+
+func (s *CheckersS) TestHasPrefix(c *check.C) {
+	testInfo(c, check.HasPrefix, "HasPrefix", []string{"obtained", "prefix"})
+
+	testCheck(c, check.HasPrefix, true, "", "https://example.com", "https://")
+	testCheck(c, check.HasPrefix, true, "", []byte("GET / HTTP/1.1"), "GET ")
+	testCheck(c, check.HasPrefix, true, "", time.Duration(1500)*time.Millisecond, "1.5")
+	testCheck(c, check.HasPrefix, false, "Obtained value starts with \"http://e\"", "http://example.com", "https://")
+	testCheck(c, check.HasPrefix, false, "Obtained value starts with \"http\"", "http", "https://")
+
+	// error states
+
+	testCheck(c, check.HasPrefix, false, "obtained value must be a string, []byte or fmt.Stringer", 1, "1")
+	testCheck(c, check.HasPrefix, false, "prefix must be a string", "abc", 'a')
+}
+
+func (s *CheckersS) TestHasSuffix(c *check.C) {
+	testInfo(c, check.HasSuffix, "HasSuffix", []string{"obtained", "suffix"})
+
+	testCheck(c, check.HasSuffix, true, "", "main.go", ".go")
+	testCheck(c, check.HasSuffix, true, "", []byte("line\n"), "\n")
+	testCheck(c, check.HasSuffix, false, "Obtained value ends with \".py\"", "main.py", ".go")
+	testCheck(c, check.HasSuffix, false, "Obtained value ends with \"go\"", "go", "main.go")
+
+	// error states
+
+	testCheck(c, check.HasSuffix, false, "obtained value must be a string, []byte or fmt.Stringer", nil, "")
+	testCheck(c, check.HasSuffix, false, "suffix must be a string", "abc", []byte("c"))
+}
+
+func (s *CheckersS) TestSatisfies(c *check.C) {
+	testInfo(c, check.Satisfies, "Satisfies", []string{"obtained", "predicate"})
+
+	positive := func(i int) bool { return i > 0 }
+	testCheck(c, check.Satisfies, true, "", 1, positive)
+	testCheck(c, check.Satisfies, false, "", -1, positive)
+
+	even := func(i int) (bool, string) { return i%2 == 0, fmt.Sprintf("%d is odd", i) }
+	testCheck(c, check.Satisfies, true, "", 2, even)
+	testCheck(c, check.Satisfies, false, "3 is odd", 3, even)
+
+	isNil := func(err error) bool { return err == nil }
+	testCheck(c, check.Satisfies, true, "", nil, isNil)
+	testCheck(c, check.Satisfies, false, "", errors.New("BOOM"), isNil)
+
+	// error states
+
+	testCheck(c, check.Satisfies, false, "predicate must be a func(T) bool or a func(T) (bool, string)", 1, true)
+	testCheck(c, check.Satisfies, false, "predicate must be a func(T) bool or a func(T) (bool, string)",
+		1, func(i int) int { return i })
+	testCheck(c, check.Satisfies, false, "predicate must be a func(T) bool or a func(T) (bool, string)",
+		1, func(i int) (bool, error) { return true, nil })
+	testCheck(c, check.Satisfies, false, "string can't be passed to func(int) bool", "1", positive)
+	testCheck(c, check.Satisfies, false, "nil can't be passed to func(int) bool", nil, positive)
+}
+
+func (s *CheckersS) TestEventually(c *check.C) {
+	eventually := check.Eventually(check.Equals, 200*time.Millisecond, time.Millisecond)
+	testInfo(c, eventually, "Eventually(Equals)", []string{"function", "expected"})
+
+	calls := 0
+	counter := func() int { calls++; return calls }
+	testCheck(c, eventually, true, "", counter, 3)
+	c.Assert(calls, check.Equals, 3)
+
+	// The last value obtained is reported. The function is called again
+	// at the timeout, even though the interval is longer.
+	params, names := testCheck(c, check.Eventually(check.Equals, 10*time.Millisecond, time.Hour),
+		false, "Not satisfied after 10ms (2 attempts)", func() int { return 1 }, 2)
+	c.Assert(params[0], check.Equals, 1)
+	c.Assert(names[0], check.Equals, "last obtained")
+
+	testCheck(c, check.Eventually(check.ErrorMatches, 0, time.Millisecond),
+		false, "Not satisfied after 0s (1 attempts): Error value is nil", func() error { return nil }, "BOOM")
+
+	// error states
+
+	testCheck(c, eventually, false, "Function must take zero arguments and return one value", 1, 1)
+	testCheck(c, eventually, false, "Function must take zero arguments and return one value", func() {}, 1)
+}
+
+func (s *CheckersS) TestConsistently(c *check.C) {
+	consistently := check.Consistently(check.Equals, 10*time.Millisecond, time.Millisecond)
+	testInfo(c, consistently, "Consistently(Equals)", []string{"function", "expected"})
+
+	calls := 0
+	testCheck(c, consistently, true, "", func() int { calls++; return 1 }, 1)
+	c.Assert(calls > 1, check.Equals, true)
+
+	// The first value failing the check is reported.
+	calls = 0
+	params, names := testCheck(c, consistently, false, "Not satisfied anymore after 2 attempts",
+		func() int { calls++; return calls }, 1)
+	c.Assert(params[0], check.Equals, 2)
+	c.Assert(names[0], check.Equals, "last obtained")
+
+	testCheck(c, check.Consistently(check.IsNil, time.Hour, time.Millisecond), false,
+		"Not satisfied anymore after 1 attempts", func() error { return io.EOF })
+
+	// error states
+
+	testCheck(c, consistently, false, "Function must take zero arguments and return one value", 1, 1)
+}
+
+func (s *CheckersS) TestReceives(c *check.C) {
+	testInfo(c, check.Receives(nil, time.Second), "Receives", []string{"channel"})
+	receivesEqual := check.Receives(check.Equals, time.Second)
+	testInfo(c, receivesEqual, "Receives(Equals)", []string{"channel", "expected"})
+
+	ch := make(chan string, 1)
+	ch <- "ok"
+	testCheck(c, check.Receives(nil, time.Second), true, "", ch)
+	go func() { ch <- "ok" }()
+	testCheck(c, receivesEqual, true, "", (<-chan string)(ch), "ok")
+
+	ch <- "ko"
+	params, names := testCheck(c, receivesEqual, false, "", ch, "ok")
+	c.Assert(params[0], check.Equals, "ko")
+	c.Assert(names[0], check.Equals, "received")
+
+	testCheck(c, check.Receives(nil, time.Millisecond), false, "Nothing received within 1ms", ch)
+	close(ch)
+	testCheck(c, receivesEqual, false, "Channel closed before a value was received", ch, "")
+
+	// error states
+
+	testCheck(c, receivesEqual, false, "obtained value must be a channel which may be received from", "ok", "ok")
+	testCheck(c, receivesEqual, false, "obtained value must be a channel which may be received from", (chan<- string)(ch), "ok")
+}
+
+func (s *CheckersS) TestCloses(c *check.C) {
+	testInfo(c, check.Closes(time.Second), "Closes", []string{"channel"})
+
+	done := make(chan struct{})
+	go close(done)
+	testCheck(c, check.Closes(time.Second), true, "", done)
+
+	ch := make(chan int, 1)
+	testCheck(c, check.Closes(time.Millisecond), false, "Channel not closed within 1ms", ch)
+	ch <- 42
+	testCheck(c, check.Closes(time.Second), false, "Received 42 rather than the channel being closed", ch)
+
+	// error states
+
+	testCheck(c, check.Closes(time.Second), false, "obtained value must be a channel which may be received from", nil)
+}
+
+type ignoringRecord struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	Children  []*ignoringRecord
+}
+
+func (s *CheckersS) TestCompletesWithin(c *check.C) {
+	testInfo(c, check.CompletesWithin, "CompletesWithin", []string{"function", "duration"})
+
+	testCheck(c, check.CompletesWithin, true, "", func() {}, time.Second)
+	testCheck(c, check.CompletesWithin, true, "", func() int { return 1 }, time.Second)
+
+	release := make(chan bool)
+	defer close(release)
+	testCheck(c, check.CompletesWithin, false, "Function has not completed within 1ms", func() { <-release }, time.Millisecond)
+
+	// Panics are recovered and reported.
+	params := []interface{}{func() { panic("boom") }, time.Second}
+	names := []string{"function", "duration"}
+	result, errStr := check.CompletesWithin.Check(params, names)
+	c.Assert(result, check.Equals, false)
+	c.Assert(errStr, check.Matches, "Function panicked after .*s")
+	c.Assert(params[0], check.Equals, "boom")
+	c.Assert(names[0], check.Equals, "panic")
+
+	// Stopping the goroutine of the function isn't mistaken for a panic.
+	params = []interface{}{runtime.Goexit, time.Second}
+	names = []string{"function", "duration"}
+	result, errStr = check.CompletesWithin.Check(params, names)
+	c.Assert(result, check.Equals, false)
+	c.Assert(errStr, check.Matches, "Function was stopped by runtime.Goexit after .*s, as by a failed c.Assert")
+	c.Assert(names[0], check.Equals, "function")
+
+	// error states
+
+	testCheck(c, check.CompletesWithin, false, "Function must take zero arguments", func(int) {}, time.Second)
+	testCheck(c, check.CompletesWithin, false, "Function must take zero arguments", nil, time.Second)
+	testCheck(c, check.CompletesWithin, false, "duration must be a time.Duration", func() {}, 1)
+	testCheck(c, check.CompletesWithin, false, "duration must not be negative", func() {}, -time.Second)
+}
+
+func (s *CheckersS) TestIsCanceled(c *check.C) {
+	testInfo(c, check.IsCanceled, "IsCanceled", []string{"context"})
+	testInfo(c, check.NotCanceled, "NotCanceled", []string{"context"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	testCheck(c, check.IsCanceled, false, "Context is not canceled", ctx)
+	testCheck(c, check.NotCanceled, true, "", ctx)
+	cancel()
+	testCheck(c, check.IsCanceled, true, "", ctx)
+	testCheck(c, check.NotCanceled, false, "Context is canceled: context canceled", ctx)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	testCheck(c, check.IsCanceled, true, "", expired)
+	testCheck(c, check.NotCanceled, false, "Context is canceled: context deadline exceeded", expired)
+
+	// error states
+
+	testCheck(c, check.IsCanceled, false, "obtained value must be a context.Context", nil)
+	testCheck(c, check.NotCanceled, false, "obtained value must be a context.Context", "ctx")
+}
+
+func (s *CheckersS) TestHasCause(c *check.C) {
+	testInfo(c, check.HasCause(check.ErrorIs), "HasCause(ErrorIs)", []string{"context", "target"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	testCheck(c, check.HasCause(check.ErrorIs), false, "Context is not canceled", ctx, context.Canceled)
+	cancel()
+	testCheck(c, check.HasCause(check.ErrorIs), true, "", ctx, context.Canceled)
+	testCheck(c, check.HasCause(check.ErrorMatches), true, "", ctx, "context canceled")
+
+	// The cause is shown when the checker fails.
+	params, names := testCheck(c, check.HasCause(check.ErrorMatches), false, "", ctx, "timeout")
+	c.Assert(params, check.DeepEquals, []interface{}{"context canceled", "timeout"})
+	c.Assert(names, check.DeepEquals, []string{"cause", "regex"})
+
+	// error states
+
+	testCheck(c, check.HasCause(check.ErrorIs), false, "obtained value must be a context.Context", nil, context.Canceled)
+}
+
+func (s *CheckersS) TestHasDeadlineBetween(c *check.C) {
+	testInfo(c, check.HasDeadlineBetween, "HasDeadlineBetween", []string{"context", "low", "high"})
+
+	deadline := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	testCheck(c, check.HasDeadlineBetween, true, "", ctx, deadline.Add(-time.Second), deadline)
+	params, names := testCheck(c, check.HasDeadlineBetween, false,
+		"2030-01-02 03:04:05 +0000 UTC is not in [2030-01-02 03:04:06 +0000 UTC, 2030-01-02 03:04:07 +0000 UTC]",
+		ctx, deadline.Add(time.Second), deadline.Add(2*time.Second))
+	c.Assert(params[0], check.Equals, deadline)
+	c.Assert(names[0], check.Equals, "deadline")
+	testCheck(c, check.HasDeadlineBetween, false, "Context has no deadline", context.Background(), deadline, deadline)
+
+	// error states
+
+	testCheck(c, check.HasDeadlineBetween, false, "obtained value must be a context.Context", nil, deadline, deadline)
+	testCheck(c, check.HasDeadlineBetween, false, "low must be a time.Time", ctx, 1, deadline)
+	testCheck(c, check.HasDeadlineBetween, false, "high must be a time.Time", ctx, deadline, nil)
+}
+
+func (s *CheckersS) TestDeepEqualsIgnoring(c *check.C) {
+	ignoring := check.DeepEqualsIgnoring("ID", "CreatedAt")
+	testInfo(c, ignoring, "DeepEqualsIgnoring(ID, CreatedAt)", []string{"obtained", "expected"})
+
+	now := time.Now()
+	obtained := ignoringRecord{ID: 1, Name: "parent", CreatedAt: now,
+		Children: []*ignoringRecord{{ID: 2, Name: "child", CreatedAt: now}}}
+	expected := ignoringRecord{Name: "parent", Children: []*ignoringRecord{{Name: "child"}}}
+	testCheck(c, ignoring, true, "", obtained, expected)
+	testCheck(c, ignoring, true, "", &obtained, &expected)
+
+	expected.Children[0].Name = "other"
+	testCheck(c, ignoring, false, "mismatch at .Children[0].Name: obtained \"child\", expected \"other\"",
+		obtained, expected)
+	testCheck(c, check.DeepEqualsIgnoring("CreatedAt"), false, "mismatch at .ID: obtained 1, expected 0",
+		obtained, expected)
+	testCheck(c, check.DeepEqualsIgnoring(), false, "mismatch at top level: obtained 1, expected 2", 1, 2)
+}
+
+type withOptionsRecord struct {
+	Tags    []string
+	Labels  map[string]string
+	At      time.Time
+	private int
+}
+
+func (s *CheckersS) TestDeepEqualsWith(c *check.C) {
+	testInfo(c, check.DeepEqualsWith(check.DeepOptions{}), "DeepEqualsWith", []string{"obtained", "expected"})
+
+	at := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	obtained := withOptionsRecord{At: at, private: 1}
+	expected := withOptionsRecord{Tags: []string{}, Labels: map[string]string{}, At: at.In(time.FixedZone("X", 3600))}
+
+	all := check.DeepEqualsWith(check.DeepOptions{EquateEmpty: true, IgnoreUnexported: true, TimeEqual: true})
+	testCheck(c, all, true, "", obtained, expected)
+	testCheck(c, all, true, "", []withOptionsRecord{obtained}, []withOptionsRecord{expected})
+	testCheck(c, check.DeepEqualsWith(check.DeepOptions{}), true, "", obtained, obtained)
+
+	testCheck(c, check.DeepEqualsWith(check.DeepOptions{IgnoreUnexported: true, TimeEqual: true}), false,
+		"mismatch at .Tags: obtained []string(nil), expected []string{}", obtained, expected)
+	testCheck(c, check.DeepEqualsWith(check.DeepOptions{EquateEmpty: true, TimeEqual: true}), false,
+		"mismatch at .private: obtained 1, expected 0", obtained, expected)
+	// Without TimeEqual, the unexported fields of time.Time are compared.
+	result, message := check.DeepEqualsWith(check.DeepOptions{EquateEmpty: true, IgnoreUnexported: true}).Check(
+		[]interface{}{obtained, expected}, []string{"obtained", "expected"})
+	c.Assert(result, check.Equals, false)
+	c.Assert(message, check.Matches, `mismatch at \.At\.loc: obtained \(\*time\.Location\)\(nil\), expected &time\.Location\{.*`)
+	testCheck(c, all, false, "mismatch at .Tags: obtained length 0, expected length 1",
+		obtained, withOptionsRecord{Tags: []string{"a"}})
+	testCheck(c, all, false, "mismatch at .At: obtained time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC), "+
+		"expected time.Date(2020, time.January, 1, 13, 0, 0, 0, time.UTC)", obtained, withOptionsRecord{At: at.Add(time.Hour)})
+}
+
+func testRecorder(status int, contentType, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", contentType)
+	recorder.WriteHeader(status)
+	recorder.WriteString(body)
+	return recorder
+}
+
+func (s *CheckersS) TestHasStatus(c *check.C) {
+	testInfo(c, check.HasStatus, "HasStatus", []string{"obtained", "status"})
+
+	recorder := testRecorder(http.StatusNotFound, "text/plain", "no such page")
+	testCheck(c, check.HasStatus, true, "", recorder, http.StatusNotFound)
+	testCheck(c, check.HasStatus, true, "", recorder.Result(), http.StatusNotFound)
+	params, names := testCheck(c, check.HasStatus, false, "", recorder, http.StatusOK)
+	c.Assert(params[0], check.Equals, "404 Not Found\nContent-Type: text/plain\n\nno such page")
+	c.Assert(names[0], check.Equals, "response")
+
+	// Long bodies are truncated.
+	params, _ = testCheck(c, check.HasStatus, false, "", testRecorder(http.StatusOK, "text/plain", strings.Repeat("x", 600)), 201)
+	c.Assert(params[0], check.Equals, "200 OK\nContent-Type: text/plain\n\n"+strings.Repeat("x", 512)+"... (88 more bytes)")
+
+	// error states
+
+	testCheck(c, check.HasStatus, false, "obtained value must be an *http.Response or an *httptest.ResponseRecorder", 200, 200)
+	testCheck(c, check.HasStatus, false, "obtained value must be an *http.Response or an *httptest.ResponseRecorder",
+		(*http.Response)(nil), 200)
+	testCheck(c, check.HasStatus, false, "status must be an int", recorder, "404")
+}
+
+func (s *CheckersS) TestHasHeader(c *check.C) {
+	testInfo(c, check.HasHeader, "HasHeader", []string{"obtained", "header", "value"})
+
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "application/json")
+	recorder.Header().Add("Vary", "Accept")
+	recorder.Header().Add("Vary", "Cookie")
+	recorder.WriteString("{}")
+	testCheck(c, check.HasHeader, true, "", recorder, "content-type", "application/json")
+	testCheck(c, check.HasHeader, true, "", recorder, "Vary", "Cookie")
+	params, _ := testCheck(c, check.HasHeader, false, "", recorder, "Vary", "Origin")
+	c.Assert(params[0], check.Equals, "200 OK\nVary: Accept\nVary: Cookie\n\n{}")
+
+	// error states
+
+	testCheck(c, check.HasHeader, false, "header must be a string", recorder, 1, "a")
+	testCheck(c, check.HasHeader, false, "value must be a string", recorder, "Vary", 1)
+}
+
+func (s *CheckersS) TestBodyMatches(c *check.C) {
+	testInfo(c, check.BodyMatches, "BodyMatches", []string{"obtained", "regex"})
+
+	resp := testRecorder(http.StatusOK, "text/plain", "hello world").Result()
+	testCheck(c, check.BodyMatches, true, "", resp, "hello.*")
+	// The body may be read again.
+	testCheck(c, check.BodyMatches, true, "", resp, "hello world")
+	params, _ := testCheck(c, check.BodyMatches, false, "", resp, "hello")
+	c.Assert(params[0], check.Equals, "200 OK\nContent-Type: text/plain\n\nhello world")
+	testCheck(c, check.BodyMatches, true, "", &http.Response{StatusCode: 204}, "")
+
+	// error states
+
+	testCheck(c, check.BodyMatches, false, "Can't compile regex: error parsing regexp: missing closing ): `^($`", resp, "(")
+}
+
+func (s *CheckersS) TestBodyJSONEquals(c *check.C) {
+	testInfo(c, check.BodyJSONEquals, "BodyJSONEquals", []string{"obtained", "expected"})
+
+	recorder := testRecorder(http.StatusOK, "application/json", `{"id": 42, "name": "alice"}`)
+	testCheck(c, check.BodyJSONEquals, true, "", recorder, `{"name":"alice","id":42}`)
+	testCheck(c, check.BodyJSONEquals, true, "", recorder, map[string]interface{}{"id": 42, "name": "alice"})
+	params, _ := testCheck(c, check.BodyJSONEquals, false, "Documents differ:\n$.id: obtained 42, expected 43",
+		recorder, `{"id": 43, "name": "alice"}`)
+	c.Assert(params[0], check.Equals, "200 OK\nContent-Type: application/json\n\n{\"id\": 42, \"name\": \"alice\"}")
+	testCheck(c, check.BodyJSONEquals, false, "Documents differ:\n$.id: obtained 9007199254740993, expected 9007199254740992",
+		testRecorder(http.StatusOK, "application/json", `{"id": 9007199254740993}`), `{"id": 9007199254740992}`)
+	testCheck(c, check.BodyJSONEquals, false, "response body is not valid JSON: invalid character '<' looking for beginning of value",
+		testRecorder(http.StatusBadGateway, "text/html", "<html>"), `{}`)
+
+	// error states
+
+	testCheck(c, check.BodyJSONEquals, false, "expected value is not valid JSON: unexpected end of JSON input", recorder, "{")
+}
+
+// testFile writes a file with the given content and mode into a temporary
+// directory of c, and returns its path.
+func testFile(c *check.C, content string, mode os.FileMode) string {
+	path := filepath.Join(c.MkDir(), "file.txt")
+	c.Assert(ioutil.WriteFile(path, []byte(content), mode), check.IsNil)
+	c.Assert(os.Chmod(path, mode), check.IsNil)
+	return path
+}
+
+func (s *CheckersS) TestFileExists(c *check.C) {
+	testInfo(c, check.FileExists, "FileExists", []string{"path"})
+
+	dir := c.MkDir()
+	testCheck(c, check.FileExists, true, "", dir)
+	testCheck(c, check.FileExists, true, "", testFile(c, "", 0644))
+	testCheck(c, check.FileExists, false, "", filepath.Join(dir, "missing"))
+
+	// error states
+
+	testCheck(c, check.FileExists, false, "path must be a string", 42)
+}
+
+func (s *CheckersS) TestHasFileMode(c *check.C) {
+	testInfo(c, check.HasFileMode, "HasFileMode", []string{"path", "mode"})
+
+	path := testFile(c, "", 0600)
+	testCheck(c, check.HasFileMode, true, "", path, os.FileMode(0600))
+	testCheck(c, check.HasFileMode, false, "Mode is -rw-------", path, os.FileMode(0644))
+	testCheck(c, check.HasFileMode, false, "Mode is -rw-------", path, os.ModeDir|0600)
+	dir := c.MkDir()
+	c.Assert(os.Chmod(dir, 0750), check.IsNil)
+	testCheck(c, check.HasFileMode, true, "", dir, os.ModeDir|0750)
+	testCheck(c, check.HasFileMode, true, "", dir, os.FileMode(0750))
+
+	// error states
+
+	testCheck(c, check.HasFileMode, false, "path must be a string", nil, os.FileMode(0600))
+	testCheck(c, check.HasFileMode, false, "mode must be an os.FileMode", path, 0600)
+	missing := filepath.Join(dir, "missing")
+	testCheck(c, check.HasFileMode, false, "stat "+missing+": no such file or directory", missing, os.FileMode(0600))
+}
+
+func (s *CheckersS) TestFileEquals(c *check.C) {
+	testInfo(c, check.FileEquals, "FileEquals", []string{"path", "expected"})
+
+	path := testFile(c, "a\nb\nc\n", 0644)
+	testCheck(c, check.FileEquals, true, "", path, "a\nb\nc\n")
+	testCheck(c, check.FileEquals, true, "", path, []byte("a\nb\nc\n"))
+	testCheck(c, check.FileEquals, false,
+		"Difference (-content +expected):\n...     @@ -1,3 +1,3 @@\n...      \"a\\n\"\n...     -\"b\\n\"\n...     +\"B\\n\"\n...      \"c\\n\"",
+		path, "a\nB\nc\n")
+	testCheck(c, check.FileEquals, false, "Content is \"ok\"", testFile(c, "ok", 0644), "ko")
+
+	// error states
+
+	testCheck(c, check.FileEquals, false, "expected value must be a string or []byte", path, 42)
+	missing := filepath.Join(c.MkDir(), "missing")
+	testCheck(c, check.FileEquals, false, "open "+missing+": no such file or directory", missing, "")
+}
+
+func (s *CheckersS) TestFileContains(c *check.C) {
+	testInfo(c, check.FileContains, "FileContains", []string{"path", "substring"})
+
+	path := testFile(c, "INFO server started\n", 0644)
+	testCheck(c, check.FileContains, true, "", path, "server started")
+	testCheck(c, check.FileContains, false, "Content is \"INFO server started\\n\"", path, "ERROR")
+	long := testFile(c, strings.Repeat("x", 600), 0644)
+	testCheck(c, check.FileContains, false, "Content is \""+strings.Repeat("x", 512)+"\"... (88 more bytes)", long, "y")
+
+	// error states
+
+	testCheck(c, check.FileContains, false, "substring must be a string", path, 'x')
+	testCheck(c, check.FileContains, false, "path must be a string", []byte(path), "x")
+}
+
+func (s *CheckersS) TestFileMatches(c *check.C) {
+	testInfo(c, check.FileMatches, "FileMatches", []string{"path", "regex"})
+
+	path := testFile(c, "1234\n", 0644)
+	testCheck(c, check.FileMatches, true, "", path, `[0-9]+\n`)
+	testCheck(c, check.FileMatches, false, "Content is \"1234\\n\"", path, `[0-9]+`)
+
+	// error states
+
+	testCheck(c, check.FileMatches, false, "Regex must be a string", path, 42)
+	testCheck(c, check.FileMatches, false, "Can't compile regex: error parsing regexp: missing closing ): `^($`", path, "(")
+}
+
+// testTree creates a directory tree with the given files, by their path,
+// in a temporary directory of c, and returns its path. Paths ending with a
+// slash are directories.
+func testTree(c *check.C, files map[string]string) string {
+	root := c.MkDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if strings.HasSuffix(name, "/") {
+			c.Assert(os.MkdirAll(path, 0755), check.IsNil)
+			continue
+		}
+		c.Assert(os.MkdirAll(filepath.Dir(path), 0755), check.IsNil)
+		c.Assert(ioutil.WriteFile(path, []byte(content), 0644), check.IsNil)
+	}
+	return root
+}
+
+func (s *CheckersS) TestDirEquals(c *check.C) {
+	testInfo(c, check.DirEquals, "DirEquals", []string{"obtained", "expected"})
+	testInfo(c, check.DirEqualsWithModes, "DirEqualsWithModes", []string{"obtained", "expected"})
+
+	golden := map[string]string{"a.txt": "a", "sub/b.txt": "b", "empty/": ""}
+	expected := testTree(c, golden)
+	obtained := testTree(c, golden)
+	testCheck(c, check.DirEquals, true, "", obtained, expected)
+	testCheck(c, check.DirEqualsWithModes, true, "", obtained, expected)
+	testCheck(c, check.DirEquals, true, "", c.MkDir(), c.MkDir())
+
+	c.Assert(os.Chmod(filepath.Join(obtained, "a.txt"), 0600), check.IsNil)
+	testCheck(c, check.DirEquals, true, "", obtained, expected)
+	testCheck(c, check.DirEqualsWithModes, false, "Differing: a.txt (mode -rw-------, expected -rw-r--r--)", obtained, expected)
+
+	obtained = testTree(c, map[string]string{"a.txt": "A", "sub/b.txt": "b", "sub/c.txt": "c", "empty": "not a dir"})
+	testCheck(c, check.DirEquals, false,
+		"Extra: sub/c.txt\nDiffering: a.txt (content), empty (file, expected directory)", obtained, expected)
+	obtained = testTree(c, map[string]string{"a.txt": "a", "sub/b.txt": "bb"})
+	testCheck(c, check.DirEquals, false, "Missing: empty\nDiffering: sub/b.txt (content)", obtained, expected)
+
+	linked, target := testTree(c, nil), testTree(c, nil)
+	c.Assert(os.Symlink("a.txt", filepath.Join(linked, "link")), check.IsNil)
+	c.Assert(os.Symlink("b.txt", filepath.Join(target, "link")), check.IsNil)
+	testCheck(c, check.DirEquals, false, "Differing: link (link to \"a.txt\", expected \"b.txt\")", linked, target)
+
+	// error states
+
+	testCheck(c, check.DirEquals, false, "obtained path must be a string", 42, expected)
+	testCheck(c, check.DirEquals, false, "expected path is not a directory", expected, filepath.Join(expected, "a.txt"))
+	missing := filepath.Join(expected, "missing")
+	testCheck(c, check.DirEquals, false, "stat "+missing+": no such file or directory", missing, expected)
+}
+
+func (s *CheckersS) TestIsUUID(c *check.C) {
+	testInfo(c, check.IsUUID, "IsUUID", []string{"value"})
+
+	testCheck(c, check.IsUUID, true, "", "123e4567-e89b-12d3-a456-426614174000")
+	testCheck(c, check.IsUUID, true, "", "123E4567-E89B-12D3-A456-426614174000")
+	testCheck(c, check.IsUUID, false, "Value is not a UUID", "123e4567e89b12d3a456426614174000")
+	testCheck(c, check.IsUUID, false, "Value is not a UUID", "{123e4567-e89b-12d3-a456-426614174000}")
+	testCheck(c, check.IsUUID, false, "Value is not a UUID", "123e4567-e89b-12d3-a456-42661417400g")
+
+	// error states
+
+	testCheck(c, check.IsUUID, false, "Value must be a string", []byte("123e4567-e89b-12d3-a456-426614174000"))
+}
+
+func (s *CheckersS) TestIsEmail(c *check.C) {
+	testInfo(c, check.IsEmail, "IsEmail", []string{"value"})
+
+	testCheck(c, check.IsEmail, true, "", "alice@example.com")
+	testCheck(c, check.IsEmail, true, "", "alice.smith+tag@mail.example.co.uk")
+	testCheck(c, check.IsEmail, false, "Value is not an email address: mail: missing '@' or angle-addr", "alice")
+	testCheck(c, check.IsEmail, false, "Value is not a bare email address", "Alice <alice@example.com>")
+	testCheck(c, check.IsEmail, false, "Value is not a bare email address", " alice@example.com")
+
+	// error states
+
+	testCheck(c, check.IsEmail, false, "Value must be a string", nil)
+}
+
+func (s *CheckersS) TestIsURL(c *check.C) {
+	testInfo(c, check.IsURL, "IsURL", []string{"value"})
+
+	testCheck(c, check.IsURL, true, "", "https://example.com/path?q=1")
+	testCheck(c, check.IsURL, true, "", "postgres://user@localhost:5432/db")
+	testCheck(c, check.IsURL, false, "Value is not an absolute URL", "/path")
+	testCheck(c, check.IsURL, false, "Value is not an absolute URL", "mailto:alice@example.com")
+	testCheck(c, check.IsURL, false, "Value is not a URL: parse \"http://[::1\": missing ']' in host", "http://[::1")
+
+	// error states
+
+	testCheck(c, check.IsURL, false, "Value must be a string", 1)
+}
+
+func (s *CheckersS) TestIsBase64(c *check.C) {
+	testInfo(c, check.IsBase64, "IsBase64", []string{"value"})
+
+	testCheck(c, check.IsBase64, true, "", "aGVsbG8=")
+	testCheck(c, check.IsBase64, true, "", "")
+	testCheck(c, check.IsBase64, false, "Value is not base64: illegal base64 data at input byte 4", "aGVsbG8")
+	testCheck(c, check.IsBase64, false, "Value is not base64: illegal base64 data at input byte 1", "a-_b")
+
+	// error states
+
+	testCheck(c, check.IsBase64, false, "Value must be a string", []byte("aGVsbG8="))
+}
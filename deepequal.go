@@ -0,0 +1,246 @@
+package check
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// comparers holds the functions registered with RegisterComparer.
+var comparers typeRegistry
+
+// RegisterComparer registers a function deciding whether two values of a
+// type are equal, for types whose values may be equal without being
+// deep-equal, such as decimals or sets. The function must be a
+// func(a, b T) bool, and it replaces any registered earlier for T. It's
+// then used for values of type T by DeepEquals, DeepEqualsWith,
+// DeepEqualsIgnoring and ApproxDeepEquals, at the top level as well as in
+// fields, elements and map values, but not for unexported fields.
+//
+// RegisterComparer panics if comparer isn't such a function. It returns a
+// function unregistering the comparer, which restores the one it replaced,
+// if any. Comparers are best registered once, in an init function, while
+// tests registering one should unregister it once done, as with C.Cleanup.
+//
+// For example:
+//
+//     func init() {
+//         check.RegisterComparer(func(a, b decimal.Decimal) bool { return a.Equal(b) })
+//     }
+//
+func RegisterComparer(comparer interface{}) (unregister func()) {
+	f := reflect.ValueOf(comparer)
+	if f.Kind() != reflect.Func || f.IsNil() || f.Type().NumIn() != 2 || f.Type().IsVariadic() ||
+		f.Type().In(0) != f.Type().In(1) || f.Type().NumOut() != 1 || f.Type().Out(0) != boolType {
+		panic(fmt.Sprintf("comparer must be a func(a, b T) bool, not %T", comparer))
+	}
+	return comparers.register(f.Type().In(0), f)
+}
+
+// comparer returns the comparer registered for type t, if any.
+func comparer(t reflect.Type) (f reflect.Value, ok bool) {
+	return comparers.lookup(t)
+}
+
+// hasComparers tells whether any comparer is registered.
+func hasComparers() bool {
+	return !comparers.empty()
+}
+
+// deepCompare walks two values with reflection in the same way as
+// reflect.DeepEqual does, but reports where the first difference was
+// found and allows relaxing how some values are compared.
+type deepCompare struct {
+	// floatTolerance is the maximum absolute difference allowed between
+	// floating point (and complex) values for them to be considered equal.
+	floatTolerance float64
+
+	// ignoredFields holds the names of struct fields which are skipped,
+	// in structs at any depth.
+	ignoredFields map[string]bool
+
+	options DeepOptions
+
+	visited map[deepVisit]bool
+}
+
+// deepVisit records a comparison in progress, so that cyclic data
+// structures don't recurse forever.
+type deepVisit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// diff returns an empty string if obtained and expected are deeply equal,
+// or a description of the first difference found otherwise.
+func (d *deepCompare) diff(obtained, expected interface{}) string {
+	d.visited = make(map[deepVisit]bool)
+	return d.compare("", reflect.ValueOf(obtained), reflect.ValueOf(expected))
+}
+
+func (d *deepCompare) mismatch(path string, a, b reflect.Value) string {
+	return fmt.Sprintf("mismatch at %s: obtained %s, expected %s", describePath(path), goSyntax(a), goSyntax(b))
+}
+
+func describePath(path string) string {
+	if path == "" {
+		return "top level"
+	}
+	return path
+}
+
+func (d *deepCompare) compare(path string, a, b reflect.Value) string {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			return fmt.Sprintf("mismatch at %s: obtained %s, expected %s",
+				describePath(path), goSyntax(validInterface(a)), goSyntax(validInterface(b)))
+		}
+		return ""
+	}
+	if a.Type() != b.Type() {
+		return fmt.Sprintf("mismatch at %s: obtained type %s, expected type %s",
+			describePath(path), a.Type(), b.Type())
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if a.Pointer() != 0 && b.Pointer() != 0 {
+			v := deepVisit{a.Pointer(), b.Pointer(), a.Type()}
+			if d.visited[v] {
+				return ""
+			}
+			d.visited[v] = true
+		}
+	}
+
+	if f, ok := comparer(a.Type()); ok && a.CanInterface() && b.CanInterface() {
+		if !f.Call([]reflect.Value{a, b})[0].Bool() {
+			return d.mismatch(path, a, b)
+		}
+		return ""
+	}
+
+	if d.options.TimeEqual && a.Type() == timeType && a.CanInterface() && b.CanInterface() {
+		if !a.Interface().(time.Time).Equal(b.Interface().(time.Time)) {
+			return d.mismatch(path, a, b)
+		}
+		return ""
+	}
+
+	switch a.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if !d.floatsEqual(a.Float(), b.Float()) {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		ac, bc := a.Complex(), b.Complex()
+		if !d.floatsEqual(real(ac), real(bc)) || !d.floatsEqual(imag(ac), imag(bc)) {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Bool:
+		if a.Bool() != b.Bool() {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if a.Int() != b.Int() {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if a.Uint() != b.Uint() {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.String:
+		if a.String() != b.String() {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Func:
+		// Like reflect.DeepEqual, functions are only equal if both are nil.
+		if !a.IsNil() || !b.IsNil() {
+			return d.mismatch(path, a, b)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return d.mismatch(path, a, b)
+			}
+			return ""
+		}
+		return d.compare(path, a.Elem(), b.Elem())
+	case reflect.Struct:
+		ignoreUnexported := d.options.IgnoreUnexported && hasExportedFields(a.Type())
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			if d.ignoredFields[name] || ignoreUnexported && a.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if diff := d.compare(path+"."+name, a.Field(i), b.Field(i)); diff != "" {
+				return diff
+			}
+		}
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() && !d.options.EquateEmpty {
+			return d.mismatch(path, a, b)
+		}
+		fallthrough
+	case reflect.Array:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("mismatch at %s: obtained length %d, expected length %d",
+				describePath(path), a.Len(), b.Len())
+		}
+		for i := 0; i < a.Len(); i++ {
+			if diff := d.compare(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i)); diff != "" {
+				return diff
+			}
+		}
+	case reflect.Map:
+		if a.IsNil() != b.IsNil() && !d.options.EquateEmpty {
+			return d.mismatch(path, a, b)
+		}
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("mismatch at %s: obtained length %d, expected length %d",
+				describePath(path), a.Len(), b.Len())
+		}
+		for _, k := range a.MapKeys() {
+			keyPath := fmt.Sprintf("%s[%s]", path, goSyntax(k))
+			bv := b.MapIndex(k)
+			if !bv.IsValid() {
+				return fmt.Sprintf("mismatch at %s: key missing from expected value", keyPath)
+			}
+			if diff := d.compare(keyPath, a.MapIndex(k), bv); diff != "" {
+				return diff
+			}
+		}
+	}
+	return ""
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// hasExportedFields tells whether the struct type t has exported fields.
+// Unexported fields aren't ignored in structs without any, such as
+// time.Time, as all of their values would be equal otherwise.
+func hasExportedFields(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *deepCompare) floatsEqual(a, b float64) bool {
+	return a == b || math.Abs(a-b) <= d.floatTolerance
+}
+
+// validInterface returns the value held by v, or nil if v is the zero Value.
+func validInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v
+}
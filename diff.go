@@ -0,0 +1,292 @@
+package check
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// formatters holds the functions registered with RegisterFormatter.
+var formatters typeRegistry
+
+// RegisterFormatter makes failed checks write out values of type T with
+// the given func(v T) string, instead of in the Go syntax of %#v, which
+// is hard to read for some types, such as protocol buffer messages or IDs
+// better shown in hex. The formatter is used for the obtained and expected
+// values which are logged, within the diffs of DeepEquals, and for the
+// values quoted by checkers explaining a failure, such as the elements
+// reported missing by SameContents. In diffs, values nested in others are
+// formatted too, except in unexported fields.
+//
+// RegisterFormatter panics if formatter isn't such a function. A formatter
+// replaces any registered earlier for T, and as with RegisterComparer, the
+// returned function unregisters it again.
+//
+// For example:
+//
+//     func init() {
+//         check.RegisterFormatter(func(id UserID) string { return fmt.Sprintf("UserID(%#x)", uint64(id)) })
+//     }
+//
+func RegisterFormatter(formatter interface{}) (unregister func()) {
+	f := reflect.ValueOf(formatter)
+	if f.Kind() != reflect.Func || f.IsNil() || f.Type().NumIn() != 1 || f.Type().IsVariadic() ||
+		f.Type().NumOut() != 1 || f.Type().Out(0).Kind() != reflect.String {
+		panic(fmt.Sprintf("formatter must be a func(v T) string, not %T", formatter))
+	}
+	return formatters.register(f.Type().In(0), f)
+}
+
+// formatValue writes out v with the formatter registered for its type, if
+// there's one.
+func formatValue(v reflect.Value) (s string, ok bool) {
+	if !v.IsValid() || !v.CanInterface() {
+		return "", false
+	}
+	f, ok := formatters.lookup(v.Type())
+	if !ok {
+		return "", false
+	}
+	return f.Call([]reflect.Value{v})[0].String(), true
+}
+
+// goSyntax writes out value as %#v does, unless a formatter is registered
+// for its type. A reflect.Value stands for the value it holds.
+func goSyntax(value interface{}) string {
+	v, ok := value.(reflect.Value)
+	if !ok {
+		v = reflect.ValueOf(value)
+	}
+	if s, ok := formatValue(v); ok {
+		return s
+	}
+	return fmt.Sprintf("%#v", value)
+}
+
+// diffMinWidth is how long the %#v form of a value must be for a failed
+// DeepEquals check to show a diff, rather than just the values, which are
+// easy enough to compare side by side when they're short.
+const diffMinWidth = 80
+
+// diffMaxCells bounds the work done comparing lines, as the number of line
+// pairs considered. Values too large to compare get no diff.
+const diffMaxCells = 4000000
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// elidedValue stands for a value which a checker left out of the log of a
+// failed check because it's shown some other way, such as in a diff.
+type elidedValue struct {
+	typ reflect.Type
+}
+
+// valueDiff returns a unified diff of the lines of obtained and expected,
+// one field or element per line, or an empty string if they're too small
+// to be worth it or can't be compared line by line.
+func valueDiff(obtained, expected interface{}) string {
+	if obtained == nil || expected == nil || reflect.TypeOf(obtained) != reflect.TypeOf(expected) {
+		return ""
+	}
+	var a, b []string
+	if s, ok := obtained.(string); ok {
+		if !isMultiLine(s) && !isMultiLine(expected.(string)) {
+			return ""
+		}
+		a, b = splitLines(s), splitLines(expected.(string))
+		for i := range a {
+			a[i] = fmt.Sprintf("%q", a[i])
+		}
+		for i := range b {
+			b[i] = fmt.Sprintf("%q", b[i])
+		}
+	} else {
+		if len(fmt.Sprintf("%#v", obtained)) < diffMinWidth && len(fmt.Sprintf("%#v", expected)) < diffMinWidth {
+			return ""
+		}
+		a = formatLines(reflect.ValueOf(obtained))
+		b = formatLines(reflect.ValueOf(expected))
+	}
+	if len(a)*len(b) > diffMaxCells {
+		return ""
+	}
+	return unifiedDiff(a, b)
+}
+
+// splitLines splits s after each line break.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// formatLines returns v written out in Go syntax, with the fields of
+// structs and the elements of slices, arrays and maps on lines of their
+// own. Map keys are sorted so that equal maps give equal lines.
+func formatLines(v reflect.Value) []string {
+	f := &lineFormatter{seen: make(map[uintptr]bool)}
+	f.format(v, "", "")
+	return f.lines
+}
+
+type lineFormatter struct {
+	lines []string
+	seen  map[uintptr]bool
+}
+
+// format adds the lines of v, the first one starting with prefix and all
+// of them indented by indent.
+func (f *lineFormatter) format(v reflect.Value, indent, prefix string) {
+	if !v.IsValid() {
+		f.lines = append(f.lines, indent+prefix+"nil")
+		return
+	}
+	if s, ok := formatValue(v); ok {
+		f.lines = append(f.lines, indent+prefix+s)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			f.lines = append(f.lines, indent+prefix+"nil")
+			return
+		}
+		f.format(v.Elem(), indent, prefix)
+		return
+	case reflect.Ptr:
+		if v.IsNil() || f.seen[v.Pointer()] {
+			break
+		}
+		f.seen[v.Pointer()] = true
+		f.format(v.Elem(), indent, prefix+"&")
+		delete(f.seen, v.Pointer())
+		return
+	case reflect.Struct:
+		if v.NumField() == 0 {
+			break
+		}
+		f.lines = append(f.lines, indent+prefix+v.Type().String()+"{")
+		for i := 0; i < v.NumField(); i++ {
+			f.formatItem(v.Field(i), indent, v.Type().Field(i).Name+": ")
+		}
+		f.lines = append(f.lines, indent+"}")
+		return
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 || v.Kind() == reflect.Slice && v.IsNil() {
+			break
+		}
+		f.lines = append(f.lines, indent+prefix+v.Type().String()+"{")
+		for i := 0; i < v.Len(); i++ {
+			f.formatItem(v.Index(i), indent, "")
+		}
+		f.lines = append(f.lines, indent+"}")
+		return
+	case reflect.Map:
+		if v.Len() == 0 {
+			break
+		}
+		keys := v.MapKeys()
+		names := make(map[reflect.Value]string, len(keys))
+		for _, key := range keys {
+			names[key] = fmt.Sprintf("%#v", key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return names[keys[i]] < names[keys[j]] })
+		f.lines = append(f.lines, indent+prefix+v.Type().String()+"{")
+		for _, key := range keys {
+			f.formatItem(v.MapIndex(key), indent, names[key]+": ")
+		}
+		f.lines = append(f.lines, indent+"}")
+		return
+	}
+	f.lines = append(f.lines, indent+prefix+fmt.Sprintf("%#v", v))
+}
+
+// formatItem adds the lines of a field or element, followed by a comma.
+func (f *lineFormatter) formatItem(v reflect.Value, indent, prefix string) {
+	f.format(v, indent+"    ", prefix)
+	f.lines[len(f.lines)-1] += ","
+}
+
+// unifiedDiff returns the differences between the lines a and b in the
+// unified format, with "-" for lines only in a and "+" for those only in b.
+func unifiedDiff(a, b []string) string {
+	// common[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	type diffLine struct {
+		op   byte
+		text string
+		i, j int
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i, j})
+			i++
+			j++
+		case j == len(b) || i < len(a) && common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	var out []string
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// A hunk spans changes less than twice the context apart.
+		end := start
+		for k := start; k < len(lines) && k-end <= 2*diffContext; k++ {
+			if lines[k].op != ' ' {
+				end = k
+			}
+		}
+		from, to := start-diffContext, end+diffContext+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(lines) {
+			to = len(lines)
+		}
+		var countA, countB int
+		for _, line := range lines[from:to] {
+			if line.op != '+' {
+				countA++
+			}
+			if line.op != '-' {
+				countB++
+			}
+		}
+		out = append(out, fmt.Sprintf("@@ -%d,%d +%d,%d @@", lines[from].i+1, countA, lines[from].j+1, countB))
+		for _, line := range lines[from:to] {
+			out = append(out, string(line.op)+line.text)
+		}
+		start = to
+	}
+	return strings.Join(out, "\n")
+}
@@ -352,7 +352,7 @@ func (s *NoTestsHelper) TearDownSuite(c *C) {
 func (s *FixtureS) TestFixtureDoesntRunWithoutTests(c *C) {
 	helper := NoTestsHelper{}
 	output := String{}
-	Run(&helper, &RunConf{Output: &output})
+	Run(&helper, &RunConf{Output: &output, NoWarnEmptySuites: true})
 	c.Check(helper.hasRun, Equals, false)
 }
 
@@ -479,3 +479,84 @@ func (s *FixtureS) TestSkipTest(c *C) {
 	c.Assert(len(helper.calls), Equals, 6)
 	c.Assert(result.Skipped, Equals, 1)
 }
+
+// -----------------------------------------------------------------------
+// Cleanup() within tests and fixture methods.
+
+type CleanupHelper struct {
+	calls []string
+}
+
+func (s *CleanupHelper) SetUpSuite(c *C) {
+	s.calls = append(s.calls, "SetUpSuite")
+	c.Cleanup(func() { s.calls = append(s.calls, "SetUpSuite cleanup") })
+}
+
+func (s *CleanupHelper) TearDownSuite(c *C) {
+	s.calls = append(s.calls, "TearDownSuite")
+	c.Cleanup(func() { s.calls = append(s.calls, "TearDownSuite cleanup") })
+}
+
+func (s *CleanupHelper) SetUpTest(c *C) {
+	s.calls = append(s.calls, "SetUpTest")
+	c.Cleanup(func() { s.calls = append(s.calls, "SetUpTest cleanup") })
+}
+
+func (s *CleanupHelper) TearDownTest(c *C) {
+	s.calls = append(s.calls, "TearDownTest")
+}
+
+func (s *CleanupHelper) Test1(c *C) {
+	s.calls = append(s.calls, "Test1")
+	c.Cleanup(func() { s.calls = append(s.calls, "cleanup 1") })
+	c.Cleanup(func() { s.calls = append(s.calls, "cleanup 2") })
+	c.Fatal("stop")
+}
+
+func (s *CleanupHelper) Test2(c *C) {
+	s.calls = append(s.calls, "Test2")
+	c.Cleanup(func() { s.calls = append(s.calls, "cleanup 3") })
+	c.Cleanup(func() { panic("cleanup panic") })
+	c.Cleanup(func() { c.Check(1, Equals, 2) })
+	c.Cleanup(func() { c.FailNow() })
+}
+
+func (s *FixtureS) TestCleanup(c *C) {
+	helper := CleanupHelper{}
+	output := String{}
+	result := Run(&helper, &RunConf{Output: &output})
+	c.Check(helper.calls, DeepEquals, []string{
+		"SetUpSuite",
+		"SetUpTest", "Test1", "TearDownTest", "cleanup 2", "cleanup 1", "SetUpTest cleanup",
+		"SetUpTest", "Test2", "TearDownTest", "cleanup 3", "SetUpTest cleanup",
+		"TearDownSuite", "TearDownSuite cleanup", "SetUpSuite cleanup",
+	})
+	c.Check(result.Failed, Equals, 1)
+	c.Check(result.Panicked, Equals, 1)
+	c.Check(output.value, Matches, "(?s).*FAIL: fixture_test\\.go:[0-9]+: CleanupHelper\\.Test1\n.*stop\n.*"+
+		"PANIC: fixture_test\\.go:[0-9]+: CleanupHelper\\.Test2\n.*"+
+		"\\.\\.\\. obtained int = 1\n\\.\\.\\. expected int = 2\n.*"+
+		"\\.\\.\\. Panic: cleanup panic .*")
+}
+
+type SuiteCleanupHelper struct {
+	calls []string
+}
+
+func (s *SuiteCleanupHelper) SetUpSuite(c *C) {
+	s.calls = append(s.calls, "SetUpSuite")
+	c.Cleanup(func() { s.calls = append(s.calls, "SetUpSuite cleanup") })
+}
+
+func (s *SuiteCleanupHelper) Test1(c *C) {
+	s.calls = append(s.calls, "Test1")
+}
+
+func (s *FixtureS) TestSuiteCleanupWithoutTearDownSuite(c *C) {
+	helper := SuiteCleanupHelper{}
+	output := String{}
+	result := Run(&helper, &RunConf{Output: &output, Verbose: true})
+	c.Check(helper.calls, DeepEquals, []string{"SetUpSuite", "Test1", "SetUpSuite cleanup"})
+	c.Check(result.Passed(), Equals, true)
+	c.Check(output.value, Matches, "PASS: fixture_test\\.go:[0-9]+: SuiteCleanupHelper\\.Test1\t *[0-9.]+s\n")
+}
@@ -0,0 +1,65 @@
+//go:build go1.18
+// +build go1.18
+
+package check
+
+// The functions in this file are type-safe counterparts of C.Check and
+// C.Assert for the most common checkers: the obtained and expected values
+// must be of the same type, so that mixing up types, such as comparing an
+// int64 with an untyped constant converted to int, fails to compile rather
+// than failing at run time. Failures are reported just like those of
+// C.Check and C.Assert, and extra arguments are logged the same way.
+
+// CheckEqual verifies that obtained == expected, as the Equals checker
+// does, returning whether it does. The test is marked as failed otherwise.
+//
+// For example:
+//
+//     check.CheckEqual(c, resp.StatusCode, http.StatusOK)
+//
+func CheckEqual[T comparable](c *C, obtained, expected T, args ...interface{}) bool {
+	return c.internalCheck("CheckEqual", obtained, Equals, append([]interface{}{expected}, args...)...)
+}
+
+// AssertEqual verifies that obtained == expected, as the Equals checker
+// does. The test is marked as failed and stopped otherwise.
+func AssertEqual[T comparable](c *C, obtained, expected T, args ...interface{}) {
+	if !c.internalCheck("AssertEqual", obtained, Equals, append([]interface{}{expected}, args...)...) {
+		c.stopNow()
+	}
+}
+
+// CheckNotEqual verifies that obtained != expected, returning whether it
+// does. The test is marked as failed otherwise.
+func CheckNotEqual[T comparable](c *C, obtained, expected T, args ...interface{}) bool {
+	return c.internalCheck("CheckNotEqual", obtained, Not(Equals), append([]interface{}{expected}, args...)...)
+}
+
+// AssertNotEqual verifies that obtained != expected. The test is marked as
+// failed and stopped otherwise.
+func AssertNotEqual[T comparable](c *C, obtained, expected T, args ...interface{}) {
+	if !c.internalCheck("AssertNotEqual", obtained, Not(Equals), append([]interface{}{expected}, args...)...) {
+		c.stopNow()
+	}
+}
+
+// CheckDeepEqual verifies that obtained is deep-equal to expected, as the
+// DeepEquals checker does, returning whether it is. The test is marked as
+// failed otherwise.
+//
+// For example:
+//
+//     check.CheckDeepEqual(c, names, []string{"alice", "bob"})
+//
+func CheckDeepEqual[T any](c *C, obtained, expected T, args ...interface{}) bool {
+	return c.internalCheck("CheckDeepEqual", obtained, DeepEquals, append([]interface{}{expected}, args...)...)
+}
+
+// AssertDeepEqual verifies that obtained is deep-equal to expected, as the
+// DeepEquals checker does. The test is marked as failed and stopped
+// otherwise.
+func AssertDeepEqual[T any](c *C, obtained, expected T, args ...interface{}) {
+	if !c.internalCheck("AssertDeepEqual", obtained, DeepEquals, append([]interface{}{expected}, args...)...) {
+		c.stopNow()
+	}
+}
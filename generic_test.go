@@ -0,0 +1,69 @@
+//go:build go1.18
+// +build go1.18
+
+package check_test
+
+import (
+	"github.com/masukomi/check"
+)
+
+type GenericS struct{}
+
+var _ = check.Suite(&GenericS{})
+
+func (s *GenericS) TestCheckEqualSucceed(c *check.C) {
+	testHelperSuccess(c, "CheckEqual(1, 1)", true, func() interface{} {
+		return check.CheckEqual(c, int64(1), 1)
+	})
+}
+
+func (s *GenericS) TestCheckEqualFail(c *check.C) {
+	log := "(?s)generic_test\\.go:[0-9]+:.*\ngeneric_test\\.go:[0-9]+:\n" +
+		"    return check\\.CheckEqual\\(c, \"a\", \"b\", myComment\\(\"Hello world!\"\\)\\)\n" +
+		"\\.+ obtained string = \"a\"\n" +
+		"\\.+ expected string = \"b\"\n" +
+		"\\.+ Hello world!\n\n"
+	testHelperFailure(c, "CheckEqual(\"a\", \"b\")", false, false, log, func() interface{} {
+		return check.CheckEqual(c, "a", "b", myComment("Hello world!"))
+	})
+}
+
+func (s *GenericS) TestAssertEqualFail(c *check.C) {
+	log := "(?s)generic_test\\.go:[0-9]+:.*\ngeneric_test\\.go:[0-9]+:\n" +
+		"    check\\.AssertEqual\\(c, 1, 2\\)\n" +
+		"\\.+ obtained int = 1\n" +
+		"\\.+ expected int = 2\n\n"
+	testHelperFailure(c, "AssertEqual(1, 2)", nil, true, log, func() interface{} {
+		check.AssertEqual(c, 1, 2)
+		return nil
+	})
+}
+
+func (s *GenericS) TestNotEqual(c *check.C) {
+	testHelperSuccess(c, "CheckNotEqual(1, 2)", true, func() interface{} {
+		return check.CheckNotEqual(c, 1, 2)
+	})
+	log := "(?s)generic_test\\.go:[0-9]+:.*\ngeneric_test\\.go:[0-9]+:\n" +
+		"    check\\.AssertNotEqual\\(c, 1, 1\\)\n" +
+		"\\.+ obtained int = 1\n" +
+		"\\.+ expected int = 1\n" +
+		"\\.+ Obtained value is equal to 1\n\n"
+	testHelperFailure(c, "AssertNotEqual(1, 1)", nil, true, log, func() interface{} {
+		check.AssertNotEqual(c, 1, 1)
+		return nil
+	})
+}
+
+func (s *GenericS) TestDeepEqual(c *check.C) {
+	testHelperSuccess(c, "AssertDeepEqual([a], [a])", nil, func() interface{} {
+		check.AssertDeepEqual(c, []string{"a"}, []string{"a"})
+		return nil
+	})
+	log := "(?s)generic_test\\.go:[0-9]+:.*\ngeneric_test\\.go:[0-9]+:\n" +
+		"    return check\\.CheckDeepEqual\\(c, \\[\\]int\\{1\\}, nil\\)\n" +
+		"\\.+ obtained \\[\\]int = \\[\\]int\\{1\\}\n" +
+		"\\.+ expected \\[\\]int = \\[\\]int\\(nil\\)\n\n"
+	testHelperFailure(c, "CheckDeepEqual([1], nil)", false, false, log, func() interface{} {
+		return check.CheckDeepEqual(c, []int{1}, nil)
+	})
+}
@@ -1,8 +1,13 @@
 package check
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -11,6 +16,16 @@ func (c *C) TestName() string {
 	return c.testName
 }
 
+// Helper marks the calling function as a test helper. Problems reported
+// while it runs are attributed to the line calling into the helper rather
+// than to the helper itself.
+func (c *C) Helper() {
+	pc, _, _, ok := runtime.Caller(1)
+	if ok {
+		c.helpers.Store(runtime.FuncForPC(pc).Name(), true)
+	}
+}
+
 // -----------------------------------------------------------------------
 // Basic succeeding/failing logic.
 
@@ -64,6 +79,32 @@ func (c *C) ExpectFailure(reason string) {
 	c.reason = reason
 }
 
+// Quarantine informs that the running test is known to be broken for the
+// provided reason, but should still run. A failure or panic of the test is
+// then reported as quarantined instead, and does not fail the run, while a
+// quarantined test which passes is reported as such, so that the marker can
+// be removed. It must be called from the test method itself.
+func (c *C) Quarantine(reason string) {
+	if reason == "" {
+		panic("Missing reason why the test is quarantined")
+	}
+	c.quarantined = true
+	c.reason = reason
+}
+
+// SetMeta attaches the key/value pair to the result of the running test,
+// to be picked up by reporters which support it, such as xunit. Setting an
+// existing key again replaces its value. It must be called from the test
+// method itself.
+func (c *C) SetMeta(key, value string) {
+	c.meta.Lock()
+	if c.meta.values == nil {
+		c.meta.values = make(map[string]string)
+	}
+	c.meta.values[key] = value
+	c.meta.Unlock()
+}
+
 // Skip skips the running test for the provided reason. If run from within
 // SetUpTest, the individual test being set up will be skipped, and if run
 // from within SetUpSuite, the whole suite is skipped.
@@ -154,6 +195,198 @@ func (c *C) FatalError(err error) {
 	}
 }
 
+// Must returns value if err is nil. Otherwise the error is logged along
+// with the calling line, the test is marked as failed, and the test
+// execution stops. It is meant to wrap calls returning a value and an
+// error, as in:
+//
+//     cfg := c.Must(LoadConfig()).(Config)
+//
+func (c *C) Must(value interface{}, err error) interface{} {
+	if err != nil {
+		c.logCaller(1)
+		c.logString(fmt.Sprint("Error: ", err))
+		c.logNewLine()
+		c.FailNow()
+	}
+	return value
+}
+
+// EventuallyPanics calls fn repeatedly, waiting interval between calls,
+// until one of the calls panics, and returns the value it panicked with.
+// Each call is recovered independently. If no call panics before timeout
+// elapses, the failure is logged along with the number of calls made, the
+// test is marked as failed, and the test execution stops.
+func (c *C) EventuallyPanics(timeout, interval time.Duration, fn func()) interface{} {
+	deadline := time.Now().Add(timeout)
+	for calls := 1; ; calls++ {
+		if panicked, value := callRecovering(fn); panicked {
+			c.logf("... EventuallyPanics: call #%d panicked with %s", calls, goSyntax(value))
+			return value
+		}
+		if !time.Now().Add(interval).Before(deadline) {
+			c.logCaller(1)
+			c.logString(fmt.Sprintf("Error: function did not panic within %s (%d calls)", timeout, calls))
+			c.logNewLine()
+			c.FailNow()
+		}
+		time.Sleep(interval)
+	}
+}
+
+// callRecovering calls fn, reporting whether it panicked and with what.
+func callRecovering(fn func()) (panicked bool, value interface{}) {
+	defer func() {
+		if panicked {
+			value = recover()
+		}
+	}()
+	panicked = true
+	fn()
+	return false, nil
+}
+
+// -----------------------------------------------------------------------
+// Goroutine leak helpers.
+
+// LeakSettleTime is how long NoLeak and NoLeakAllowing wait for the
+// goroutines started by the checked function to finish before reporting
+// them as leaked. Lowering it makes failing leak checks report sooner, at
+// the risk of flagging goroutines which were just slow to exit.
+var LeakSettleTime = 2 * time.Second
+
+// NoLeak calls f and verifies that no goroutine started while it ran is
+// still running once it returns, after giving them a short time to settle.
+// The stacks of the leaked goroutines are logged. As with Check, the test
+// is marked as failed and false is returned if the verification fails.
+//
+// Goroutines started at the same time by concurrently running tests are
+// indistinguishable from those started by f, so prefer NoLeak in suites
+// which aren't concurrent.
+func (c *C) NoLeak(f func()) bool {
+	return c.noLeak(0, f)
+}
+
+// NoLeakAllowing works as NoLeak, but tolerates up to allowed goroutines
+// left behind by f, such as known background workers started lazily.
+func (c *C) NoLeakAllowing(allowed int, f func()) bool {
+	return c.noLeak(allowed, f)
+}
+
+func (c *C) noLeak(allowed int, f func()) bool {
+	before := goroutineStacks()
+	f()
+	deadline := time.Now().Add(LeakSettleTime)
+	leaked := newGoroutines(before, goroutineStacks())
+	for len(leaked) > allowed && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		leaked = newGoroutines(before, goroutineStacks())
+	}
+	if len(leaked) <= allowed {
+		return true
+	}
+	c.logCaller(2)
+	c.logString(fmt.Sprintf("Error: %d goroutines leaked (%d allowed):", len(leaked), allowed))
+	c.logNewLine()
+	for _, stack := range leaked {
+		c.log(stack)
+		c.logNewLine()
+	}
+	c.Fail()
+	return false
+}
+
+// goroutineStacks returns the stacks of all the goroutines, by their id.
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := make(map[string]string)
+	for _, stack := range strings.Split(strings.TrimSpace(string(buf)), "\n\n") {
+		// The stack starts with a "goroutine 42 [running]:" line.
+		fields := strings.Fields(stack)
+		if len(fields) > 1 {
+			stacks[fields[1]] = stack
+		}
+	}
+	return stacks
+}
+
+// newGoroutines returns the stacks in after for goroutines not in before.
+func newGoroutines(before, after map[string]string) []string {
+	var stacks []string
+	for id, stack := range after {
+		if _, ok := before[id]; !ok {
+			stacks = append(stacks, stack)
+		}
+	}
+	sort.Strings(stacks)
+	return stacks
+}
+
+// -----------------------------------------------------------------------
+// Network helpers.
+
+var allocatedPorts = struct {
+	sync.Mutex
+	ports map[int]bool
+}{ports: make(map[int]bool)}
+
+// FreePort returns a TCP port on the loopback interface which was free
+// when the function was called. The same port is never returned twice
+// within a test run, so concurrent tests don't get conflicting ports.
+//
+// Note that the port is released before FreePort returns, so in theory
+// something else might grab it before the test gets to use it. Prefer
+// FreeListener, which hands back the listener itself, whenever the code
+// under test can be given a net.Listener.
+func (c *C) FreePort() int {
+	for i := 0; i < 100; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			c.failListen(err)
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		l.Close()
+
+		allocatedPorts.Lock()
+		taken := allocatedPorts.ports[port]
+		allocatedPorts.ports[port] = true
+		allocatedPorts.Unlock()
+		if !taken {
+			return port
+		}
+	}
+	c.failListen(errors.New("no unused port found"))
+	return 0
+}
+
+// FreeListener returns a TCP listener bound to a free port on the loopback
+// interface. Unlike FreePort, the port is never released in between, so
+// there's no race with other processes. The listener is closed once the
+// running test or fixture method is done, unless closed earlier.
+func (c *C) FreeListener() net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		c.failListen(err)
+	}
+	c.addCleanup(func() { l.Close() })
+	return l
+}
+
+func (c *C) failListen(err error) {
+	c.logCaller(2)
+	c.logString(fmt.Sprint("Error: cannot listen on a free port: ", err))
+	c.logNewLine()
+	c.FailNow()
+}
+
 // -----------------------------------------------------------------------
 // Generic checks and assertions based on checkers.
 
@@ -183,6 +416,62 @@ func (c *C) Assert(obtained interface{}, checker Checker, args ...interface{}) {
 	}
 }
 
+// Expectation verifies an obtained value against checkers in a fluent
+// style. See C.Expect and C.Require.
+type Expectation struct {
+	c        *C
+	obtained interface{}
+	stop     bool
+}
+
+// Expect returns an expectation on the obtained value, which verifies it
+// against checkers as Check does, so that a failure is logged and the test
+// execution continues. Expectations may be chained to verify the same
+// value with several checkers.
+//
+// For example:
+//
+//     c.Expect(users).To(HasLen, 2).To(Contains, "alice").NotTo(Contains, "root")
+//
+func (c *C) Expect(obtained interface{}) *Expectation {
+	return &Expectation{c: c, obtained: obtained}
+}
+
+// Require returns an expectation on the obtained value, which verifies it
+// against checkers as Assert does, so that a failure also stops the test
+// execution.
+//
+// For example:
+//
+//     c.Require(err).To(IsNil)
+//
+func (c *C) Require(obtained interface{}) *Expectation {
+	return &Expectation{c: c, obtained: obtained, stop: true}
+}
+
+// To verifies that the obtained value matches the expected value according
+// to the provided checker, as Check or Assert does, and returns the
+// expectation for more checkers to be chained.
+func (e *Expectation) To(checker Checker, args ...interface{}) *Expectation {
+	if !e.c.internalCheck("To", e.obtained, checker, args...) && e.stop {
+		e.c.stopNow()
+	}
+	return e
+}
+
+// NotTo verifies that the obtained value doesn't match the expected value
+// according to the provided checker, as Not(checker) does, and returns the
+// expectation for more checkers to be chained.
+func (e *Expectation) NotTo(checker Checker, args ...interface{}) *Expectation {
+	if checker != nil {
+		checker = Not(checker)
+	}
+	if !e.c.internalCheck("NotTo", e.obtained, checker, args...) && e.stop {
+		e.c.stopNow()
+	}
+	return e
+}
+
 func (c *C) internalCheck(funcName string, obtained interface{}, checker Checker, args ...interface{}) bool {
 	if checker == nil {
 		c.logCaller(2)
@@ -4,11 +4,16 @@
 package check_test
 
 import (
+	"errors"
+	"fmt"
 	"github.com/masukomi/check"
+	"net"
 	"os"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
+	"time"
 )
 
 var helpersS = check.Suite(&HelpersS{})
@@ -93,6 +98,37 @@ func (s *HelpersS) TestCheckSucceedWithoutExpected(c *check.C) {
 	}
 }
 
+func (s *HelpersS) TestCheckFailWithDiff(c *check.C) {
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    return c\\.Check\\(\"a\\\\nb\", check\\.DeepEquals, \"a\\\\nc\"\\)\n" +
+		"\\.+ obtained string = \\(see difference\\)\n" +
+		"\\.+ expected string = \\(see difference\\)\n" +
+		"\\.+ Difference \\(-obtained \\+expected\\):\n" +
+		"\\.+     @@ -1,2 \\+1,2 @@\n" +
+		"\\.+      \"a\\\\n\"\n" +
+		"\\.+     -\"b\"\n" +
+		"\\.+     \\+\"c\"\n\n"
+	testHelperFailure(c, "Check(\"a\\nb\", DeepEquals, \"a\\nc\")", false, false, log,
+		func() interface{} {
+			return c.Check("a\nb", check.DeepEquals, "a\nc")
+		})
+}
+
+// logID is written out by a registered formatter when logged.
+type logID int
+
+func (s *HelpersS) TestCheckFailWithFormatter(c *check.C) {
+	c.Cleanup(check.RegisterFormatter(func(id logID) string { return fmt.Sprintf("logID(%03d)", int(id)) }))
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    return c\\.Check\\(logID\\(1\\), check\\.Equals, logID\\(2\\)\\)\n" +
+		"\\.+ obtained check_test\\.logID = logID\\(001\\)\n" +
+		"\\.+ expected check_test\\.logID = logID\\(002\\)\n\n"
+	testHelperFailure(c, "Check(logID(1), Equals, logID(2))", false, false, log,
+		func() interface{} {
+			return c.Check(logID(1), check.Equals, logID(2))
+		})
+}
+
 func (s *HelpersS) TestCheckFailWithExpected(c *check.C) {
 	checker := &MyChecker{result: false}
 	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
@@ -334,6 +370,208 @@ func (s *HelpersS) TestAssertWithNilChecker(c *check.C) {
 		})
 }
 
+// -----------------------------------------------------------------------
+// Tests for Expect() and Require().
+
+func (s *HelpersS) TestExpectSucceed(c *check.C) {
+	testHelperSuccess(c, "Expect(1).To(Equals, 1).NotTo(Equals, 2)", nil, func() interface{} {
+		c.Expect(1).To(check.Equals, 1).NotTo(check.Equals, 2)
+		return nil
+	})
+}
+
+func (s *HelpersS) TestExpectFailContinues(c *check.C) {
+	checker := &MyChecker{result: false}
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    c\\.Expect\\(1\\)\\.To\\(checker, 2\\)\\.NotTo\\(check\\.Equals, 1\\)\n" +
+		"\\.+ myobtained int = 1\n" +
+		"\\.+ myexpected int = 2\n\n" +
+		"helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    c\\.Expect\\(1\\)\\.To\\(checker, 2\\)\\.NotTo\\(check\\.Equals, 1\\)\n" +
+		"\\.+ obtained int = 1\n" +
+		"\\.+ expected int = 1\n" +
+		"\\.+ Obtained value is equal to 1\n\n"
+	testHelperFailure(c, "Expect(1).To(checker, 2).NotTo(Equals, 1)", nil, false, log,
+		func() interface{} {
+			c.Expect(1).To(checker, 2).NotTo(check.Equals, 1)
+			return nil
+		})
+}
+
+func (s *HelpersS) TestExpectWithNilChecker(c *check.C) {
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    c\\.Expect\\(1\\)\\.NotTo\\(nil\\)\n" +
+		"\\.+ NotTo\\(obtained, nil!\\?, \\.\\.\\.\\):\n" +
+		"\\.+ Oops\\.\\. you've provided a nil checker!\n\n"
+	testHelperFailure(c, "Expect(1).NotTo(nil)", nil, false, log,
+		func() interface{} {
+			c.Expect(1).NotTo(nil)
+			return nil
+		})
+}
+
+func (s *HelpersS) TestRequireSucceed(c *check.C) {
+	testHelperSuccess(c, "Require(nil).To(IsNil)", nil, func() interface{} {
+		c.Require(nil).To(check.IsNil)
+		return nil
+	})
+}
+
+func (s *HelpersS) TestRequireFailStops(c *check.C) {
+	checker := &MyChecker{result: false}
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    c\\.Require\\(1\\)\\.To\\(checker, 2\\)\\.To\\(checker, 3\\)\n" +
+		"\\.+ myobtained int = 1\n" +
+		"\\.+ myexpected int = 2\n\n"
+	testHelperFailure(c, "Require(1).To(checker, 2)", nil, true, log,
+		func() interface{} {
+			c.Require(1).To(checker, 2).To(checker, 3)
+			return nil
+		})
+}
+
+// -----------------------------------------------------------------------
+// Tests for Must().
+
+func loadValue(err error) (int, error) {
+	return 42, err
+}
+
+func (s *HelpersS) TestMustSucceed(c *check.C) {
+	testHelperSuccess(c, "Must(loadValue(nil))", 42, func() interface{} {
+		return c.Must(loadValue(nil)).(int)
+	})
+}
+
+func (s *HelpersS) TestMustFail(c *check.C) {
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    return c\\.Must\\(loadValue\\(errors\\.New\\(\"BOOM\"\\)\\)\\)\\.\\(int\\)\n" +
+		"\\.+ Error: BOOM\n\n"
+	testHelperFailure(c, "Must(loadValue(err))", nil, true, log,
+		func() interface{} {
+			return c.Must(loadValue(errors.New("BOOM"))).(int)
+		})
+}
+
+// -----------------------------------------------------------------------
+// Tests for EventuallyPanics().
+
+func (s *HelpersS) TestEventuallyPanicsSucceed(c *check.C) {
+	calls := 0
+	value := c.EventuallyPanics(time.Second, time.Millisecond, func() {
+		calls++
+		if calls == 3 {
+			panic("exhausted")
+		}
+	})
+	c.Check(value, check.Equals, "exhausted")
+	c.Check(calls, check.Equals, 3)
+}
+
+func (s *HelpersS) TestEventuallyPanicsFail(c *check.C) {
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    return c\\.EventuallyPanics\\(20\\*time\\.Millisecond, 5\\*time\\.Millisecond, func\\(\\) \\{\\}\\)\n" +
+		"\\.+ Error: function did not panic within 20ms \\([0-9]+ calls\\)\n\n"
+	testHelperFailure(c, "EventuallyPanics(...)", nil, true, log,
+		func() interface{} {
+			return c.EventuallyPanics(20*time.Millisecond, 5*time.Millisecond, func() {})
+		})
+}
+
+// -----------------------------------------------------------------------
+// Tests for NoLeak() and NoLeakAllowing().
+
+func (s *HelpersS) TestNoLeakSucceed(c *check.C) {
+	testHelperSuccess(c, "NoLeak()", true, func() interface{} {
+		return c.NoLeak(func() {
+			go time.Sleep(50 * time.Millisecond)
+		})
+	})
+}
+
+func (s *HelpersS) TestNoLeakFail(c *check.C) {
+	defer func(d time.Duration) { check.LeakSettleTime = d }(check.LeakSettleTime)
+	check.LeakSettleTime = 50 * time.Millisecond
+	stop := make(chan struct{})
+	defer close(stop)
+	log := "(?s)helpers_test\\.go:[0-9]+:.*\nhelpers_test\\.go:[0-9]+:\n" +
+		"    return c\\.NoLeak\\(func\\(\\) \\{\n.*" +
+		"\\.+ Error: 1 goroutines leaked \\(0 allowed\\):\n\n" +
+		"goroutine [0-9]+ \\[.*\\]:\n.*TestNoLeakFail.*\n\n"
+	testHelperFailure(c, "NoLeak()", false, false, log, func() interface{} {
+		return c.NoLeak(func() {
+			go func() { <-stop }()
+		})
+	})
+}
+
+func (s *HelpersS) TestNoLeakAllowing(c *check.C) {
+	stop := make(chan struct{})
+	defer close(stop)
+	testHelperSuccess(c, "NoLeakAllowing()", true, func() interface{} {
+		return c.NoLeakAllowing(1, func() {
+			go func() { <-stop }()
+		})
+	})
+}
+
+// -----------------------------------------------------------------------
+// Ensure problems are attributed to the line of the failing assertion.
+
+type CallerHelper struct {
+	line int
+}
+
+func (s *CallerHelper) TestClosure(c *check.C) {
+	func() {
+		s.line = getMyLine() + 1
+		c.Check(1, check.Equals, 2)
+	}()
+}
+
+func (s *CallerHelper) TestDefer(c *check.C) {
+	defer func() {
+		s.line = getMyLine() + 1
+		c.Check(1, check.Equals, 2)
+	}()
+}
+
+func (s *CallerHelper) TestLoop(c *check.C) {
+	for _, v := range []int{1, 2} {
+		s.line = getMyLine() + 1
+		c.Check(v, check.Equals, 0)
+	}
+}
+
+func checkPositive(c *check.C, v int) {
+	c.Helper()
+	c.Check(v > 0, check.Equals, true)
+}
+
+func (s *CallerHelper) TestHelper(c *check.C) {
+	s.line = getMyLine() + 1
+	checkPositive(c, -1)
+}
+
+func (s *HelpersS) TestCallerAttribution(c *check.C) {
+	for _, test := range []struct {
+		name, code string
+		count      int
+	}{
+		{"TestClosure", "c.Check(1, check.Equals, 2)", 1},
+		{"TestDefer", "c.Check(1, check.Equals, 2)", 1},
+		{"TestLoop", "c.Check(v, check.Equals, 0)", 2},
+		{"TestHelper", "checkPositive(c, -1)", 1},
+	} {
+		helper := CallerHelper{}
+		output := String{}
+		check.Run(&helper, &check.RunConf{Output: &output, Filter: test.name})
+		location := fmt.Sprintf("helpers_test.go:%d:\n    %s\n...", helper.line, test.code)
+		c.Check(strings.Count(output.value, location), check.Equals, test.count,
+			check.Commentf("%s output:\n%s", test.name, output.value))
+	}
+}
+
 // -----------------------------------------------------------------------
 // Ensure that values logged work properly in some interesting cases.
 
@@ -450,6 +688,129 @@ func (s *HelpersS) TestConcurrentLogging(c *check.C) {
 	stop.Wait()
 }
 
+// -----------------------------------------------------------------------
+// FreePort() and FreeListener() tests.
+
+type FreePortHelper struct {
+	ports    []int
+	listener net.Listener
+}
+
+func (s *FreePortHelper) Test(c *check.C) {
+	for i := 0; i < 5; i++ {
+		s.ports = append(s.ports, c.FreePort())
+	}
+	s.listener = c.FreeListener()
+	go func() {
+		if conn, err := s.listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	c.Assert(err, check.IsNil)
+	conn.Close()
+}
+
+func (s *HelpersS) TestFreePort(c *check.C) {
+	helper := FreePortHelper{}
+	output := String{}
+	check.Run(&helper, &check.RunConf{Output: &output})
+	c.Assert(output.value, check.Equals, "")
+	c.Assert(helper.ports, check.HasLen, 5)
+	seen := make(map[int]bool)
+	for _, port := range helper.ports {
+		c.Check(port > 0, check.Equals, true)
+		c.Check(seen[port], check.Equals, false)
+		seen[port] = true
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if c.Check(err, check.IsNil) {
+			l.Close()
+		}
+	}
+}
+
+func (s *HelpersS) TestFreeListenerClosedAfterTest(c *check.C) {
+	helper := FreePortHelper{}
+	output := String{}
+	check.Run(&helper, &check.RunConf{Output: &output})
+	c.Assert(helper.listener, check.NotNil)
+	_, err := net.Dial("tcp", helper.listener.Addr().String())
+	c.Assert(err, check.NotNil)
+}
+
+// -----------------------------------------------------------------------
+// SuiteValue() tests.
+
+type SuiteValueHelper struct {
+	built  int
+	values []interface{}
+}
+
+func (s *SuiteValueHelper) value(c *check.C) interface{} {
+	return c.SuiteValue("dataset", func() interface{} {
+		s.built++
+		return &[]int{1, 2, 3}
+	})
+}
+
+func (s *SuiteValueHelper) SetUpSuite(c *check.C) {
+	s.values = append(s.values, s.value(c))
+}
+
+func (s *SuiteValueHelper) Test1(c *check.C) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.value(c)
+		}()
+	}
+	wg.Wait()
+	s.values = append(s.values, s.value(c))
+}
+
+func (s *SuiteValueHelper) Test2(c *check.C) {
+	s.values = append(s.values, s.value(c))
+	other := c.SuiteValue("other", func() interface{} { return "other" })
+	c.Check(other, check.Equals, "other")
+}
+
+func (s *HelpersS) TestSuiteValue(c *check.C) {
+	helper := SuiteValueHelper{}
+	output := String{}
+	check.Run(&helper, &check.RunConf{Output: &output})
+	c.Assert(output.value, check.Equals, "")
+	c.Assert(helper.values, check.HasLen, 3)
+	c.Check(helper.built, check.Equals, 1)
+	c.Check(helper.values[1], check.Equals, helper.values[0])
+	c.Check(helper.values[2], check.Equals, helper.values[0])
+
+	// Values are not carried over to another run of the suite.
+	check.Run(&helper, &check.RunConf{Output: &output})
+	c.Check(helper.built, check.Equals, 2)
+	c.Check(helper.values[3], check.Not(check.Equals), helper.values[0])
+}
+
+type SuiteValuePanicHelper struct{}
+
+func (s *SuiteValuePanicHelper) Test1(c *check.C) {
+	c.SuiteValue("broken", func() interface{} { panic("BOOM") })
+}
+
+func (s *SuiteValuePanicHelper) Test2(c *check.C) {
+	c.SuiteValue("broken", func() interface{} { return 1 })
+}
+
+func (s *HelpersS) TestSuiteValueFactoryPanic(c *check.C) {
+	output := String{}
+	result := check.Run(&SuiteValuePanicHelper{}, &check.RunConf{Output: &output})
+	c.Check(result.Panicked, check.Equals, 2)
+	c.Check(output.value, check.Matches, `(?s).*\.\.\. Panic: BOOM.*`)
+	c.Check(output.value, check.Matches,
+		`(?s).*\.\.\. Panic: factory of suite value "broken" has panicked before.*`)
+}
+
 // -----------------------------------------------------------------------
 // Test the TestName function
 
@@ -0,0 +1,72 @@
+package check
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeRegistry holds the functions registered for types, such as the
+// comparers of DeepEquals and the formatters of logged values.
+type typeRegistry struct {
+	m     sync.RWMutex
+	funcs map[reflect.Type]*registeredFunc
+}
+
+// registeredFunc is a function registered for a type, along with the one
+// it replaced, which is restored once it's unregistered.
+type registeredFunc struct {
+	f        reflect.Value
+	replaced *registeredFunc
+}
+
+// register registers f for type t, replacing any function registered
+// earlier for it, and returns a function undoing that.
+func (r *typeRegistry) register(t reflect.Type, f reflect.Value) (unregister func()) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.funcs == nil {
+		r.funcs = make(map[reflect.Type]*registeredFunc)
+	}
+	entry := &registeredFunc{f: f, replaced: r.funcs[t]}
+	r.funcs[t] = entry
+	return func() { r.unregister(t, entry) }
+}
+
+// unregister removes entry from the functions registered for type t,
+// wherever it is in the chain of replaced functions.
+func (r *typeRegistry) unregister(t reflect.Type, entry *registeredFunc) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.funcs[t] == entry {
+		if entry.replaced == nil {
+			delete(r.funcs, t)
+		} else {
+			r.funcs[t] = entry.replaced
+		}
+		return
+	}
+	for e := r.funcs[t]; e != nil; e = e.replaced {
+		if e.replaced == entry {
+			e.replaced = entry.replaced
+			return
+		}
+	}
+}
+
+// lookup returns the function registered for type t, if any.
+func (r *typeRegistry) lookup(t reflect.Type) (f reflect.Value, ok bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	entry, ok := r.funcs[t]
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return entry.f, true
+}
+
+// empty tells whether no function is registered.
+func (r *typeRegistry) empty() bool {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return len(r.funcs) == 0
+}
@@ -1,9 +1,13 @@
 package check
 
 import (
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,7 +17,13 @@ type reporter interface {
 	GetReport() ([]byte, error)
 }
 
-type outputWriter interface {
+// OutputWriter is implemented by reporters, which are told about each call
+// of a test or fixture method as it starts and once it's done, along with
+// the label describing its outcome, such as "PASS" or "FAIL". Write
+// receives the log of calls as it's produced when streaming, which
+// StreamEnabled enables. Custom reporters are made available with
+// RegisterReporter.
+type OutputWriter interface {
 	Write(content []byte) (n int, err error)
 	WriteCallStarted(label string, c *C)
 
@@ -25,6 +35,40 @@ type outputWriter interface {
 	StreamEnabled() bool
 }
 
+type outputWriter = OutputWriter
+
+// suiteWriter is implemented by output writers which need to know when
+// all the calls of a suite have been reported.
+type suiteWriter interface {
+	WriteSuiteDone(suiteName string)
+}
+
+// trailerWriter is implemented by output writers which have more to say
+// once the summary of the run has been printed.
+type trailerWriter interface {
+	WriteTrailer()
+}
+
+// nonStreamingWriter is embedded by output writers which only report the
+// outcome of calls. Such writers never stream, so they're only written to
+// when combined with a writer which does, and the log lines they're given
+// are discarded.
+type nonStreamingWriter struct{}
+
+func (nonStreamingWriter) Write(content []byte) (n int, err error) {
+	return len(content), nil
+}
+
+func (nonStreamingWriter) WriteCallStarted(label string, c *C) {}
+
+func (nonStreamingWriter) StreamEnabled() bool { return false }
+
+// reportableCall returns whether the call c is worth reporting on its own.
+// Tests always are, while fixtures only are when they didn't succeed.
+func reportableCall(c *C) bool {
+	return c.kind == testKd || c.status != succeededSt
+}
+
 /*************** Plain writer *****************/
 
 type plainWriter struct {
@@ -34,12 +78,89 @@ type plainWriter struct {
 	wroteCallProblemLast bool
 	stream               bool
 	verbose              bool
+	verboseOnFailure     bool
+	dedup                bool
+	failuresAtEnd        bool
+	failures             []string
+	wroteCallProblem     bool
+	suites               map[string]*plainWriter
+	color                bool
+	slowest              *slowestTests
+	quiet                bool
 }
 
 func newPlainWriter(writer io.Writer, verbose, stream bool) *plainWriter {
 	return &plainWriter{writer: writer, stream: stream, verbose: verbose}
 }
 
+// plainWriterFor returns a plain writer set up as requested by conf.
+func plainWriterFor(writer io.Writer, conf *RunConf) *plainWriter {
+	w := newPlainWriter(writer, conf.Verbose, conf.Stream)
+	// Output is not cached in stream mode, so there's nothing to hold back.
+	w.verboseOnFailure = conf.VerboseOnFailure && !conf.Stream
+	w.dedup = conf.DedupOutput
+	w.failuresAtEnd = conf.FailuresAtEnd && !conf.Stream
+	w.color = conf.Color
+	if conf.Slowest > 0 {
+		w.slowest = newSlowestTests(conf.Slowest)
+	}
+	return w
+}
+
+// quietWriterFor returns a plain writer which only reports problems,
+// whatever the verbosity requested by conf.
+func quietWriterFor(writer io.Writer, conf *RunConf) *plainWriter {
+	quiet := *conf
+	quiet.Verbose, quiet.Stream, quiet.VerboseOnFailure = false, false, false
+	w := plainWriterFor(writer, &quiet)
+	w.quiet = true
+	return w
+}
+
+// suiteWriter returns the writer buffering the verbose output of the
+// suite c belongs to, or nil if output is not being buffered per suite.
+func (w *plainWriter) suiteWriter(c *C) *plainWriter {
+	if !w.verboseOnFailure {
+		return nil
+	}
+	name := c.method.suiteName()
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.suites == nil {
+		w.suites = make(map[string]*plainWriter)
+	}
+	sw, ok := w.suites[name]
+	if !ok {
+		sw = newPlainWriter(&bytes.Buffer{}, true, false)
+		sw.dedup = w.dedup
+		sw.color = w.color
+		w.suites[name] = sw
+	}
+	return sw
+}
+
+// WriteSuiteDone flushes the verbose output buffered for the given suite
+// if any of its calls had a problem, and discards it otherwise.
+func (w *plainWriter) WriteSuiteDone(suiteName string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	sw, ok := w.suites[suiteName]
+	if !ok {
+		return
+	}
+	delete(w.suites, suiteName)
+	if !sw.wroteCallProblem {
+		return
+	}
+	buf := sw.writer.(*bytes.Buffer).Bytes()
+	if w.wroteCallProblemLast && len(buf) > 0 && buf[0] != '\n' {
+		w.writer.Write([]byte("\n-----------------------------------" +
+			"-----------------------------------\n"))
+	}
+	w.writer.Write(buf)
+	w.wroteCallProblemLast = sw.wroteCallProblemLast
+}
+
 func (w *plainWriter) StreamEnabled() bool { return w.stream }
 
 func (w *plainWriter) Write(content []byte) (n int, err error) {
@@ -59,6 +180,11 @@ func (w *plainWriter) WriteCallStarted(label string, c *C) {
 }
 
 func (w *plainWriter) WriteCallSkipped(label string, c *C) {
+	if c.status == quarantinedSt && !w.quiet {
+		// Still show what went wrong with the quarantined test.
+		w.writeProblem(label, c)
+		return
+	}
 	w.writeSuccess(label, c)
 }
 
@@ -75,23 +201,72 @@ func (w *plainWriter) WriteCallSuccess(label string, c *C) {
 }
 
 func (w *plainWriter) writeProblem(label string, c *C) {
+	w.slowest.add(c)
+	if w.failuresAtEnd && c.status != quarantinedSt {
+		w.m.Lock()
+		w.failures = append(w.failures, renderCallHeader(label, c, problemSeparator, "\n\n")+w.callLog(c))
+		w.m.Unlock()
+	}
+	if sw := w.suiteWriter(c); sw != nil {
+		sw.writeProblem(label, c)
+		return
+	}
 	var prefix string
 	if !w.stream {
-		prefix = "\n-----------------------------------" +
-			"-----------------------------------\n"
+		prefix = problemSeparator
 	}
-	header := renderCallHeader(label, c, prefix, "\n\n")
+	header := renderCallHeader(w.colorize(label), c, prefix, "\n\n")
 	w.m.Lock()
 	w.wroteCallProblemLast = true
+	w.wroteCallProblem = true
 	w.writer.Write([]byte(header))
-	if !w.stream {
+	if w.dedup && !w.stream {
+		io.WriteString(w.writer, dedupLog(c.logb.String()))
+	} else if !w.stream {
 		c.logb.WriteTo(w.writer)
 	}
 	w.m.Unlock()
 }
 
+const problemSeparator = "\n-----------------------------------" +
+	"-----------------------------------\n"
+
+func (w *plainWriter) callLog(c *C) string {
+	if w.dedup {
+		return dedupLog(c.logb.String())
+	}
+	return c.logb.String()
+}
+
+// WriteTrailer lists the slowest tests, if requested, and repeats all the
+// problems reported during the run, so that they may be found at the end
+// of long outputs.
+func (w *plainWriter) WriteTrailer() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if tests := w.slowest.list(); len(tests) > 0 {
+		fmt.Fprintf(w.writer, "\n%d SLOWEST TESTS:\n", len(tests))
+		for _, test := range tests {
+			fmt.Fprintf(w.writer, "%12s  %s\n", test.Duration.Round(time.Millisecond), test.Name)
+		}
+	}
+	if len(w.failures) == 0 {
+		return
+	}
+	fmt.Fprintf(w.writer, "\n===================================="+
+		"==================================\n%d PROBLEMS REPORTED DURING THE RUN:\n", len(w.failures))
+	for _, failure := range w.failures {
+		io.WriteString(w.writer, failure)
+	}
+}
+
 func (w *plainWriter) writeSuccess(label string, c *C) {
-	if w.stream || (w.verbose && c.kind == testKd) {
+	w.slowest.add(c)
+	if sw := w.suiteWriter(c); sw != nil {
+		sw.writeSuccess(label, c)
+		return
+	}
+	if w.stream || (w.verbose && c.kind == testKd) || (c.quarantined && !w.quiet) {
 		// TODO Use a buffer here.
 		var suffix string
 		if c.reason != "" {
@@ -104,7 +279,7 @@ func (w *plainWriter) writeSuccess(label string, c *C) {
 		if w.stream {
 			suffix += "\n"
 		}
-		header := renderCallHeader(label, c, "", suffix)
+		header := renderCallHeader(w.colorize(label), c, "", suffix)
 		w.m.Lock()
 		// Resist temptation of using line as prefix above due to race.
 		if !w.stream && w.wroteCallProblemLast {
@@ -118,12 +293,126 @@ func (w *plainWriter) writeSuccess(label string, c *C) {
 	}
 }
 
+// labelColors holds the ANSI color of each label of the plain writer.
+var labelColors = map[string]string{
+	"PASS":              "\x1b[32m",
+	"FAIL EXPECTED":     "\x1b[32m",
+	"QUARANTINE PASSED": "\x1b[32m",
+	"FAIL":              "\x1b[31m",
+	"MISS":              "\x1b[31m",
+	"SKIP":              "\x1b[33m",
+	"PANIC":             "\x1b[33m",
+	"QUARANTINED":       "\x1b[33m",
+}
+
+// colorize returns label in its color if colors are enabled.
+func (w *plainWriter) colorize(label string) string {
+	if color, ok := labelColors[label]; ok && w.color {
+		return color + label + "\x1b[0m"
+	}
+	return label
+}
+
+// slowestTests keeps the given number of slowest tests seen, for the
+// writers which report them. Its methods do nothing on a nil value, so
+// that writers may leave it unset when slow tests aren't wanted.
+type slowestTests struct {
+	m     sync.Mutex
+	n     int
+	tests []timedTest
+}
+
+// timedTest is how long a test took to run.
+type timedTest struct {
+	Name     string
+	Duration time.Duration
+}
+
+func newSlowestTests(n int) *slowestTests {
+	return &slowestTests{n: n}
+}
+
+// add records the duration of c, if it's a test slow enough to be kept.
+func (s *slowestTests) add(c *C) {
+	if s == nil || c.kind != testKd {
+		return
+	}
+	test := timedTest{c.method.String(), c.duration}
+	s.m.Lock()
+	defer s.m.Unlock()
+	i := sort.Search(len(s.tests), func(i int) bool { return s.tests[i].Duration < test.Duration })
+	if i >= s.n {
+		return
+	}
+	s.tests = append(s.tests, timedTest{})
+	copy(s.tests[i+1:], s.tests[i:])
+	s.tests[i] = test
+	if len(s.tests) > s.n {
+		s.tests = s.tests[:s.n]
+	}
+}
+
+// list returns the slowest tests seen, slowest first.
+func (s *slowestTests) list() []timedTest {
+	if s == nil {
+		return nil
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]timedTest(nil), s.tests...)
+}
+
 func renderCallHeader(label string, c *C, prefix, suffix string) string {
 	pc := c.method.PC()
 	return fmt.Sprintf("%s%s: %s: %s%s", prefix, label, niceFuncPath(pc),
 		niceFuncName(pc), suffix)
 }
 
+var logLocationRe = regexp.MustCompile(`^\S+\.go:\d+:\n$`)
+
+// dedupLog collapses consecutive identical records of a call log into a
+// single one, noting how many times it was repeated. A record is either a
+// problem report, from its "file.go:line:" header up to the blank line
+// closing it, or any other single line.
+func dedupLog(log string) string {
+	lines := strings.SplitAfter(log, "\n")
+	var records []string
+	for i := 0; i < len(lines) && lines[i] != ""; {
+		end := i + 1
+		if logLocationRe.MatchString(lines[i]) {
+			for end < len(lines) && lines[end-1] != "\n" {
+				end++
+			}
+		}
+		records = append(records, strings.Join(lines[i:end], ""))
+		i = end
+	}
+	var buf bytes.Buffer
+	for i := 0; i < len(records); {
+		n := 1
+		for i+n < len(records) && records[i+n] == records[i] {
+			n++
+		}
+		buf.WriteString(repeatedRecord(records[i], n))
+		i += n
+	}
+	return buf.String()
+}
+
+func repeatedRecord(record string, n int) string {
+	if n == 1 {
+		return record
+	}
+	note := fmt.Sprintf("(repeated %d times)", n)
+	if strings.HasSuffix(record, "\n\n") {
+		return record[:len(record)-1] + "... " + note + "\n\n"
+	}
+	if strings.HasSuffix(record, "\n") {
+		return record[:len(record)-1] + " " + note + "\n"
+	}
+	return record + " " + note
+}
+
 /*************** xUnit writer *****************/
 type xunitReport struct {
 	XMLName xml.Name     `xml:"testsuites"`
@@ -131,25 +420,57 @@ type xunitReport struct {
 }
 
 type xunitSuite struct {
-	Package   string    `xml:"package,attr,omitempty"`
-	Name      string    `xml:"name,attr,omitempty"`
-	Classname string    `xml:"classname,attr,omitempty"`
-	Time      float64   `xml:"time,attr"`
-	Timestamp time.Time `xml:"timestamp,attr"`
+	Package   string       `xml:"package,attr,omitempty"`
+	Name      string       `xml:"name,attr,omitempty"`
+	Classname string       `xml:"classname,attr,omitempty"`
+	Time      xunitSeconds `xml:"time,attr"`
+	Timestamp string       `xml:"timestamp,attr"`
 
 	Tests    uint64 `xml:"tests,attr"`
 	Failures uint64 `xml:"failures,attr"`
 	Errors   uint64 `xml:"errors,attr"`
 	Skipped  uint64 `xml:"skipped,attr"`
 
-	// TODO: according specs suite also contains Properties node
-	// but reporter has no use for it for now
-	Testcases []xunitTestcase `xml:"testcase,omitempty"`
+	// Properties holds the properties of the run, set with
+	// SetReportProperty.
+	Properties *xunitProperties `xml:"properties,omitempty"`
+	Testcases  []xunitTestcase  `xml:"testcase,omitempty"`
+
+	// TODO: specs define also nodes "system-out" and "system-err" for
+	// suites, but reporter has no use for them for now, logs being kept
+	// per testcase
+
+	m     sync.Mutex
+	start time.Time
+}
+
+// xunitSeconds is a duration in seconds, written out in decimal notation
+// since not all xunit consumers understand exponents.
+type xunitSeconds float64
 
-	// TODO: specs define also nodes "properties", "system-out" and "system-err"
-	// but reporter has no use for them for now
+func (d xunitSeconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: strconv.FormatFloat(float64(d), 'f', -1, 64)}, nil
+}
+
+// xunitTimestampFormat is the format of timestamps in xunit reports, which
+// is ISO 8601 without a time zone, in local time.
+const xunitTimestampFormat = "2006-01-02T15:04:05"
+
+func newXunitSuite(name, pkg string, start time.Time) *xunitSuite {
+	return &xunitSuite{
+		Name:      name,
+		Package:   pkg,
+		Timestamp: start.Format(xunitTimestampFormat),
+		start:     start,
+	}
+}
 
-	m sync.Mutex
+// finish extends the duration of the suite up to end, if it's later than
+// what was recorded so far. It must be called with the lock held.
+func (s *xunitSuite) finish(end time.Time) {
+	if d := xunitSeconds(end.Sub(s.start).Seconds()); d > s.Time {
+		s.Time = d
+	}
 }
 
 func (s *xunitSuite) TestFail(tc xunitTestcase, message, value string) {
@@ -164,11 +485,11 @@ func (s *xunitSuite) TestFail(tc xunitTestcase, message, value string) {
 	s.addTestCase(tc)
 	s.m.Unlock()
 }
-func (s *xunitSuite) TestError(tc xunitTestcase, message, value string) {
+func (s *xunitSuite) TestError(tc xunitTestcase, message, value, errorType string) {
 	tc.Error = &xunitTestcaseResult{
 		Message: message,
 		Value:   value,
-		Type:    "go.error",
+		Type:    errorType,
 	}
 
 	s.m.Lock()
@@ -177,8 +498,8 @@ func (s *xunitSuite) TestError(tc xunitTestcase, message, value string) {
 	s.m.Unlock()
 }
 
-func (s *xunitSuite) TestSkip(tc xunitTestcase) {
-	tc.Skipped = true
+func (s *xunitSuite) TestSkip(tc xunitTestcase, message string) {
+	tc.Skipped = &xunitSkipped{Message: message}
 
 	s.m.Lock()
 	s.Skipped++
@@ -195,20 +516,57 @@ func (s *xunitSuite) TestSuccess(tc xunitTestcase) {
 func (s *xunitSuite) addTestCase(tc xunitTestcase) {
 	s.Tests++
 	s.Testcases = append(s.Testcases, tc)
-	s.Time = time.Since(s.Timestamp).Seconds()
+	s.finish(tc.end)
 }
 
 type xunitTestcase struct {
-	Name      string  `xml:"name,attr,omitempty"`
-	Classname string  `xml:"classname,attr,omitempty"`
-	Time      float64 `xml:"time,attr"`
+	Name      string       `xml:"name,attr,omitempty"`
+	Classname string       `xml:"classname,attr,omitempty"`
+	Time      xunitSeconds `xml:"time,attr"`
 
 	File string `xml:"file,attr,omitempty"`
 	Line int    `xml:"line,attr,omitempty"`
 
+	Properties *xunitProperties `xml:"properties,omitempty"`
+
 	Failure *xunitTestcaseResult `xml:"failure,omitempty"`
 	Error   *xunitTestcaseResult `xml:"error,omitempty"`
-	Skipped bool                 `xml:"skipped,omitempty"`
+	Skipped *xunitSkipped        `xml:"skipped,omitempty"`
+
+	// SystemOut holds the whole log of the call, including what was
+	// logged with c.Log and c.Logf, whatever its outcome.
+	SystemOut string `xml:"system-out,omitempty"`
+
+	end time.Time
+}
+
+type xunitProperties struct {
+	Properties []xunitProperty `xml:"property"`
+}
+
+type xunitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// newXunitProperties returns the properties for the given metadata, sorted
+// by name, or nil if there's none.
+func newXunitProperties(meta map[string]string) *xunitProperties {
+	if len(meta) == 0 {
+		return nil
+	}
+	properties := &xunitProperties{}
+	for name, value := range meta {
+		properties.Properties = append(properties.Properties, xunitProperty{name, value})
+	}
+	sort.Slice(properties.Properties, func(i, j int) bool {
+		return properties.Properties[i].Name < properties.Properties[j].Name
+	})
+	return properties
+}
+
+type xunitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
 }
 
 type xunitTestcaseResult struct {
@@ -222,9 +580,16 @@ type xunitWriter struct {
 	m      sync.Mutex
 	writer io.Writer
 	stream bool
+	dedup  bool
 	suites map[string]*xunitSuite
 
 	systemOut io.Writer
+
+	// report receives the report suite by suite as each one is done,
+	// rather than all at once from GetReport, if set.
+	report        io.Writer
+	reportStarted bool
+	reportErr     error
 }
 
 // creates new writer for xUnit reports
@@ -237,16 +602,102 @@ func newXunitWriter(writer io.Writer, stream bool) *xunitWriter {
 	}
 }
 
+// xunitWriterFor returns an xunit writer set up according to conf, which
+// logs into writer. With conf.StreamXunit, each suite is written into
+// report as soon as it's done instead of being held until GetReport.
+func xunitWriterFor(writer, report io.Writer, conf *RunConf) *xunitWriter {
+	w := newXunitWriter(writer, conf.Stream)
+	w.dedup = conf.DedupOutput
+	if conf.StreamXunit {
+		w.report = report
+		if writer == report {
+			// Streamed log lines would corrupt the report.
+			w.writer = nil
+		}
+	}
+	return w
+}
+
+// GetReport returns the report of all the calls written so far. When the
+// report is being streamed, only the part not yet written out is returned.
 func (w *xunitWriter) GetReport() ([]byte, error) {
+	if w.report != nil {
+		return w.reportRemainder()
+	}
 	report := xunitReport{}
 	report.Suites = make([]xunitSuite, 0, len(w.suites))
+	properties := newXunitProperties(reportProperties())
 	for k := range w.suites {
 		report.Suites = append(report.Suites, *w.suites[k])
+		report.Suites[len(report.Suites)-1].Properties = properties
 	}
 
 	return xml.MarshalIndent(report, "", "    ")
 }
 
+// WriteSuiteDone records the end of the given suite, after its last
+// fixture ran, and writes out its report when streaming, so that its memory
+// can be released.
+func (w *xunitWriter) WriteSuiteDone(suiteName string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	suite, ok := w.suites[suiteName]
+	if !ok {
+		return
+	}
+	suite.m.Lock()
+	suite.finish(time.Now())
+	suite.m.Unlock()
+	if w.report != nil {
+		delete(w.suites, suiteName)
+		w.writeSuite(w.report, suite)
+	}
+}
+
+func (w *xunitWriter) reportRemainder() ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	names := make([]string, 0, len(w.suites))
+	for name := range w.suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		w.writeSuite(&buf, w.suites[name])
+		delete(w.suites, name)
+	}
+	if w.reportErr != nil {
+		return nil, w.reportErr
+	}
+	if !w.reportStarted {
+		return []byte("<testsuites></testsuites>"), nil
+	}
+	buf.WriteString("</testsuites>")
+	return buf.Bytes(), nil
+}
+
+// writeSuite writes the report of suite into out, indented as it would be
+// within the complete report, opening the report first if needed.
+func (w *xunitWriter) writeSuite(out io.Writer, suite *xunitSuite) {
+	if w.reportErr != nil {
+		return
+	}
+	if !w.reportStarted {
+		if _, w.reportErr = io.WriteString(out, "<testsuites>\n"); w.reportErr != nil {
+			return
+		}
+		w.reportStarted = true
+	}
+	suite.Properties = newXunitProperties(reportProperties())
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("    ", "    ")
+	start := xml.StartElement{Name: xml.Name{Local: "testsuite"}}
+	if w.reportErr = encoder.EncodeElement(suite, start); w.reportErr == nil {
+		_, w.reportErr = io.WriteString(out, "\n")
+	}
+}
+
 func (w *xunitWriter) Write(content []byte) (n int, err error) {
 	if w.writer == nil {
 		return
@@ -264,14 +715,18 @@ func (w *xunitWriter) WriteCallStarted(label string, c *C) {
 func (w *xunitWriter) WriteCallSkipped(label string, c *C) {
 	res := w.newTestcase(c)
 	if !isAutogenerated(res.File) {
-		w.getSuite(c).TestSkip(res)
+		message := c.reason
+		if c.status == quarantinedSt {
+			message = "Quarantined: " + c.reason
+		}
+		w.getSuite(c).TestSkip(res, message)
 	}
 }
 
 func (w *xunitWriter) WriteCallFailure(label string, c *C) {
 	res := w.newTestcase(c)
 	if !isAutogenerated(res.File) {
-		message := strings.TrimSpace(c.logb.String())
+		message := strings.TrimSpace(w.callLog(c))
 		w.getSuite(c).TestFail(res, label, message)
 	}
 }
@@ -279,8 +734,8 @@ func (w *xunitWriter) WriteCallFailure(label string, c *C) {
 func (w *xunitWriter) WriteCallError(label string, c *C) {
 	res := w.newTestcase(c)
 	if !isAutogenerated(res.File) {
-		message := strings.TrimSpace(c.logb.String())
-		w.getSuite(c).TestError(res, label, message)
+		message := strings.TrimSpace(w.callLog(c))
+		w.getSuite(c).TestError(res, label, message, xunitErrorType(c))
 	}
 }
 
@@ -293,16 +748,19 @@ func (w *xunitWriter) WriteCallSuccess(label string, c *C) {
 
 func (w *xunitWriter) StreamEnabled() bool { return w.stream }
 
+func (w *xunitWriter) callLog(c *C) string {
+	if w.dedup {
+		return dedupLog(c.logb.String())
+	}
+	return c.logb.String()
+}
+
 func (w *xunitWriter) getSuite(c *C) (suite *xunitSuite) {
 	var ok bool
 	suiteName := c.method.suiteName()
 	w.m.Lock()
 	if suite, ok = w.suites[suiteName]; !ok {
-		suite = &xunitSuite{
-			Name:      suiteName,
-			Package:   getFuncPackage(c.method.PC()),
-			Timestamp: c.startTime,
-		}
+		suite = newXunitSuite(suiteName, getFuncPackage(c.method.PC()), c.startTime)
 		w.suites[suiteName] = suite
 	}
 	w.m.Unlock()
@@ -312,15 +770,113 @@ func (w *xunitWriter) getSuite(c *C) (suite *xunitSuite) {
 
 func (w *xunitWriter) newTestcase(c *C) xunitTestcase {
 	file, line := getFuncPosition(c.method.PC())
+	elapsed := c.elapsed()
 	return xunitTestcase{
 		Name:      c.testName,
 		Classname: c.method.suiteName(),
 		File:      file,
 		Line:      line,
-		Time:      time.Since(c.startTime).Seconds(),
+		Time:      xunitSeconds(elapsed.Seconds()),
+		SystemOut: w.callLog(c),
+		end:       c.startTime.Add(elapsed),
+
+		Properties: newXunitProperties(c.metadata()),
 	}
 }
 
+// xunitErrorType returns the type of the error of c, telling panics in
+// tests from those in their fixtures.
+func xunitErrorType(c *C) string {
+	switch {
+	case c.status == fixturePanickedSt, c.status == panickedSt && c.kind != testKd:
+		return "go.fixture-panic"
+	case c.status == panickedSt:
+		return "go.panic"
+	}
+	return "go.error"
+}
+
 func isAutogenerated(filename string) bool {
 	return filename == "<autogenerated>"
 }
+
+/*************** Multi writer *****************/
+
+// multiWriter reports to several output writers at once. Since writers
+// may consume the log of a call as they report it, each of them is handed
+// the log as it was when reporting started.
+type multiWriter struct {
+	writers []outputWriter
+}
+
+func newMultiWriter(writers ...outputWriter) *multiWriter {
+	return &multiWriter{writers: writers}
+}
+
+func (w *multiWriter) forEach(c *C, f func(writer outputWriter)) {
+	log := c.logb.String()
+	for i, writer := range w.writers {
+		if i > 0 {
+			c.logb.reset(log)
+		}
+		f(writer)
+	}
+}
+
+func (w *multiWriter) Write(content []byte) (n int, err error) {
+	for _, writer := range w.writers {
+		if n, err = writer.Write(content); err != nil {
+			return
+		}
+	}
+	return len(content), nil
+}
+
+func (w *multiWriter) WriteCallStarted(label string, c *C) {
+	for _, writer := range w.writers {
+		writer.WriteCallStarted(label, c)
+	}
+}
+
+func (w *multiWriter) WriteCallSuccess(label string, c *C) {
+	w.forEach(c, func(writer outputWriter) { writer.WriteCallSuccess(label, c) })
+}
+
+func (w *multiWriter) WriteCallSkipped(label string, c *C) {
+	w.forEach(c, func(writer outputWriter) { writer.WriteCallSkipped(label, c) })
+}
+
+func (w *multiWriter) WriteCallError(label string, c *C) {
+	w.forEach(c, func(writer outputWriter) { writer.WriteCallError(label, c) })
+}
+
+func (w *multiWriter) WriteCallFailure(label string, c *C) {
+	w.forEach(c, func(writer outputWriter) { writer.WriteCallFailure(label, c) })
+}
+
+// StreamEnabled reports whether any of the writers is streaming, in which
+// case the log of calls is written out as it happens.
+func (w *multiWriter) StreamEnabled() bool {
+	for _, writer := range w.writers {
+		if writer.StreamEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *multiWriter) WriteSuiteDone(suiteName string) {
+	for _, writer := range w.writers {
+		if sw, ok := writer.(suiteWriter); ok {
+			sw.WriteSuiteDone(suiteName)
+		}
+	}
+}
+
+func (w *multiWriter) WriteTrailer() {
+	for _, writer := range w.writers {
+		if tw, ok := writer.(trailerWriter); ok {
+			tw.WriteTrailer()
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package check
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************** Allure writer *****************/
+
+// allureResult is the content of an Allure result file, as described by
+// https://allurereport.org/docs/how-it-works-test-result-file/.
+type allureResult struct {
+	UUID          string              `json:"uuid"`
+	HistoryID     string              `json:"historyId"`
+	FullName      string              `json:"fullName"`
+	Name          string              `json:"name"`
+	Status        string              `json:"status"`
+	StatusDetails *allureStatusDetail `json:"statusDetails,omitempty"`
+	Stage         string              `json:"stage"`
+	Start         int64               `json:"start"`
+	Stop          int64               `json:"stop"`
+	Labels        []allureLabel       `json:"labels"`
+	Steps         []allureStep        `json:"steps"`
+	Attachments   []allureAttachment  `json:"attachments"`
+}
+
+type allureStatusDetail struct {
+	Message string `json:"message,omitempty"`
+	Trace   string `json:"trace,omitempty"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureStep struct {
+	Name          string              `json:"name"`
+	Status        string              `json:"status"`
+	StatusDetails *allureStatusDetail `json:"statusDetails,omitempty"`
+	Stage         string              `json:"stage"`
+	Start         int64               `json:"start"`
+	Stop          int64               `json:"stop"`
+	Attachments   []allureAttachment  `json:"attachments"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+// allureWriter writes a result file for each test into an Allure results
+// directory as soon as the test is done, with its log attached. The
+// SetUpTest and TearDownTest fixtures of a test are its steps. Metadata set
+// with c.SetMeta becomes labels, so "owner", "severity" or "tag" keys are
+// understood by Allure. Problems in suite fixtures, which aren't tests
+// themselves, get result files of their own.
+type allureWriter struct {
+	nonStreamingWriter
+	m       sync.Mutex
+	dir     string
+	dedup   bool
+	steps   map[string][]allureStep
+	created bool
+	failed  bool
+}
+
+func newAllureWriter(conf *RunConf) *allureWriter {
+	dir := conf.AllureDir
+	if dir == "" {
+		dir = "allure-results"
+	}
+	return &allureWriter{
+		dir:   dir,
+		dedup: conf.DedupOutput,
+		steps: make(map[string][]allureStep),
+	}
+}
+
+func (w *allureWriter) WriteCallSuccess(label string, c *C) { w.writeCall(label, c) }
+func (w *allureWriter) WriteCallSkipped(label string, c *C) { w.writeCall(label, c) }
+func (w *allureWriter) WriteCallError(label string, c *C)   { w.writeCall(label, c) }
+func (w *allureWriter) WriteCallFailure(label string, c *C) { w.writeCall(label, c) }
+
+func (w *allureWriter) writeCall(label string, c *C) {
+	log := c.logb.String()
+	if w.dedup {
+		log = dedupLog(log)
+	}
+	start := c.startTime
+	stop := start.Add(c.elapsed())
+	w.m.Lock()
+	defer w.m.Unlock()
+	if c.kind != testKd && c.testName != "" {
+		step := allureStep{
+			Name:          c.method.Info.Name,
+			Status:        allureStatus(c),
+			StatusDetails: allureDetails(c, log),
+			Stage:         "finished",
+			Start:         allureMillis(start),
+			Stop:          allureMillis(stop),
+			Attachments:   w.attach(log),
+		}
+		w.steps[c.testName] = append(w.steps[c.testName], step)
+		return
+	}
+	if !reportableCall(c) {
+		return
+	}
+	name := c.method.String()
+	fullName := funcImportPath(c.method.PC()) + "." + name
+	labels := []allureLabel{
+		{"suite", c.method.suiteName()},
+		{"package", funcImportPath(c.method.PC())},
+		{"testClass", c.method.suiteName()},
+		{"testMethod", c.method.Info.Name},
+		{"framework", "gocheck"},
+		{"language", "go"},
+	}
+	meta := c.metadata()
+	keys := make([]string, 0, len(meta))
+	for key := range meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		labels = append(labels, allureLabel{key, meta[key]})
+	}
+	steps := w.steps[c.testName]
+	delete(w.steps, c.testName)
+	if steps == nil {
+		steps = []allureStep{}
+	}
+	result := allureResult{
+		UUID:          allureUUID(),
+		HistoryID:     fmt.Sprintf("%x", md5.Sum([]byte(fullName))),
+		FullName:      fullName,
+		Name:          name,
+		Status:        allureStatus(c),
+		StatusDetails: allureDetails(c, log),
+		Stage:         "finished",
+		Start:         allureMillis(start),
+		Stop:          allureMillis(stop),
+		Labels:        labels,
+		Steps:         steps,
+		Attachments:   w.attach(log),
+	}
+	content, err := json.Marshal(&result)
+	if err == nil {
+		err = w.writeFile(result.UUID+"-result.json", content)
+	}
+	w.warn(err)
+}
+
+// attach writes log into an attachment file and returns it, if it isn't
+// empty. It must be called with the lock held.
+func (w *allureWriter) attach(log string) []allureAttachment {
+	if log == "" {
+		return []allureAttachment{}
+	}
+	source := allureUUID() + "-attachment.txt"
+	w.warn(w.writeFile(source, []byte(log)))
+	return []allureAttachment{{Name: "log", Source: source, Type: "text/plain"}}
+}
+
+// writeFile writes a file into the results directory, creating it first if
+// needed. It must be called with the lock held.
+func (w *allureWriter) writeFile(name string, content []byte) error {
+	if !w.created {
+		if err := os.MkdirAll(w.dir, 0755); err != nil {
+			return err
+		}
+		w.created = true
+	}
+	return ioutil.WriteFile(filepath.Join(w.dir, name), content, 0644)
+}
+
+// warn reports the first error writing results. It must be called with
+// the lock held.
+func (w *allureWriter) warn(err error) {
+	if err != nil && !w.failed {
+		w.failed = true
+		fmt.Fprintf(os.Stderr, "WARNING: cannot write Allure results: %v\n", err)
+	}
+}
+
+// allureStatus returns the Allure status of c. Failed assertions are
+// failures, while panics are broken tests, as Allure tells them apart.
+func allureStatus(c *C) string {
+	switch c.status {
+	case succeededSt:
+		return "passed"
+	case skippedSt, quarantinedSt:
+		return "skipped"
+	case panickedSt, fixturePanickedSt:
+		return "broken"
+	}
+	return "failed"
+}
+
+// allureDetails returns the reason or log of c, if it didn't pass.
+func allureDetails(c *C, log string) *allureStatusDetail {
+	switch c.status {
+	case succeededSt:
+		return nil
+	case skippedSt, quarantinedSt, missedSt:
+		return &allureStatusDetail{Message: c.reason}
+	}
+	// The message is the first line which isn't a location or code.
+	var message string
+	for _, line := range strings.Split(log, "\n") {
+		if line != "" && !strings.HasPrefix(line, "    ") && !githubLocationRe.MatchString(line) {
+			message = line
+			break
+		}
+	}
+	return &allureStatusDetail{Message: message, Trace: log}
+}
+
+func allureMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// allureUUID returns a random version 4 UUID.
+func allureUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,104 @@
+package check
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+/*************** Allure writer tests *****************/
+type AllureWriterSuite struct {
+	dir    string
+	writer *allureWriter
+}
+
+var _ = Suite(&AllureWriterSuite{})
+
+func (s *AllureWriterSuite) SetUpTest(c *C) {
+	s.dir = filepath.Join(c.MkDir(), "results")
+	s.writer = newAllureWriter(&RunConf{AllureDir: s.dir})
+}
+
+func (s *AllureWriterSuite) results(c *C) []allureResult {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*-result.json"))
+	c.Assert(err, IsNil)
+	var results []allureResult
+	for _, name := range names {
+		content, err := ioutil.ReadFile(name)
+		c.Assert(err, IsNil)
+		var result allureResult
+		c.Assert(json.Unmarshal(content, &result), IsNil)
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *AllureWriterSuite) attachment(c *C, attachment allureAttachment) string {
+	content, err := ioutil.ReadFile(filepath.Join(s.dir, attachment.Source))
+	c.Assert(err, IsNil)
+	return string(content)
+}
+
+func (s *AllureWriterSuite) TestFailedTest(c *C) {
+	setUp := &C{method: c.method, kind: fixtureKd, testName: c.testName, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", setUp)
+
+	call := &C{method: c.method, kind: testKd, testName: c.testName, logb: &logger{}, status: failedSt}
+	call.logf("file.go:12:\n    c.Assert(1, Equals, 2)\n... obtained int = 1")
+	call.SetMeta("severity", "critical")
+	s.writer.WriteCallFailure("FAIL", call)
+
+	results := s.results(c)
+	c.Assert(results, HasLen, 1)
+	result := results[0]
+	c.Check(result.Name, Equals, "AllureWriterSuite.TestFailedTest")
+	c.Check(result.FullName, Equals, "github.com/masukomi/check.AllureWriterSuite.TestFailedTest")
+	c.Check(result.Status, Equals, "failed")
+	c.Check(result.StatusDetails.Message, Equals, "... obtained int = 1")
+	c.Check(result.Stage, Equals, "finished")
+	c.Check(result.HistoryID, Matches, "[0-9a-f]{32}")
+	c.Check(result.Labels, DeepEquals, []allureLabel{
+		{"suite", "AllureWriterSuite"},
+		{"package", "github.com/masukomi/check"},
+		{"testClass", "AllureWriterSuite"},
+		{"testMethod", "TestFailedTest"},
+		{"framework", "gocheck"},
+		{"language", "go"},
+		{"severity", "critical"},
+	})
+	c.Assert(result.Steps, HasLen, 1)
+	c.Check(result.Steps[0].Name, Equals, "TestFailedTest")
+	c.Check(result.Steps[0].Status, Equals, "passed")
+	c.Assert(result.Attachments, HasLen, 1)
+	c.Check(result.Attachments[0].Type, Equals, "text/plain")
+	c.Check(strings.Contains(s.attachment(c, result.Attachments[0]), "obtained int = 1"), Equals, true)
+}
+
+func (s *AllureWriterSuite) TestStatuses(c *C) {
+	for _, status := range []funcStatus{skippedSt, panickedSt, quarantinedSt, missedSt} {
+		s.writer.WriteCallError("X", &C{method: c.method, kind: testKd, logb: &logger{}, status: status, reason: "why"})
+	}
+	statuses := make(map[string]int)
+	for _, result := range s.results(c) {
+		statuses[result.Status]++
+	}
+	c.Check(statuses, DeepEquals, map[string]int{"skipped": 2, "broken": 1, "failed": 1})
+}
+
+func (s *AllureWriterSuite) TestSuiteFixtures(c *C) {
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", fixture)
+	c.Check(s.results(c), HasLen, 0)
+
+	fixture.status = panickedSt
+	s.writer.WriteCallError("PANIC", fixture)
+	results := s.results(c)
+	c.Assert(results, HasLen, 1)
+	c.Check(results[0].Status, Equals, "broken")
+}
+
+func (s *AllureWriterSuite) TestUUID(c *C) {
+	c.Check(allureUUID(), Matches, "[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}")
+	c.Check(allureUUID(), Not(Equals), allureUUID())
+}
@@ -0,0 +1,115 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/*************** GitHub Actions writer *****************/
+
+// githubWriter is a plain writer which also emits GitHub Actions error
+// annotations for the problems of failing calls, so that they show up
+// inline in pull request diffs. Each problem is annotated at the innermost
+// location logged for it, which is where the failing assertion was made.
+// Problems without a location, such as panics, are annotated at the
+// function which had them, with the first line of its log.
+type githubWriter struct {
+	*plainWriter
+	workspace string
+}
+
+func newGithubWriter(writer io.Writer, conf *RunConf) *githubWriter {
+	return &githubWriter{
+		plainWriter: plainWriterFor(writer, conf),
+		workspace:   os.Getenv("GITHUB_WORKSPACE"),
+	}
+}
+
+func (w *githubWriter) WriteCallError(label string, c *C) {
+	w.annotate(label, c)
+	w.plainWriter.WriteCallError(label, c)
+}
+
+func (w *githubWriter) WriteCallFailure(label string, c *C) {
+	w.annotate(label, c)
+	w.plainWriter.WriteCallFailure(label, c)
+}
+
+var githubLocationRe = regexp.MustCompile(`^(\S+\.go):(\d+):$`)
+
+// githubProblem is a problem found in the log of a call.
+type githubProblem struct {
+	file, line string
+	message    []string
+}
+
+func (w *githubWriter) annotate(label string, c *C) {
+	var problems []*githubProblem
+	var problem *githubProblem
+	for _, line := range strings.Split(c.logb.String(), "\n") {
+		if m := githubLocationRe.FindStringSubmatch(line); m != nil {
+			if problem == nil || len(problem.message) > 0 {
+				problem = &githubProblem{}
+				problems = append(problems, problem)
+			}
+			problem.file, problem.line = m[1], m[2]
+		} else if line == "" {
+			problem = nil
+		} else if problem != nil && !strings.HasPrefix(line, "    ") {
+			problem.message = append(problem.message, line)
+		}
+	}
+	if len(problems) == 0 {
+		file, line := getFuncPosition(c.method.PC())
+		problem := &githubProblem{file: file, line: fmt.Sprint(line)}
+		if log := strings.TrimSpace(c.logb.String()); log != "" {
+			problem.message = []string{strings.SplitN(log, "\n", 2)[0]}
+		}
+		problems = append(problems, problem)
+	}
+	title := label + ": " + c.method.String()
+	var out string
+	for _, problem := range problems {
+		message := strings.Join(problem.message, "\n")
+		if message == "" {
+			message = title
+		}
+		out += fmt.Sprintf("::error file=%s,line=%s,title=%s::%s\n",
+			githubEscapeProperty(w.relativePath(problem.file)), problem.line,
+			githubEscapeProperty(title), githubEscapeData(message))
+	}
+	w.m.Lock()
+	io.WriteString(w.writer, out)
+	w.m.Unlock()
+}
+
+// relativePath returns the path of file, as logged, relative to the
+// GitHub workspace, which is how annotations must refer to files.
+func (w *githubWriter) relativePath(file string) string {
+	if !filepath.IsAbs(file) && initWDErr == nil {
+		file = filepath.Join(initWD, file)
+	}
+	if w.workspace != "" {
+		if rel, err := filepath.Rel(w.workspace, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+var githubDataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+var githubPropertyEscaper = strings.NewReplacer(
+	"%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+func githubEscapeData(s string) string {
+	return githubDataEscaper.Replace(s)
+}
+
+func githubEscapeProperty(s string) string {
+	return githubPropertyEscaper.Replace(s)
+}
@@ -0,0 +1,63 @@
+package check
+
+import (
+	"bytes"
+	"strings"
+)
+
+/*************** GitHub Actions writer tests *****************/
+type GithubWriterSuite struct {
+	output *bytes.Buffer
+	writer *githubWriter
+}
+
+var _ = Suite(&GithubWriterSuite{})
+
+func (s *GithubWriterSuite) SetUpTest(c *C) {
+	s.output = &bytes.Buffer{}
+	s.writer = newGithubWriter(s.output, &RunConf{})
+	s.writer.workspace = strings.TrimSuffix(initWD, "/")
+}
+
+func (s *GithubWriterSuite) annotations() []string {
+	var annotations []string
+	for _, line := range strings.Split(s.output.String(), "\n") {
+		if strings.HasPrefix(line, "::") {
+			annotations = append(annotations, line)
+		}
+	}
+	return annotations
+}
+
+func (s *GithubWriterSuite) TestAnnotatesAssertions(c *C) {
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt}
+	call.logb.Write([]byte("Some log line\n" +
+		"foo_test.go:10:\n    helper(c)\nfoo_test.go:42:\n    c.Check(a, Equals, b)\n" +
+		"... obtained int = 1\n... expected int = 2\n\n" +
+		"sub/bar_test.go:7:\n    c.Fatal(\"50%, done: nope\")\n... Error: 50%, done: nope\n\n"))
+	s.writer.WriteCallFailure("FAIL", call)
+
+	c.Assert(s.annotations(), DeepEquals, []string{
+		"::error file=foo_test.go,line=42,title=FAIL%3A GithubWriterSuite.TestAnnotatesAssertions::" +
+			"... obtained int = 1%0A... expected int = 2",
+		"::error file=sub/bar_test.go,line=7,title=FAIL%3A GithubWriterSuite.TestAnnotatesAssertions::" +
+			"... Error: 50%25, done: nope",
+	})
+	c.Assert(s.output.String(), Matches, "(?s).*\n-+\nFAIL: .*GithubWriterSuite.TestAnnotatesAssertions\n\nSome log line\n.*")
+}
+
+func (s *GithubWriterSuite) TestAnnotatesPanicsAtFunction(c *C) {
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, status: panickedSt}
+	call.logb.Write([]byte("... Panic: boom (PC=0x1234)\n\n"))
+	s.writer.WriteCallError("PANIC", call)
+
+	annotations := s.annotations()
+	c.Assert(annotations, HasLen, 1)
+	c.Assert(annotations[0], Matches, "::error file=reporter_github_test.go,line=[0-9]+,"+
+		"title=PANIC%3A GithubWriterSuite.TestAnnotatesPanicsAtFunction::... Panic: boom \\(PC=0x1234\\)")
+}
+
+func (s *GithubWriterSuite) TestNoAnnotationsForSuccess(c *C) {
+	s.writer.WriteCallSuccess("PASS", c)
+	c.Assert(s.annotations(), HasLen, 0)
+}
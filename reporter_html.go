@@ -0,0 +1,205 @@
+package check
+
+import (
+	"bytes"
+	"html/template"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*************** HTML writer *****************/
+
+// htmlWriter collects the results of tests, and of fixtures which didn't
+// succeed, to render a self-contained HTML report from GetReport.
+type htmlWriter struct {
+	nonStreamingWriter
+	m       sync.Mutex
+	dedup   bool
+	suites  []*htmlSuite
+	byName  map[string]*htmlSuite
+	started time.Time
+	slowest *slowestTests
+}
+
+type htmlSuite struct {
+	Name  string
+	Calls []htmlCall
+}
+
+type htmlCall struct {
+	Name     string
+	Status   string
+	Fixture  bool
+	Duration time.Duration
+	Log      string
+}
+
+func newHTMLWriter(conf *RunConf) *htmlWriter {
+	w := &htmlWriter{
+		dedup:   conf.DedupOutput,
+		byName:  make(map[string]*htmlSuite),
+		started: time.Now(),
+	}
+	if conf.Slowest > 0 {
+		w.slowest = newSlowestTests(conf.Slowest)
+	}
+	return w
+}
+
+func (w *htmlWriter) WriteCallSuccess(label string, c *C) { w.addCall(c) }
+func (w *htmlWriter) WriteCallSkipped(label string, c *C) { w.addCall(c) }
+func (w *htmlWriter) WriteCallError(label string, c *C)   { w.addCall(c) }
+func (w *htmlWriter) WriteCallFailure(label string, c *C) { w.addCall(c) }
+
+func (w *htmlWriter) addCall(c *C) {
+	w.slowest.add(c)
+	if !reportableCall(c) {
+		return
+	}
+	call := htmlCall{
+		Name:     c.method.String(),
+		Status:   testStatus(c),
+		Fixture:  c.kind != testKd,
+		Duration: c.duration,
+		Log:      c.logb.String(),
+	}
+	if w.dedup {
+		call.Log = dedupLog(call.Log)
+	}
+	name := c.method.suiteName()
+	w.m.Lock()
+	defer w.m.Unlock()
+	suite, ok := w.byName[name]
+	if !ok {
+		suite = &htmlSuite{Name: name}
+		w.byName[name] = suite
+		w.suites = append(w.suites, suite)
+	}
+	suite.Calls = append(suite.Calls, call)
+}
+
+// htmlStatusCount is the number of tests with a given status.
+type htmlStatusCount struct {
+	Status string
+	Count  int
+}
+
+type htmlReport struct {
+	Generated time.Time
+	Duration  time.Duration
+	Passed    bool
+	Total     int
+	Counts    []htmlStatusCount
+	Suites    []*htmlSuite
+	Longest   time.Duration
+	Slowest   []timedTest
+}
+
+func (w *htmlWriter) GetReport() ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	report := htmlReport{
+		Generated: time.Now(),
+		Duration:  time.Since(w.started),
+		Passed:    true,
+		Suites:    w.suites,
+		Slowest:   w.slowest.list(),
+	}
+	counts := make(map[string]int)
+	for _, suite := range w.suites {
+		for _, call := range suite.Calls {
+			if call.Duration > report.Longest {
+				report.Longest = call.Duration
+			}
+			if call.Fixture {
+				continue
+			}
+			report.Total++
+			counts[call.Status]++
+			switch call.Status {
+			case TestFailed, TestPanicked, TestFixturePanicked, TestMissed:
+				report.Passed = false
+			}
+		}
+	}
+	for status, count := range counts {
+		report.Counts = append(report.Counts, htmlStatusCount{status, count})
+	}
+	sort.Slice(report.Counts, func(i, j int) bool {
+		return report.Counts[i].Status < report.Counts[j].Status
+	})
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, &report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// htmlStatusClass returns the CSS class of the badge for a status.
+func htmlStatusClass(status string) string {
+	switch status {
+	case TestPassed, TestExpectedFailure, TestQuarantinePassed:
+		return "pass"
+	case TestSkipped, TestQuarantined:
+		return "skip"
+	}
+	return "fail"
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"class": htmlStatusClass,
+	"bar": func(d, longest time.Duration) float64 {
+		if longest <= 0 {
+			return 0
+		}
+		// Percentage of the longest duration, with one decimal.
+		return float64(1000*d/longest) / 10
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Test report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 .badge { font-size: 60%; vertical-align: middle; }
+section { margin-bottom: 2em; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: 0.3em 0.5em; border-bottom: 1px solid #eee; vertical-align: top; }
+td.name { width: 40%; }
+td.time { width: 20%; }
+.badge { display: inline-block; padding: 0.1em 0.6em; border-radius: 0.8em; color: #fff; font-size: 85%; }
+.pass { background: #2a9d4b; }
+.skip { background: #9a9a9a; }
+.fail { background: #d73a49; }
+.bar { height: 0.6em; background: #79a6d2; min-width: 1px; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>Test report <span class="badge {{if .Passed}}pass{{else}}fail{{end}}">{{if .Passed}}PASSED{{else}}FAILED{{end}}</span></h1>
+<p>{{.Total}} tests in {{.Duration}}{{range .Counts}}, {{.Count}} {{.Status}}{{end}}. Generated at {{.Generated.Format "2006-01-02 15:04:05 MST"}}.</p>
+{{$longest := .Longest}}{{if .Slowest}}<section>
+<h2>Slowest tests</h2>
+<table>
+{{range .Slowest}}<tr>
+<td class="name">{{.Name}}</td>
+<td class="time">{{.Duration}}<div class="bar" style="width: {{bar .Duration $longest}}%"></div></td>
+</tr>
+{{end}}</table>
+</section>
+{{end}}{{range .Suites}}<section>
+<h2>{{.Name}}</h2>
+<table>
+{{range .Calls}}<tr>
+<td class="name">{{.Name}}{{if .Fixture}} (fixture){{end}}</td>
+<td><span class="badge {{class .Status}}">{{.Status}}</span></td>
+<td class="time">{{.Duration}}<div class="bar" style="width: {{bar .Duration $longest}}%"></div></td>
+<td>{{if .Log}}<details><summary>log</summary><pre>{{.Log}}</pre></details>{{end}}</td>
+</tr>
+{{end}}</table>
+</section>
+{{end}}</body>
+</html>
+`))
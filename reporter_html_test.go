@@ -0,0 +1,61 @@
+package check
+
+import (
+	"time"
+)
+
+/*************** HTML writer tests *****************/
+type HTMLWriterSuite struct {
+	writer *htmlWriter
+}
+
+var _ = Suite(&HTMLWriterSuite{})
+
+func (s *HTMLWriterSuite) SetUpTest(c *C) {
+	s.writer = newHTMLWriter(&RunConf{})
+}
+
+func (s *HTMLWriterSuite) TestReport(c *C) {
+	pass := &C{method: c.method, kind: testKd, logb: &logger{}}
+	pass.duration = time.Second
+	s.writer.WriteCallSuccess("PASS", pass)
+
+	fail := &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt}
+	fail.duration = 4 * time.Second
+	fail.logb.Write([]byte("... obtained <nil>\n"))
+	s.writer.WriteCallFailure("FAIL", fail)
+
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", fixture)
+
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	html := string(report)
+	c.Check(html, Matches, `(?s)<!DOCTYPE html>.*<span class="badge fail">FAILED</span>.*`)
+	c.Check(html, Matches, `(?s).*<p>2 tests in [^,]+, 1 failed, 1 passed\. .*`)
+	c.Check(html, Matches, `(?s).*<h2>HTMLWriterSuite</h2>.*`)
+	c.Check(html, Matches, `(?s).*<span class="badge pass">passed</span>.*width: 25%.*`)
+	c.Check(html, Matches, `(?s).*<span class="badge fail">failed</span>.*width: 100%.*`+
+		`<details><summary>log</summary><pre>\.\.\. obtained &lt;nil&gt;\n</pre></details>.*`)
+	c.Check(html, Not(Matches), `(?s).*\(fixture\).*`)
+}
+
+func (s *HTMLWriterSuite) TestPassingReport(c *C) {
+	s.writer.WriteCallSuccess("PASS", c)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Check(string(report), Matches, `(?s).*<span class="badge pass">PASSED</span>.*`)
+}
+
+func (s *HTMLWriterSuite) TestSlowestTests(c *C) {
+	s.writer = newHTMLWriter(&RunConf{Slowest: 1})
+	for _, d := range []time.Duration{time.Second, 3 * time.Second} {
+		test := &C{method: c.method, kind: testKd, logb: &logger{}}
+		test.duration = d
+		s.writer.WriteCallSuccess("PASS", test)
+	}
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Check(string(report), Matches, `(?s).*<h2>Slowest tests</h2>\n<table>\n<tr>\n`+
+		`<td class="name">HTMLWriterSuite\.TestSlowestTests</td>\n<td class="time">3s<div [^\n]*width: 100%[^\n]*</td>\n</tr>\n</table>.*`)
+}
@@ -0,0 +1,137 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+/*************** JSON writer *****************/
+
+// jsonTestEvent is written as a single line for each test, and for each
+// fixture which didn't succeed.
+type jsonTestEvent struct {
+	Type     string            `json:"type"`
+	Suite    string            `json:"suite"`
+	Test     string            `json:"test"`
+	Status   string            `json:"status"`
+	Duration float64           `json:"duration"`
+	Reason   string            `json:"reason,omitempty"`
+	Output   string            `json:"output,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+}
+
+// jsonSummaryEvent is the last line of the report.
+type jsonSummaryEvent struct {
+	Type     string         `json:"type"`
+	Passed   bool           `json:"passed"`
+	Total    int            `json:"total"`
+	Counts   map[string]int `json:"counts"`
+	Duration float64        `json:"duration"`
+
+	Slowest    []jsonSlowTest    `json:"slowest,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// jsonSlowTest is one of the slowest tests listed in the summary.
+type jsonSlowTest struct {
+	Test     string  `json:"test"`
+	Duration float64 `json:"duration"`
+}
+
+// jsonWriter reports each test as a JSON object on its own line as soon
+// as it's done, and the totals as a final summary object from GetReport.
+// Durations are in seconds.
+type jsonWriter struct {
+	nonStreamingWriter
+	m       sync.Mutex
+	writer  io.Writer
+	dedup   bool
+	counts  map[string]int
+	total   int
+	started time.Time
+	slowest *slowestTests
+}
+
+func newJSONWriter(writer io.Writer, conf *RunConf) *jsonWriter {
+	w := &jsonWriter{
+		writer:  writer,
+		dedup:   conf.DedupOutput,
+		counts:  make(map[string]int),
+		started: time.Now(),
+	}
+	if conf.Slowest > 0 {
+		w.slowest = newSlowestTests(conf.Slowest)
+	}
+	return w
+}
+
+func (w *jsonWriter) GetReport() ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	summary := jsonSummaryEvent{
+		Type:     "summary",
+		Passed:   true,
+		Total:    w.total,
+		Counts:   w.counts,
+		Duration: time.Since(w.started).Seconds(),
+	}
+	for _, status := range []string{TestFailed, TestPanicked, TestFixturePanicked, TestMissed} {
+		if w.counts[status] > 0 {
+			summary.Passed = false
+		}
+	}
+	summary.Properties = reportProperties()
+	for _, test := range w.slowest.list() {
+		summary.Slowest = append(summary.Slowest, jsonSlowTest{test.Name, test.Duration.Seconds()})
+	}
+	report, err := json.Marshal(summary)
+	if err != nil {
+		return nil, err
+	}
+	return append(report, '\n'), nil
+}
+
+func (w *jsonWriter) WriteCallSuccess(label string, c *C) { w.writeEvent(c) }
+func (w *jsonWriter) WriteCallSkipped(label string, c *C) { w.writeEvent(c) }
+func (w *jsonWriter) WriteCallError(label string, c *C)   { w.writeEvent(c) }
+func (w *jsonWriter) WriteCallFailure(label string, c *C) { w.writeEvent(c) }
+
+func (w *jsonWriter) writeEvent(c *C) {
+	w.slowest.add(c)
+	if !reportableCall(c) {
+		return
+	}
+	result := newTestResult(c)
+	event := jsonTestEvent{
+		Type:     "test",
+		Suite:    result.Suite,
+		Test:     result.Name,
+		Status:   result.Status,
+		Duration: result.Duration.Seconds(),
+		Reason:   result.Reason,
+		Output:   c.logb.String(),
+		Meta:     result.Meta,
+	}
+	if c.kind != testKd {
+		event.Type = "fixture"
+	}
+	if w.dedup {
+		event.Output = dedupLog(event.Output)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(event); err != nil {
+		return
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	if c.kind == testKd {
+		w.counts[result.Status]++
+		w.total++
+	}
+	w.writer.Write(buf.Bytes())
+}
@@ -0,0 +1,108 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+/*************** JSON writer tests *****************/
+type JSONWriterSuite struct {
+	output *bytes.Buffer
+	writer *jsonWriter
+}
+
+var _ = Suite(&JSONWriterSuite{})
+
+func (s *JSONWriterSuite) SetUpTest(c *C) {
+	s.output = &bytes.Buffer{}
+	s.writer = newJSONWriter(s.output, &RunConf{})
+}
+
+func (s *JSONWriterSuite) events(c *C) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(s.output.String()), "\n") {
+		var event map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(line), &event), IsNil)
+		events = append(events, event)
+	}
+	return events
+}
+
+func (s *JSONWriterSuite) TestTestEvent(c *C) {
+	c.Log("Expected failure!")
+	c.SetMeta("dataset", "small")
+	c.status = failedSt
+	s.writer.WriteCallFailure("FAIL", c)
+	c.status = succeededSt
+
+	events := s.events(c)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0]["type"], Equals, "test")
+	c.Check(events[0]["suite"], Equals, "JSONWriterSuite")
+	c.Check(events[0]["test"], Equals, "JSONWriterSuite.TestTestEvent")
+	c.Check(events[0]["status"], Equals, "failed")
+	c.Check(events[0]["output"], Equals, "Expected failure!\n")
+	c.Check(events[0]["meta"], DeepEquals, map[string]interface{}{"dataset": "small"})
+	c.Check(events[0]["duration"], FitsTypeOf, float64(0))
+}
+
+func (s *JSONWriterSuite) TestSucceededFixturesAreLeftOut(c *C) {
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", fixture)
+	c.Assert(s.output.String(), Equals, "")
+
+	fixture.status = panickedSt
+	s.writer.WriteCallError("PANIC", fixture)
+	events := s.events(c)
+	c.Assert(events, HasLen, 1)
+	c.Check(events[0]["type"], Equals, "fixture")
+	c.Check(events[0]["status"], Equals, "panicked")
+}
+
+func (s *JSONWriterSuite) TestSummary(c *C) {
+	s.writer.WriteCallSuccess("PASS", c)
+	s.writer.WriteCallSuccess("PASS", c)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	var summary map[string]interface{}
+	c.Assert(json.Unmarshal(report, &summary), IsNil)
+	c.Check(summary["type"], Equals, "summary")
+	c.Check(summary["passed"], Equals, true)
+	c.Check(summary["total"], Equals, float64(2))
+	c.Check(summary["counts"], DeepEquals, map[string]interface{}{"passed": float64(2)})
+
+	c.status = failedSt
+	s.writer.WriteCallFailure("FAIL", c)
+	c.status = succeededSt
+	report, err = s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(report, &summary), IsNil)
+	c.Check(summary["passed"], Equals, false)
+	c.Check(summary["total"], Equals, float64(3))
+}
+
+func (s *JSONWriterSuite) TestSlowest(c *C) {
+	writer := newJSONWriter(s.output, &RunConf{Slowest: 1})
+	call := &C{method: c.method, kind: testKd, logb: &logger{}}
+	call.duration = 2 * time.Second
+	writer.WriteCallSuccess("PASS", call)
+	writer.WriteCallSuccess("PASS", &C{method: c.method, kind: testKd, logb: &logger{}})
+	report, err := writer.GetReport()
+	c.Assert(err, IsNil)
+	var summary map[string]interface{}
+	c.Assert(json.Unmarshal(report, &summary), IsNil)
+	c.Check(summary["slowest"], DeepEquals, []interface{}{
+		map[string]interface{}{"test": "JSONWriterSuite.TestSlowest", "duration": 2.0},
+	})
+}
+
+func (s *JSONWriterSuite) TestReportProperties(c *C) {
+	defer SetReportProperty("sha", "abc123")()
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	var summary map[string]interface{}
+	c.Assert(json.Unmarshal(report, &summary), IsNil)
+	c.Check(summary["properties"], DeepEquals, map[string]interface{}{"sha": "abc123"})
+}
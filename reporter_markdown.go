@@ -0,0 +1,129 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************** Markdown writer *****************/
+
+// markdownWriter collects the results of tests, and of fixtures which
+// didn't succeed, to render a GitHub-flavored Markdown summary from
+// GetReport: a table with a row per test, followed by the logs of those
+// which didn't pass. It suits $GITHUB_STEP_SUMMARY.
+type markdownWriter struct {
+	nonStreamingWriter
+	m       sync.Mutex
+	dedup   bool
+	rows    []markdownRow
+	started time.Time
+}
+
+type markdownRow struct {
+	suite, test, status string
+	duration            time.Duration
+	log                 string
+}
+
+func newMarkdownWriter(conf *RunConf) *markdownWriter {
+	return &markdownWriter{dedup: conf.DedupOutput, started: time.Now()}
+}
+
+func (w *markdownWriter) WriteCallSuccess(label string, c *C) { w.addRow(c) }
+func (w *markdownWriter) WriteCallSkipped(label string, c *C) { w.addRow(c) }
+func (w *markdownWriter) WriteCallError(label string, c *C)   { w.addRow(c) }
+func (w *markdownWriter) WriteCallFailure(label string, c *C) { w.addRow(c) }
+
+func (w *markdownWriter) addRow(c *C) {
+	if !reportableCall(c) {
+		return
+	}
+	row := markdownRow{
+		suite:    c.method.suiteName(),
+		test:     c.method.Info.Name,
+		status:   testStatus(c),
+		duration: c.duration,
+	}
+	if c.kind != testKd {
+		row.test += " (fixture)"
+	}
+	if row.status != TestPassed {
+		row.log = c.logb.String()
+		if w.dedup {
+			row.log = dedupLog(row.log)
+		}
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.rows = append(w.rows, row)
+}
+
+func (w *markdownWriter) GetReport() ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	rows := append([]markdownRow(nil), w.rows...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].suite != rows[j].suite {
+			return rows[i].suite < rows[j].suite
+		}
+		return rows[i].test < rows[j].test
+	})
+	passed, total := true, 0
+	counts := make(map[string]int)
+	for _, row := range rows {
+		switch row.status {
+		case TestFailed, TestPanicked, TestFixturePanicked, TestMissed:
+			passed = false
+		}
+		if !strings.HasSuffix(row.test, " (fixture)") {
+			total++
+			counts[row.status]++
+		}
+	}
+	var buf bytes.Buffer
+	outcome := "PASSED"
+	if !passed {
+		outcome = "FAILED"
+	}
+	fmt.Fprintf(&buf, "### Test results: %s\n\n", outcome)
+	fmt.Fprintf(&buf, "%d tests in %s", total, time.Since(w.started).Round(time.Millisecond))
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for i, status := range statuses {
+		sep := ", "
+		if i == 0 {
+			sep = ": "
+		}
+		fmt.Fprintf(&buf, "%s%d %s", sep, counts[status], status)
+	}
+	buf.WriteString(".\n")
+	if len(rows) > 0 {
+		buf.WriteString("\n| Suite | Test | Result | Duration |\n| --- | --- | --- | ---: |\n")
+		for _, row := range rows {
+			fmt.Fprintf(&buf, "| %s | %s | %s | %s |\n", markdownEscape(row.suite),
+				markdownEscape(row.test), row.status, row.duration.Round(time.Microsecond))
+		}
+	}
+	for _, row := range rows {
+		if log := strings.TrimRight(row.log, "\n"); log != "" {
+			fmt.Fprintf(&buf, "\n<details><summary>%s.%s: %s</summary>\n\n```\n%s\n```\n\n</details>\n",
+				htmlEscaper.Replace(row.suite), htmlEscaper.Replace(row.test), row.status,
+				strings.Replace(log, "```", "` ` `", -1))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// markdownEscape escapes the characters which would break a table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,38 @@
+package check
+
+/*************** Markdown writer tests *****************/
+type MarkdownWriterSuite struct{}
+
+var _ = Suite(&MarkdownWriterSuite{})
+
+func (s *MarkdownWriterSuite) TestReport(c *C) {
+	writer := newMarkdownWriter(&RunConf{})
+	writer.WriteCallSuccess("PASS", &C{method: c.method, kind: fixtureKd, logb: &logger{}})
+	failed := &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt}
+	failed.logf("a | b <c>")
+	writer.WriteCallFailure("FAIL", failed)
+	writer.WriteCallSuccess("PASS", &C{method: c.method, kind: testKd, logb: &logger{}})
+	writer.WriteCallError("PANIC", &C{method: c.method, kind: fixtureKd, logb: &logger{}, status: panickedSt})
+
+	report, err := writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(string(report), Matches, "### Test results: FAILED\n\n"+
+		"2 tests in [0-9.]+[mµn]?s: 1 failed, 1 passed.\n\n"+
+		`\| Suite \| Test \| Result \| Duration \|\n`+
+		`\| --- \| --- \| --- \| ---: \|\n`+
+		`\| MarkdownWriterSuite \| TestReport \| failed \| 0s \|\n`+
+		`\| MarkdownWriterSuite \| TestReport \| passed \| 0s \|\n`+
+		`\| MarkdownWriterSuite \| TestReport \(fixture\) \| panicked \| 0s \|\n`+
+		"\n<details><summary>MarkdownWriterSuite.TestReport: failed</summary>\n\n"+
+		"```\na \\| b <c>\n```\n\n</details>\n")
+}
+
+func (s *MarkdownWriterSuite) TestEmptyRun(c *C) {
+	report, err := newMarkdownWriter(&RunConf{}).GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(string(report), Matches, "### Test results: PASSED\n\n0 tests in .*s.\n")
+}
+
+func (s *MarkdownWriterSuite) TestEscape(c *C) {
+	c.Assert(markdownEscape("a|b\nc"), Equals, `a\|b c`)
+}
@@ -0,0 +1,172 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+/*************** Per-suite writer *****************/
+
+// reporterExtensions holds the extension of the report files of the
+// built-in reporters, when written per suite. Others get ".txt".
+var reporterExtensions = map[string]string{
+	"xunit":     ".xml",
+	"json":      ".json",
+	"tap":       ".tap",
+	"html":      ".html",
+	"test2json": ".json",
+	"markdown":  ".md",
+}
+
+// perSuiteWriter writes a separate report file for each suite into a
+// directory, named after the suite. Each suite gets its own writer of the
+// chosen reporter, which is done with once the suite is: its report, if
+// it has one, is then written into the file of the suite, which is closed.
+// Since calls are told apart by suite, the log of calls isn't streamed.
+type perSuiteWriter struct {
+	nonStreamingWriter
+	m      sync.Mutex
+	name   string
+	dir    string
+	conf   RunConf
+	suites map[string]*suiteReport
+	failed bool
+}
+
+// suiteReport is the writer and file of a suite being reported.
+type suiteReport struct {
+	writer outputWriter
+	file   *os.File
+}
+
+func newPerSuiteWriter(name, dir string, conf *RunConf) *perSuiteWriter {
+	w := &perSuiteWriter{
+		name:   name,
+		dir:    dir,
+		conf:   *conf,
+		suites: make(map[string]*suiteReport),
+	}
+	w.conf.Stream = false
+	return w
+}
+
+// isDirTarget tells whether the given report target names a directory to
+// write a report per suite into, which it does when it ends with a slash.
+func isDirTarget(target string) bool {
+	return strings.HasSuffix(target, "/") || strings.HasSuffix(target, string(os.PathSeparator))
+}
+
+func (w *perSuiteWriter) WriteCallStarted(label string, c *C) {
+	if writer := w.suiteWriter(c); writer != nil {
+		writer.WriteCallStarted(label, c)
+	}
+}
+
+func (w *perSuiteWriter) WriteCallSuccess(label string, c *C) {
+	if writer := w.suiteWriter(c); writer != nil {
+		writer.WriteCallSuccess(label, c)
+	}
+}
+
+func (w *perSuiteWriter) WriteCallSkipped(label string, c *C) {
+	if writer := w.suiteWriter(c); writer != nil {
+		writer.WriteCallSkipped(label, c)
+	}
+}
+
+func (w *perSuiteWriter) WriteCallError(label string, c *C) {
+	if writer := w.suiteWriter(c); writer != nil {
+		writer.WriteCallError(label, c)
+	}
+}
+
+func (w *perSuiteWriter) WriteCallFailure(label string, c *C) {
+	if writer := w.suiteWriter(c); writer != nil {
+		writer.WriteCallFailure(label, c)
+	}
+}
+
+// suiteWriter returns the writer of the suite of c, creating it and its
+// file on the first call of the suite. It returns nil if the file can't be
+// created.
+func (w *perSuiteWriter) suiteWriter(c *C) outputWriter {
+	suiteName := c.method.suiteName()
+	w.m.Lock()
+	defer w.m.Unlock()
+	if suite, ok := w.suites[suiteName]; ok {
+		return suite.writer
+	}
+	ext, ok := reporterExtensions[w.name]
+	if !ok {
+		ext = ".txt"
+	}
+	err := os.MkdirAll(w.dir, 0755)
+	var file *os.File
+	if err == nil {
+		file, err = os.Create(filepath.Join(w.dir, suiteName+ext))
+	}
+	var writer outputWriter
+	if err == nil {
+		writer, err = getWriter(w.name, file, &w.conf)
+	}
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		w.warn(suiteName, err)
+		w.suites[suiteName] = &suiteReport{}
+		return nil
+	}
+	w.suites[suiteName] = &suiteReport{writer, file}
+	return writer
+}
+
+// WriteSuiteDone finishes the report of the given suite.
+func (w *perSuiteWriter) WriteSuiteDone(suiteName string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if suite, ok := w.suites[suiteName]; ok {
+		delete(w.suites, suiteName)
+		w.finish(suiteName, suite)
+	}
+}
+
+// WriteTrailer finishes the reports of the suites which weren't done.
+func (w *perSuiteWriter) WriteTrailer() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	for suiteName, suite := range w.suites {
+		delete(w.suites, suiteName)
+		w.finish(suiteName, suite)
+	}
+}
+
+// finish writes out the report of a suite and closes its file. It must be
+// called with the lock held.
+func (w *perSuiteWriter) finish(suiteName string, suite *suiteReport) {
+	if suite.writer == nil {
+		return
+	}
+	if sw, ok := suite.writer.(suiteWriter); ok {
+		sw.WriteSuiteDone(suiteName)
+	}
+	if r, ok := suite.writer.(reporter); ok {
+		w.warn(suiteName, writeReport(suite.file, r))
+	}
+	if tw, ok := suite.writer.(trailerWriter); ok {
+		tw.WriteTrailer()
+	}
+	w.warn(suiteName, suite.file.Close())
+}
+
+// warn reports the first error writing reports. It must be called with
+// the lock held.
+func (w *perSuiteWriter) warn(suiteName string, err error) {
+	if err != nil && !w.failed {
+		w.failed = true
+		fmt.Fprintf(os.Stderr, "WARNING: cannot write the report of suite %s: %v\n", suiteName, err)
+	}
+}
@@ -0,0 +1,44 @@
+package check
+
+import (
+	"io/ioutil"
+	"path/filepath"
+)
+
+/*************** Per-suite writer tests *****************/
+type PerSuiteWriterSuite struct{}
+
+var _ = Suite(&PerSuiteWriterSuite{})
+
+func (s *PerSuiteWriterSuite) TestXunitFilePerSuite(c *C) {
+	dir := filepath.Join(c.MkDir(), "reports") + "/"
+	writer := newPerSuiteWriter("xunit", dir, &RunConf{})
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, testName: c.testName}
+	writer.WriteCallStarted("START", call)
+	writer.WriteCallSuccess("PASS", call)
+	writer.WriteSuiteDone("PerSuiteWriterSuite")
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "PerSuiteWriterSuite.xml"))
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Matches, "(?s)<testsuites>\n +<testsuite .*name=\"PerSuiteWriterSuite\" .*tests=\"1\".*")
+	c.Assert(writer.suites, HasLen, 0)
+}
+
+func (s *PerSuiteWriterSuite) TestPlainFinishedByTrailer(c *C) {
+	dir := c.MkDir() + "/"
+	writer := newPerSuiteWriter("plain", dir, &RunConf{Stream: true})
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt}
+	call.logf("Oops")
+	writer.WriteCallFailure("FAIL", call)
+	writer.WriteTrailer()
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "PerSuiteWriterSuite.txt"))
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Matches, "\n-+\nFAIL: .*PerSuiteWriterSuite.TestPlainFinishedByTrailer\n\nOops\n")
+}
+
+func (s *PerSuiteWriterSuite) TestIsDirTarget(c *C) {
+	c.Check(isDirTarget("reports/"), Equals, true)
+	c.Check(isDirTarget("report.xml"), Equals, false)
+	c.Check(isDirTarget(""), Equals, false)
+}
@@ -0,0 +1,141 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************** Progress writer *****************/
+
+// progressWidth is the most columns the progress line takes, so that it
+// fits in a terminal line and may be overwritten in place.
+const progressWidth = 79
+
+// progressWriter wraps the output writer of a streamed run to show a
+// progress line at the bottom of the terminal: how many tests are done out
+// of how many, the time elapsed and which tests are running. The line is
+// cleared before anything else is written and drawn again afterwards.
+type progressWriter struct {
+	outputWriter
+	m       sync.Mutex
+	writer  io.Writer
+	total   int
+	done    int
+	running map[string]bool
+	started time.Time
+	shown   bool
+}
+
+func newProgressWriter(inner outputWriter, writer io.Writer, total int) *progressWriter {
+	return &progressWriter{
+		outputWriter: inner,
+		writer:       writer,
+		total:        total,
+		running:      make(map[string]bool),
+		started:      time.Now(),
+	}
+}
+
+// update clears the progress line, calls f, and then draws the line again.
+func (w *progressWriter) update(f func()) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.clear()
+	f()
+	io.WriteString(w.writer, w.line())
+	w.shown = true
+}
+
+// clear erases the progress line, if it's shown. It must be called with
+// the lock held.
+func (w *progressWriter) clear() {
+	if w.shown {
+		io.WriteString(w.writer, "\r\x1b[K")
+		w.shown = false
+	}
+}
+
+// Finish erases the progress line for good, once the run is over.
+func (w *progressWriter) Finish() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.clear()
+}
+
+// line returns the progress line, without a line break, so that it may
+// be erased.
+func (w *progressWriter) line() string {
+	line := fmt.Sprintf("[%d/%d] %s", w.done, w.total, time.Since(w.started).Round(time.Second))
+	if len(w.running) > 0 {
+		names := make([]string, 0, len(w.running))
+		for name := range w.running {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		line += " running: " + strings.Join(names, ", ")
+	}
+	if runes := []rune(line); len(runes) > progressWidth {
+		line = string(runes[:progressWidth-3]) + "..."
+	}
+	return line
+}
+
+func (w *progressWriter) Write(content []byte) (n int, err error) {
+	w.update(func() { n, err = w.outputWriter.Write(content) })
+	return
+}
+
+func (w *progressWriter) WriteCallStarted(label string, c *C) {
+	w.update(func() {
+		w.outputWriter.WriteCallStarted(label, c)
+		if c.kind == testKd {
+			w.running[c.method.String()] = true
+		}
+	})
+}
+
+func (w *progressWriter) WriteCallSuccess(label string, c *C) {
+	w.update(func() { w.outputWriter.WriteCallSuccess(label, c); w.callDone(c) })
+}
+
+func (w *progressWriter) WriteCallSkipped(label string, c *C) {
+	w.update(func() { w.outputWriter.WriteCallSkipped(label, c); w.callDone(c) })
+}
+
+func (w *progressWriter) WriteCallError(label string, c *C) {
+	w.update(func() { w.outputWriter.WriteCallError(label, c); w.callDone(c) })
+}
+
+func (w *progressWriter) WriteCallFailure(label string, c *C) {
+	w.update(func() { w.outputWriter.WriteCallFailure(label, c); w.callDone(c) })
+}
+
+// callDone counts c as done if it's a test. It must be called with the
+// lock held.
+func (w *progressWriter) callDone(c *C) {
+	if c.kind == testKd {
+		delete(w.running, c.method.String())
+		w.done++
+	}
+}
+
+func (w *progressWriter) WriteSuiteDone(suiteName string) {
+	if sw, ok := w.outputWriter.(suiteWriter); ok {
+		w.update(func() { sw.WriteSuiteDone(suiteName) })
+	}
+}
+
+// isTerminal tells whether output is a terminal.
+func isTerminal(output io.Writer) bool {
+	file, ok := output.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
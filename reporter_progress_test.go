@@ -0,0 +1,59 @@
+package check
+
+import (
+	"bytes"
+	"strings"
+)
+
+/*************** Progress writer tests *****************/
+type ProgressWriterSuite struct{}
+
+var _ = Suite(&ProgressWriterSuite{})
+
+func (s *ProgressWriterSuite) TestProgressLine(c *C) {
+	output := &bytes.Buffer{}
+	writer := newProgressWriter(plainWriterFor(output, &RunConf{Stream: true}), output, 2)
+	call := &C{method: c.method, kind: testKd, logb: &logger{}}
+
+	writer.WriteCallStarted("START", call)
+	c.Assert(output.String(), Matches,
+		"START: .*ProgressWriterSuite.TestProgressLine\n"+
+			`\[0/2\] 0s running: ProgressWriterSuite.TestProgressLine`)
+	output.Reset()
+
+	writer.WriteCallSuccess("PASS", call)
+	c.Assert(output.String(), Matches,
+		"\r\x1b\\[K"+
+			"PASS: .*ProgressWriterSuite.TestProgressLine\t.*\n\n"+
+			`\[1/2\] 0s`)
+	output.Reset()
+
+	writer.Finish()
+	c.Assert(output.String(), Equals, "\r\x1b[K")
+	output.Reset()
+	writer.Finish()
+	c.Assert(output.String(), Equals, "")
+}
+
+func (s *ProgressWriterSuite) TestFixturesAreNotCounted(c *C) {
+	output := &bytes.Buffer{}
+	writer := newProgressWriter(plainWriterFor(&bytes.Buffer{}, &RunConf{Stream: true}), output, 1)
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	writer.WriteCallStarted("START", fixture)
+	writer.WriteCallSuccess("PASS", fixture)
+	c.Assert(output.String(), Equals, "[0/1] 0s\r\x1b[K[0/1] 0s")
+}
+
+func (s *ProgressWriterSuite) TestLongLinesAreTruncated(c *C) {
+	writer := newProgressWriter(plainWriterFor(&bytes.Buffer{}, &RunConf{}), &bytes.Buffer{}, 1)
+	for i := 0; i < 10; i++ {
+		writer.running[strings.Repeat("x", i+5)] = true
+	}
+	line := writer.line()
+	c.Assert(line, HasLen, progressWidth)
+	c.Assert(strings.HasSuffix(line, "..."), Equals, true)
+}
+
+func (s *ProgressWriterSuite) TestIsTerminal(c *C) {
+	c.Assert(isTerminal(&bytes.Buffer{}), Equals, false)
+}
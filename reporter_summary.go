@@ -0,0 +1,94 @@
+package check
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************** Summary writer *****************/
+
+// runSummary is the content of the file written with -check.summary.
+type runSummary struct {
+	Passed    bool               `json:"passed"`
+	Error     string             `json:"error,omitempty"`
+	Total     int                `json:"total"`
+	Counts    map[string]int     `json:"counts"`
+	Failed    []string           `json:"failed"`
+	Duration  float64            `json:"duration"`
+	Durations map[string]float64 `json:"durations"`
+	WorkDirs  []string           `json:"workdirs,omitempty"`
+}
+
+// summaryWriter records the outcome of each test, regardless of the
+// reporters in use, to summarize the run once it's over. It doesn't
+// write anything itself.
+type summaryWriter struct {
+	nonStreamingWriter
+	m         sync.Mutex
+	counts    map[string]int
+	failed    []string
+	durations map[string]float64
+	started   time.Time
+}
+
+func newSummaryWriter() *summaryWriter {
+	return &summaryWriter{
+		counts:    make(map[string]int),
+		durations: make(map[string]float64),
+		started:   time.Now(),
+	}
+}
+
+func (w *summaryWriter) WriteCallSuccess(label string, c *C) { w.addCall(c) }
+func (w *summaryWriter) WriteCallSkipped(label string, c *C) { w.addCall(c) }
+func (w *summaryWriter) WriteCallError(label string, c *C)   { w.addCall(c) }
+func (w *summaryWriter) WriteCallFailure(label string, c *C) { w.addCall(c) }
+
+func (w *summaryWriter) addCall(c *C) {
+	if c.kind != testKd {
+		return
+	}
+	name := c.method.String()
+	status := testStatus(c)
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.counts[status]++
+	w.durations[name] = c.duration.Seconds()
+	switch status {
+	case TestFailed, TestPanicked, TestFixturePanicked, TestMissed:
+		w.failed = append(w.failed, name)
+	}
+}
+
+// summary returns the JSON summary of the run which ended with result.
+// Failed tests, listed by name, include those which panicked or were
+// missed, so that they're the set of tests to run again.
+func (w *summaryWriter) summary(result *Result) ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	summary := runSummary{
+		Passed:    result.Passed(),
+		Counts:    w.counts,
+		Failed:    append([]string{}, w.failed...),
+		Duration:  time.Since(w.started).Seconds(),
+		Durations: w.durations,
+	}
+	if result.RunError != nil {
+		summary.Error = result.RunError.Error()
+	}
+	for _, count := range w.counts {
+		summary.Total += count
+	}
+	sort.Strings(summary.Failed)
+	if result.WorkDir != "" {
+		summary.WorkDirs = strings.Split(result.WorkDir, ":")
+	}
+	report, err := json.Marshal(&summary)
+	if err != nil {
+		return nil, err
+	}
+	return append(report, '\n'), nil
+}
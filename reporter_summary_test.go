@@ -0,0 +1,39 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+/*************** Summary writer tests *****************/
+type SummaryWriterSuite struct{}
+
+var _ = Suite(&SummaryWriterSuite{})
+
+func (s *SummaryWriterSuite) TestSummary(c *C) {
+	writer := newSummaryWriter()
+	passed := &C{method: c.method, kind: testKd, logb: &logger{}}
+	passed.duration = 2 * time.Second
+	writer.WriteCallSuccess("PASS", passed)
+	writer.WriteCallFailure("FAIL", &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt})
+	writer.WriteCallError("PANIC", &C{method: c.method, kind: fixtureKd, logb: &logger{}, status: panickedSt})
+
+	content, err := writer.summary(&Result{Failed: 1, WorkDir: "/tmp/a:/tmp/b"})
+	c.Assert(err, IsNil)
+	var summary runSummary
+	c.Assert(json.Unmarshal(content, &summary), IsNil)
+	c.Check(summary.Passed, Equals, false)
+	c.Check(summary.Error, Equals, "")
+	c.Check(summary.Total, Equals, 2)
+	c.Check(summary.Counts, DeepEquals, map[string]int{"passed": 1, "failed": 1})
+	c.Check(summary.Failed, DeepEquals, []string{"SummaryWriterSuite.TestSummary"})
+	c.Check(summary.Durations, HasLen, 1)
+	c.Check(summary.WorkDirs, DeepEquals, []string{"/tmp/a", "/tmp/b"})
+}
+
+func (s *SummaryWriterSuite) TestEmptyRun(c *C) {
+	content, err := newSummaryWriter().summary(&Result{RunError: errors.New("bad order file")})
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Matches, `\{"passed":false,"error":"bad order file","total":0,"counts":\{\},"failed":\[\],"duration":[0-9.e-]+,"durations":\{\}\}`+"\n")
+}
@@ -0,0 +1,120 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+/*************** TAP writer *****************/
+
+// tapWriter reports tests in the Test Anything Protocol, version 13. Each
+// test gets a test line as soon as it's done, followed by a YAML
+// diagnostic block holding its log if it didn't pass. Since the number of
+// tests isn't known upfront, the plan comes last, from GetReport. Problems
+// in fixtures, which aren't tests themselves, are reported as comments.
+type tapWriter struct {
+	nonStreamingWriter
+	m       sync.Mutex
+	writer  io.Writer
+	dedup   bool
+	started bool
+	count   int
+}
+
+func newTapWriter(writer io.Writer, conf *RunConf) *tapWriter {
+	return &tapWriter{writer: writer, dedup: conf.DedupOutput}
+}
+
+func (w *tapWriter) GetReport() ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return []byte(w.header() + fmt.Sprintf("1..%d\n", w.count)), nil
+}
+
+// header returns the version line if it wasn't written yet.
+func (w *tapWriter) header() string {
+	if w.started {
+		return ""
+	}
+	w.started = true
+	return "TAP version 13\n"
+}
+
+func (w *tapWriter) WriteCallSuccess(label string, c *C) { w.writeCall(label, c) }
+func (w *tapWriter) WriteCallSkipped(label string, c *C) { w.writeCall(label, c) }
+func (w *tapWriter) WriteCallError(label string, c *C)   { w.writeCall(label, c) }
+func (w *tapWriter) WriteCallFailure(label string, c *C) { w.writeCall(label, c) }
+
+func (w *tapWriter) writeCall(label string, c *C) {
+	if !reportableCall(c) {
+		return
+	}
+	log := c.logb.String()
+	if w.dedup {
+		log = dedupLog(log)
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	out := w.header()
+	if c.kind != testKd {
+		out += fmt.Sprintf("# %s in %s\n", label, c.method.String())
+		if log = strings.TrimRight(log, "\n"); log != "" {
+			for _, line := range strings.Split(log, "\n") {
+				out += "# " + line + "\n"
+			}
+		}
+		io.WriteString(w.writer, out)
+		return
+	}
+	w.count++
+	ok, directive := tapOutcome(c)
+	out += fmt.Sprintf("%s %d - %s", ok, w.count, c.method.String())
+	if directive != "" {
+		out += " # " + tapEscape(directive)
+	}
+	out += "\n"
+	if ok == "not ok" || c.status == quarantinedSt {
+		out += tapDiagnostic(testStatus(c), log)
+	}
+	io.WriteString(w.writer, out)
+}
+
+// tapOutcome returns whether the test is "ok" or "not ok" according to
+// TAP, and the directive explaining it, if any. Tests which failed as
+// expected or while quarantined are "not ok" with a TODO directive, which
+// TAP consumers don't count as failures.
+func tapOutcome(c *C) (ok, directive string) {
+	switch c.status {
+	case succeededSt:
+		if c.mustFail {
+			return "not ok", "TODO expected failure: " + c.reason
+		} else if c.quarantined {
+			return "ok", "TODO quarantined: " + c.reason
+		}
+		return "ok", ""
+	case skippedSt:
+		return "ok", "SKIP " + c.reason
+	case quarantinedSt:
+		return "not ok", "TODO quarantined: " + c.reason
+	}
+	return "not ok", ""
+}
+
+// tapEscape escapes the characters with special meaning in test lines.
+func tapEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "#", `\#`, "\n", " ").Replace(s)
+}
+
+// tapDiagnostic returns a YAML block describing a test which didn't pass.
+func tapDiagnostic(status, log string) string {
+	out := "  ---\n  status: " + status + "\n"
+	if log = strings.TrimRight(log, "\n"); log != "" {
+		out += "  message: |\n"
+		for _, line := range strings.Split(log, "\n") {
+			out += "    " + line + "\n"
+		}
+	}
+	return out + "  ...\n"
+}
@@ -0,0 +1,70 @@
+package check
+
+import (
+	"bytes"
+)
+
+/*************** TAP writer tests *****************/
+type TapWriterSuite struct {
+	output *bytes.Buffer
+	writer *tapWriter
+}
+
+var _ = Suite(&TapWriterSuite{})
+
+func (s *TapWriterSuite) SetUpTest(c *C) {
+	s.output = &bytes.Buffer{}
+	s.writer = newTapWriter(s.output, &RunConf{})
+}
+
+func (s *TapWriterSuite) TestReport(c *C) {
+	pass := &C{method: c.method, kind: testKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", pass)
+
+	fail := &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt}
+	fail.logb.Write([]byte("foo_test.go:12:\n    c.Check(1, Equals, 2)\n"))
+	s.writer.WriteCallFailure("FAIL", fail)
+
+	skip := &C{method: c.method, kind: testKd, logb: &logger{}, status: skippedSt, reason: "not #1"}
+	s.writer.WriteCallSkipped("SKIP", skip)
+
+	expected := &C{method: c.method, kind: testKd, logb: &logger{}, mustFail: true, reason: "bug"}
+	s.writer.WriteCallSuccess("FAIL EXPECTED", expected)
+
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(s.output.String()+string(report), Equals, "TAP version 13\n"+
+		"ok 1 - TapWriterSuite.TestReport\n"+
+		"not ok 2 - TapWriterSuite.TestReport\n"+
+		"  ---\n"+
+		"  status: failed\n"+
+		"  message: |\n"+
+		"    foo_test.go:12:\n"+
+		"        c.Check(1, Equals, 2)\n"+
+		"  ...\n"+
+		"ok 3 - TapWriterSuite.TestReport # SKIP not \\#1\n"+
+		"not ok 4 - TapWriterSuite.TestReport # TODO expected failure: bug\n"+
+		"  ---\n"+
+		"  status: expected failure\n"+
+		"  ...\n"+
+		"1..4\n")
+}
+
+func (s *TapWriterSuite) TestFixtureProblem(c *C) {
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", fixture)
+	c.Assert(s.output.String(), Equals, "")
+
+	fixture.status = panickedSt
+	fixture.logb.Write([]byte("... Panic: boom\n"))
+	s.writer.WriteCallError("PANIC", fixture)
+	c.Assert(s.output.String(), Equals, "TAP version 13\n"+
+		"# PANIC in TapWriterSuite.TestFixtureProblem\n"+
+		"# ... Panic: boom\n")
+}
+
+func (s *TapWriterSuite) TestEmptyRun(c *C) {
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(string(report), Equals, "TAP version 13\n1..0\n")
+}
@@ -0,0 +1,115 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************** TeamCity writer *****************/
+
+// teamCityWriter reports tests as TeamCity service messages while they run,
+// so that TeamCity can show the progress of each of them. Messages about
+// tests carry the test name as their flow id, so that those of tests
+// running concurrently aren't mixed up. Problems in fixtures, which aren't
+// tests themselves, are reported as build log errors.
+type teamCityWriter struct {
+	m      sync.Mutex
+	writer io.Writer
+	dedup  bool
+	suites map[string]bool
+}
+
+func newTeamCityWriter(writer io.Writer, conf *RunConf) *teamCityWriter {
+	return &teamCityWriter{
+		writer: writer,
+		dedup:  conf.DedupOutput,
+		suites: make(map[string]bool),
+	}
+}
+
+func (w *teamCityWriter) Write(content []byte) (n int, err error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.writer.Write(content)
+}
+
+func (w *teamCityWriter) WriteCallStarted(label string, c *C) {
+	suite := c.method.suiteName()
+	w.m.Lock()
+	defer w.m.Unlock()
+	if !w.suites[suite] {
+		w.suites[suite] = true
+		w.message("testSuiteStarted", "name", suite, "flowId", suite)
+	}
+	if c.kind == testKd {
+		w.message("testStarted", "name", c.method.String(), "flowId", c.method.String())
+	}
+}
+
+func (w *teamCityWriter) WriteCallSuccess(label string, c *C) { w.writeCall(label, c) }
+func (w *teamCityWriter) WriteCallSkipped(label string, c *C) { w.writeCall(label, c) }
+func (w *teamCityWriter) WriteCallError(label string, c *C)   { w.writeCall(label, c) }
+func (w *teamCityWriter) WriteCallFailure(label string, c *C) { w.writeCall(label, c) }
+
+func (w *teamCityWriter) StreamEnabled() bool { return false }
+
+// WriteSuiteDone closes the block of messages of the suite.
+func (w *teamCityWriter) WriteSuiteDone(suiteName string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.suites[suiteName] {
+		delete(w.suites, suiteName)
+		w.message("testSuiteFinished", "name", suiteName, "flowId", suiteName)
+	}
+}
+
+func (w *teamCityWriter) writeCall(label string, c *C) {
+	if !reportableCall(c) {
+		return
+	}
+	log := c.logb.String()
+	if w.dedup {
+		log = dedupLog(log)
+	}
+	name := c.method.String()
+	w.m.Lock()
+	defer w.m.Unlock()
+	if c.kind != testKd {
+		w.message("message", "text", label+" in "+name, "errorDetails", log, "status", "ERROR")
+		return
+	}
+	switch c.status {
+	case succeededSt:
+	case skippedSt:
+		w.message("testIgnored", "name", name, "message", c.reason, "flowId", name)
+	case quarantinedSt:
+		w.message("testIgnored", "name", name, "message", "Quarantined: "+c.reason, "flowId", name)
+	case missedSt:
+		w.message("testFailed", "name", name, "message", "MISS", "details", log, "flowId", name)
+	default:
+		w.message("testFailed", "name", name, "message", label, "details", log, "flowId", name)
+	}
+	w.message("testFinished", "name", name, "duration", fmt.Sprint(int64(c.duration/time.Millisecond)), "flowId", name)
+}
+
+// message writes a service message with the given attribute names and
+// values. It must be called with the lock held.
+func (w *teamCityWriter) message(kind string, attrs ...string) {
+	out := "##teamcity[" + kind
+	for i := 0; i+1 < len(attrs); i += 2 {
+		out += fmt.Sprintf(" %s='%s'", attrs[i], teamCityEscape(attrs[i+1]))
+	}
+	io.WriteString(w.writer, out+"]\n")
+}
+
+var teamCityEscaper = strings.NewReplacer(
+	"|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]")
+
+// teamCityEscape escapes the characters with special meaning in the values
+// of service message attributes.
+func teamCityEscape(s string) string {
+	return teamCityEscaper.Replace(s)
+}
@@ -0,0 +1,57 @@
+package check
+
+import (
+	"bytes"
+	"time"
+)
+
+/*************** TeamCity writer tests *****************/
+type TeamCityWriterSuite struct {
+	output *bytes.Buffer
+	writer *teamCityWriter
+}
+
+var _ = Suite(&TeamCityWriterSuite{})
+
+func (s *TeamCityWriterSuite) SetUpTest(c *C) {
+	s.output = &bytes.Buffer{}
+	s.writer = newTeamCityWriter(s.output, &RunConf{})
+}
+
+func (s *TeamCityWriterSuite) TestMessages(c *C) {
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt}
+	call.duration = 1500 * time.Millisecond
+	call.logb.Write([]byte("foo_test.go:12:\n... obtained 'a' [1]\n"))
+	s.writer.WriteCallStarted("START", call)
+	s.writer.WriteCallFailure("FAIL", call)
+
+	call.status = skippedSt
+	call.reason = "slow"
+	s.writer.WriteCallStarted("START", call)
+	s.writer.WriteCallSkipped("SKIP", call)
+	s.writer.WriteSuiteDone("TeamCityWriterSuite")
+
+	c.Assert(s.output.String(), Equals, ""+
+		"##teamcity[testSuiteStarted name='TeamCityWriterSuite' flowId='TeamCityWriterSuite']\n"+
+		"##teamcity[testStarted name='TeamCityWriterSuite.TestMessages' flowId='TeamCityWriterSuite.TestMessages']\n"+
+		"##teamcity[testFailed name='TeamCityWriterSuite.TestMessages' message='FAIL' "+
+		"details='foo_test.go:12:|n... obtained |'a|' |[1|]|n' flowId='TeamCityWriterSuite.TestMessages']\n"+
+		"##teamcity[testFinished name='TeamCityWriterSuite.TestMessages' duration='1500' flowId='TeamCityWriterSuite.TestMessages']\n"+
+		"##teamcity[testStarted name='TeamCityWriterSuite.TestMessages' flowId='TeamCityWriterSuite.TestMessages']\n"+
+		"##teamcity[testIgnored name='TeamCityWriterSuite.TestMessages' message='slow' flowId='TeamCityWriterSuite.TestMessages']\n"+
+		"##teamcity[testFinished name='TeamCityWriterSuite.TestMessages' duration='1500' flowId='TeamCityWriterSuite.TestMessages']\n"+
+		"##teamcity[testSuiteFinished name='TeamCityWriterSuite' flowId='TeamCityWriterSuite']\n")
+}
+
+func (s *TeamCityWriterSuite) TestFixtureProblem(c *C) {
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", fixture)
+	c.Assert(s.output.String(), Equals, "")
+
+	fixture.status = panickedSt
+	fixture.logb.Write([]byte("... Panic: boom\n"))
+	s.writer.WriteCallError("PANIC", fixture)
+	c.Assert(s.output.String(), Equals,
+		"##teamcity[message text='PANIC in TeamCityWriterSuite.TestFixtureProblem' "+
+			"errorDetails='... Panic: boom|n' status='ERROR']\n")
+}
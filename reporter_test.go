@@ -1,5 +1,12 @@
 package check
 
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
 /*************** xUnit writer tests *****************/
 type XUnitTestSuite struct {
 	writer *xunitWriter
@@ -33,7 +40,7 @@ func (s *XUnitTestSuite) TestSkip(c *C) {
 	match := "<testsuites>\n" +
 		" +<testsuite .*name=\"XUnitTestSuite\" .*tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"1\">\n" +
 		" +<testcase name=\"XUnitTestSuite\\.TestSkip\" classname=\"XUnitTestSuite\" .*file=\"[^\"]*reporter_test.go\".*>\n" +
-		" +<skipped>true</skipped>\n" +
+		" +<skipped></skipped>\n" +
 		" +</testcase>\n" +
 		" +</testsuite>\n" +
 		"</testsuites>"
@@ -74,6 +81,113 @@ func (s *XUnitTestSuite) TestError(c *C) {
 
 }
 
+func (s *XUnitTestSuite) TestMeta(c *C) {
+	c.SetMeta("version", "1.0")
+	c.SetMeta("dataset", "small & quick")
+	s.writer.WriteCallSuccess("PASS", c)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+
+	match := "<testsuites>\n" +
+		" +<testsuite .*name=\"XUnitTestSuite\" .*tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\">\n" +
+		" +<testcase name=\"XUnitTestSuite\\.TestMeta\" classname=\"XUnitTestSuite\" .*file=\"[^\"]*reporter_test.go\".*>\n" +
+		" +<properties>\n" +
+		" +<property name=\"dataset\" value=\"small &amp; quick\"></property>\n" +
+		" +<property name=\"version\" value=\"1.0\"></property>\n" +
+		" +</properties>\n" +
+		" +</testcase>\n" +
+		" +</testsuite>\n" +
+		"</testsuites>"
+
+	c.Assert(string(report), Matches, match)
+}
+
+func (s *XUnitTestSuite) TestSkipReason(c *C) {
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, testName: c.testName,
+		status: skippedSt, reason: "needs a <db>"}
+	s.writer.WriteCallSkipped("SKIP", call)
+	call = &C{method: c.method, kind: testKd, logb: &logger{}, testName: c.testName,
+		status: quarantinedSt, reason: "flaky"}
+	s.writer.WriteCallSkipped("QUARANTINED", call)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Check(string(report), Matches, `(?s).*<skipped message="needs a &lt;db&gt;"></skipped>.*`)
+	c.Check(string(report), Matches, `(?s).*<skipped message="Quarantined: flaky"></skipped>.*`)
+}
+
+func (s *XUnitTestSuite) TestErrorTypes(c *C) {
+	for _, call := range []*C{
+		{method: c.method, kind: testKd, logb: &logger{}, status: panickedSt},
+		{method: c.method, kind: testKd, logb: &logger{}, status: fixturePanickedSt},
+		{method: c.method, kind: fixtureKd, logb: &logger{}, status: panickedSt},
+	} {
+		s.writer.WriteCallError("PANIC", call)
+	}
+	s.writer.WriteCallFailure("FAIL", &C{method: c.method, kind: testKd, logb: &logger{}, status: failedSt})
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+
+	match := "(?s).*<error message=\"PANIC\" type=\"go.panic\">.*" +
+		"<error message=\"PANIC\" type=\"go.fixture-panic\">.*" +
+		"<error message=\"PANIC\" type=\"go.fixture-panic\">.*" +
+		"<failure message=\"FAIL\" type=\"go.failure\">.*"
+	c.Assert(string(report), Matches, match)
+}
+
+func (s *XUnitTestSuite) TestSystemOut(c *C) {
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, testName: c.testName}
+	call.Logf("Connecting to <%s>", "db")
+	s.writer.WriteCallSuccess("PASS", call)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+
+	match := "(?s).*<testcase name=\"XUnitTestSuite\\.TestSystemOut\" .*>\n" +
+		" +<system-out>Connecting to &lt;db&gt;&#xA;</system-out>\n" +
+		" +</testcase>\n.*"
+	c.Assert(string(report), Matches, match)
+}
+
+func (s *XUnitTestSuite) TestTimes(c *C) {
+	start := time.Now().Add(-2 * time.Second)
+	call := &C{method: c.method, kind: testKd, logb: &logger{}, testName: c.testName,
+		startTime: start, stopTime: start.Add(1500 * time.Millisecond)}
+	s.writer.WriteCallStarted("PASS", call)
+	s.writer.WriteCallSuccess("PASS", call)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Check(string(report), Matches, `(?s).*<testsuite .*time="1.5" timestamp="`+start.Format("2006-01-02T15:04:05")+`".*`)
+	c.Check(string(report), Matches, `(?s).*<testcase name="XUnitTestSuite\.TestTimes" .*time="1.5".*`)
+
+	// The suite lasts until it's done, after its last fixture.
+	s.writer.WriteSuiteDone("XUnitTestSuite")
+	report, err = s.writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Check(string(report), Matches, `(?s).*<testsuite .*time="2\.[0-9]+" timestamp=.*`)
+}
+
+func (s *XUnitTestSuite) TestTimesWithoutExponent(c *C) {
+	attr, err := xunitSeconds(3.5e-05).MarshalXMLAttr(xml.Name{Local: "time"})
+	c.Assert(err, IsNil)
+	c.Check(attr.Value, Equals, "0.000035")
+}
+
+func (s *XUnitTestSuite) TestReportProperties(c *C) {
+	defer SetReportProperty("build", "42")()
+	s.writer.WriteCallSuccess("PASS", c)
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+
+	match := "<testsuites>\n" +
+		" +<testsuite .*name=\"XUnitTestSuite\" .*>\n" +
+		" +<properties>\n" +
+		" +<property name=\"build\" value=\"42\"></property>\n" +
+		" +</properties>\n" +
+		" +<testcase name=\"XUnitTestSuite\\.TestReportProperties\" .*</testcase>\n" +
+		" +</testsuite>\n" +
+		"</testsuites>"
+	c.Assert(string(report), Matches, match)
+}
+
 func (s *XUnitTestSuite) TestCombine(c *C) {
 	s.writer.WriteCallError("ERR", c)
 	s.writer.WriteCallFailure("FAIL", c)
@@ -104,7 +218,7 @@ func (s *XUnitTestSuite) TestCombine(c *C) {
 		" +</testcase>\n" +
 
 		" +<testcase name=\"XUnitTestSuite\\.TestCombine\" classname=\"XUnitTestSuite\" .*file=\"[^\"]*reporter_test.go\".*>\n" +
-		" +<skipped>true</skipped>\n" +
+		" +<skipped></skipped>\n" +
 		" +</testcase>\n" +
 
 		" +</testsuite>\n" +
@@ -112,3 +226,254 @@ func (s *XUnitTestSuite) TestCombine(c *C) {
 
 	c.Assert(string(report), Matches, match)
 }
+
+/*************** Log de-duplication tests *****************/
+type DedupLogSuite struct{}
+
+var _ = Suite(&DedupLogSuite{})
+
+func (s *DedupLogSuite) TestSingleRecordsUntouched(c *C) {
+	log := "one\ntwo\none\n"
+	c.Assert(dedupLog(log), Equals, log)
+}
+
+func (s *DedupLogSuite) TestRepeatedLines(c *C) {
+	log := "start\nretrying\nretrying\nretrying\ndone"
+	c.Assert(dedupLog(log), Equals, "start\nretrying (repeated 3 times)\ndone")
+}
+
+func (s *DedupLogSuite) TestRepeatedProblems(c *C) {
+	problem := "foo_test.go:12:\n    c.Check(v, Equals, 1)\n... obtained int = 2\n... expected int = 1\n\n"
+	other := "foo_test.go:12:\n    c.Check(v, Equals, 1)\n... obtained int = 3\n... expected int = 1\n\n"
+	log := problem + problem + other + other + other + "tail\n"
+	c.Assert(dedupLog(log), Equals,
+		"foo_test.go:12:\n    c.Check(v, Equals, 1)\n... obtained int = 2\n... expected int = 1\n"+
+			"... (repeated 2 times)\n\n"+
+			"foo_test.go:12:\n    c.Check(v, Equals, 1)\n... obtained int = 3\n... expected int = 1\n"+
+			"... (repeated 3 times)\n\n"+
+			"tail\n")
+}
+
+/*************** Failures at end tests *****************/
+type FailuresAtEndSuite struct{}
+
+var _ = Suite(&FailuresAtEndSuite{})
+
+func (s *FailuresAtEndSuite) TestTrailer(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{FailuresAtEnd: true})
+	c.Log("Expected failure!")
+	writer.WriteCallFailure("FAIL", c)
+	writer.WriteCallSkipped("SKIP", c)
+	inline := output.String()
+	output.Reset()
+
+	writer.WriteTrailer()
+	c.Assert(output.String(), Equals, "\n"+strings.Repeat("=", 70)+
+		"\n1 PROBLEMS REPORTED DURING THE RUN:\n"+inline)
+	c.Assert(inline, Matches, "\n-+\nFAIL: .*reporter_test.go:[0-9]+: FailuresAtEndSuite.TestTrailer\n\nExpected failure!\n")
+}
+
+func (s *FailuresAtEndSuite) TestNothingToRepeat(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{FailuresAtEnd: true})
+	writer.WriteCallSuccess("PASS", c)
+	writer.WriteTrailer()
+	c.Assert(output.String(), Equals, "")
+}
+
+func (s *FailuresAtEndSuite) TestIgnoredWhenStreaming(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{FailuresAtEnd: true, Stream: true})
+	writer.WriteCallFailure("FAIL", c)
+	output.Reset()
+	writer.WriteTrailer()
+	c.Assert(output.String(), Equals, "")
+}
+
+/*************** Color tests *****************/
+type ColorSuite struct{}
+
+var _ = Suite(&ColorSuite{})
+
+func (s *ColorSuite) TestColoredLabels(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{Verbose: true, Color: true})
+	writer.WriteCallSuccess("PASS", c)
+	writer.WriteCallSkipped("SKIP", c)
+	writer.WriteCallFailure("FAIL", c)
+	c.Assert(output.String(), Matches, "\x1b\\[32mPASS\x1b\\[0m: .*\n"+
+		"\x1b\\[33mSKIP\x1b\\[0m: .*\n"+
+		"\n-+\n\x1b\\[31mFAIL\x1b\\[0m: .*\n\n")
+}
+
+func (s *ColorSuite) TestNoColorsByDefault(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{Verbose: true})
+	writer.WriteCallSuccess("PASS", c)
+	c.Assert(output.String(), Matches, "PASS: .*\n")
+}
+
+/*************** Slowest tests tests *****************/
+type SlowestSuite struct{}
+
+var _ = Suite(&SlowestSuite{})
+
+func timedCall(c *C, kind funcKind, d time.Duration) *C {
+	call := &C{method: c.method, kind: kind, logb: &logger{}}
+	call.duration = d
+	return call
+}
+
+func (s *SlowestSuite) TestKeepsSlowest(c *C) {
+	slowest := newSlowestTests(2)
+	for _, d := range []time.Duration{3, 1, 5, 4} {
+		slowest.add(timedCall(c, testKd, d*time.Second))
+	}
+	slowest.add(timedCall(c, fixtureKd, 10*time.Second))
+	name := "SlowestSuite.TestKeepsSlowest"
+	c.Assert(slowest.list(), DeepEquals, []timedTest{{name, 5 * time.Second}, {name, 4 * time.Second}})
+}
+
+func (s *SlowestSuite) TestNilDoesNothing(c *C) {
+	var slowest *slowestTests
+	slowest.add(timedCall(c, testKd, time.Second))
+	c.Assert(slowest.list(), IsNil)
+}
+
+func (s *SlowestSuite) TestPlainTrailer(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{Slowest: 3})
+	writer.WriteCallSuccess("PASS", timedCall(c, testKd, 1500*time.Millisecond))
+	writer.WriteCallSuccess("PASS", timedCall(c, testKd, 250*time.Millisecond))
+	writer.WriteTrailer()
+	c.Assert(output.String(), Equals, "\n2 SLOWEST TESTS:\n"+
+		"        1.5s  SlowestSuite.TestPlainTrailer\n"+
+		"       250ms  SlowestSuite.TestPlainTrailer\n")
+}
+
+func (s *SlowestSuite) TestNotRequested(c *C) {
+	output := &bytes.Buffer{}
+	writer := plainWriterFor(output, &RunConf{})
+	writer.WriteCallSuccess("PASS", timedCall(c, testKd, time.Second))
+	writer.WriteTrailer()
+	c.Assert(output.String(), Equals, "")
+}
+
+/*************** Quiet writer tests *****************/
+type QuietWriterSuite struct{}
+
+var _ = Suite(&QuietWriterSuite{})
+
+func (s *QuietWriterSuite) TestOnlyProblems(c *C) {
+	output := &bytes.Buffer{}
+	writer := quietWriterFor(output, &RunConf{Verbose: true, Stream: true, VerboseOnFailure: true})
+	c.Assert(writer.StreamEnabled(), Equals, false)
+
+	writer.WriteCallStarted("START", c)
+	writer.WriteCallSuccess("PASS", c)
+	writer.WriteCallSkipped("SKIP", c)
+	quarantined := &C{method: c.method, kind: testKd, logb: &logger{}, status: quarantinedSt, quarantined: true}
+	writer.WriteCallSkipped("QUARANTINED", quarantined)
+	writer.WriteCallSuccess("QUARANTINE PASSED", &C{method: c.method, kind: testKd, logb: &logger{}, quarantined: true})
+	c.Assert(output.String(), Equals, "")
+
+	c.Log("Expected failure!")
+	writer.WriteCallFailure("FAIL", c)
+	writer.WriteCallError("PANIC", c)
+	c.Assert(output.String(), Matches, "\n-+\nFAIL: .*QuietWriterSuite.TestOnlyProblems\n\nExpected failure!\n"+
+		"\n-+\nPANIC: .*QuietWriterSuite.TestOnlyProblems\n\n")
+}
+
+/*************** Multi writer tests *****************/
+type MultiWriterSuite struct{}
+
+var _ = Suite(&MultiWriterSuite{})
+
+func (s *MultiWriterSuite) TestEachWriterGetsTheLog(c *C) {
+	output := &bytes.Buffer{}
+	plain := plainWriterFor(output, &RunConf{})
+	xunit := newXunitWriter(nil, false)
+	writer := newMultiWriter(plain, xunit)
+
+	c.Log("Expected failure!")
+	writer.WriteCallStarted("START", c)
+	writer.WriteCallFailure("FAIL", c)
+
+	c.Assert(output.String(), Matches, "(?s).*FAIL: .*MultiWriterSuite.TestEachWriterGetsTheLog\n\nExpected failure!\n")
+	report, err := xunit.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(string(report), Matches, `(?s).*<failure message="FAIL" type="go.failure">Expected failure!</failure>.*`)
+}
+
+func (s *MultiWriterSuite) TestOptionalInterfaces(c *C) {
+	output := &bytes.Buffer{}
+	plain := plainWriterFor(output, &RunConf{FailuresAtEnd: true, VerboseOnFailure: true})
+	writer := newMultiWriter(newXunitWriter(nil, false), plain)
+	c.Assert(writer.StreamEnabled(), Equals, false)
+
+	writer.WriteCallFailure("FAIL", c)
+	c.Assert(output.String(), Equals, "")
+	writer.WriteSuiteDone("MultiWriterSuite")
+	c.Assert(output.String(), Matches, "(?s).*FAIL: .*")
+	output.Reset()
+	writer.WriteTrailer()
+	c.Assert(output.String(), Matches, "(?s).*1 PROBLEMS REPORTED DURING THE RUN:\n.*")
+}
+
+/*************** Streamed xUnit writer tests *****************/
+type XUnitStreamSuite struct{}
+
+var _ = Suite(&XUnitStreamSuite{})
+
+func (s *XUnitStreamSuite) TestSuiteWrittenWhenDone(c *C) {
+	report := &bytes.Buffer{}
+	writer := xunitWriterFor(report, report, &RunConf{StreamXunit: true})
+	writer.WriteCallSuccess("PASS", c)
+	writer.WriteCallFailure("FAIL", c)
+	c.Assert(report.String(), Equals, "")
+
+	writer.WriteSuiteDone("XUnitStreamSuite")
+	c.Assert(report.String(), Matches, "<testsuites>\n"+
+		"    <testsuite .*name=\"XUnitStreamSuite\" .*tests=\"2\" failures=\"1\" errors=\"0\" skipped=\"0\">\n"+
+		"        <testcase name=\"XUnitStreamSuite\\.TestSuiteWrittenWhenDone\" .*</testcase>\n"+
+		"        <testcase name=\"XUnitStreamSuite\\.TestSuiteWrittenWhenDone\" .*>\n"+
+		"            <failure message=\"FAIL\" type=\"go.failure\"></failure>\n"+
+		"        </testcase>\n"+
+		"    </testsuite>\n")
+	c.Assert(writer.suites, HasLen, 0)
+
+	remainder, err := writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(string(remainder), Equals, "</testsuites>")
+}
+
+func (s *XUnitStreamSuite) TestRemainderHasUnfinishedSuites(c *C) {
+	report := &bytes.Buffer{}
+	writer := xunitWriterFor(nil, report, &RunConf{StreamXunit: true})
+	writer.WriteCallSuccess("PASS", c)
+	remainder, err := writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(report.String(), Equals, "")
+	c.Assert(string(remainder), Matches, "<testsuites>\n"+
+		"    <testsuite .*name=\"XUnitStreamSuite\" .*tests=\"1\" .*>\n"+
+		"        <testcase .*</testcase>\n"+
+		"    </testsuite>\n"+
+		"</testsuites>")
+}
+
+func (s *XUnitStreamSuite) TestEmptyReport(c *C) {
+	writer := xunitWriterFor(nil, &bytes.Buffer{}, &RunConf{StreamXunit: true})
+	remainder, err := writer.GetReport()
+	c.Assert(err, IsNil)
+	c.Assert(string(remainder), Equals, "<testsuites></testsuites>")
+}
+
+func (s *XUnitStreamSuite) TestNotStreamingByDefault(c *C) {
+	output := &bytes.Buffer{}
+	writer := xunitWriterFor(output, output, &RunConf{})
+	writer.WriteCallSuccess("PASS", c)
+	writer.WriteSuiteDone("XUnitStreamSuite")
+	c.Assert(output.String(), Equals, "")
+	c.Assert(writer.suites, HasLen, 1)
+}
@@ -0,0 +1,199 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*************** test2json writer *****************/
+
+// test2jsonEvent is an event in the format of "go test -json", as
+// documented by "go doc test2json".
+type test2jsonEvent struct {
+	Time    *time.Time `json:",omitempty"`
+	Action  string
+	Package string   `json:",omitempty"`
+	Test    string   `json:",omitempty"`
+	Elapsed *float64 `json:",omitempty"`
+	Output  string   `json:",omitempty"`
+}
+
+// test2jsonWriter reports tests as the events "go test -json" emits, so
+// that tools which consume them work with check suites. Each test is
+// reported as a Go test named after its suite and method, with the output
+// "go test -v" would have for it. Problems in fixtures, which aren't tests
+// themselves, are reported as output of the package.
+type test2jsonWriter struct {
+	nonStreamingWriter
+	m       sync.Mutex
+	writer  io.Writer
+	encoder *json.Encoder
+	dedup   bool
+	pkg     string
+	failed  bool
+	running map[string]bool
+	started time.Time
+}
+
+func newTest2jsonWriter(writer io.Writer, conf *RunConf) *test2jsonWriter {
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	return &test2jsonWriter{
+		writer:  writer,
+		encoder: encoder,
+		dedup:   conf.DedupOutput,
+		running: make(map[string]bool),
+		started: time.Now(),
+	}
+}
+
+// GetReport returns the final output and outcome of the package.
+func (w *test2jsonWriter) GetReport() ([]byte, error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	action, output := "pass", "PASS\n"
+	if w.failed {
+		action, output = "fail", "FAIL\n"
+	}
+	elapsed := time.Since(w.started).Seconds()
+	now := time.Now()
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	for _, event := range []test2jsonEvent{
+		{Time: &now, Action: "output", Package: w.pkg, Output: output},
+		{Time: &now, Action: action, Package: w.pkg, Elapsed: &elapsed},
+	} {
+		if err := encoder.Encode(&event); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(buf.String()), nil
+}
+
+func (w *test2jsonWriter) WriteCallStarted(label string, c *C) {
+	if c.kind != testKd {
+		return
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.run(c)
+}
+
+func (w *test2jsonWriter) WriteCallSuccess(label string, c *C) { w.writeCall(label, c) }
+func (w *test2jsonWriter) WriteCallSkipped(label string, c *C) { w.writeCall(label, c) }
+func (w *test2jsonWriter) WriteCallError(label string, c *C)   { w.writeCall(label, c) }
+func (w *test2jsonWriter) WriteCallFailure(label string, c *C) { w.writeCall(label, c) }
+
+func (w *test2jsonWriter) writeCall(label string, c *C) {
+	if !reportableCall(c) {
+		return
+	}
+	log := c.logb.String()
+	if w.dedup {
+		log = dedupLog(log)
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	if c.kind != testKd {
+		w.failed = true
+		w.output(c, "", fmt.Sprintf("%s: %s\n", label, c.method.String()))
+		w.output(c, "", indentLog(log))
+		return
+	}
+	name := c.method.String()
+	if !w.running[name] {
+		w.run(c)
+	}
+	delete(w.running, name)
+	action := test2jsonAction(c)
+	if action == "fail" {
+		w.failed = true
+	}
+	elapsed := c.duration.Seconds()
+	w.output(c, name, fmt.Sprintf("--- %s: %s (%.2fs)\n", strings.ToUpper(action), name, elapsed))
+	if c.reason != "" && action == "skip" {
+		w.output(c, name, "    "+c.reason+"\n")
+	}
+	w.output(c, name, indentLog(log))
+	w.event(c, test2jsonEvent{Action: action, Test: name, Elapsed: &elapsed})
+}
+
+// run reports that the test of c started. It must be called with the
+// lock held.
+func (w *test2jsonWriter) run(c *C) {
+	name := c.method.String()
+	w.running[name] = true
+	w.event(c, test2jsonEvent{Action: "run", Test: name})
+	w.output(c, name, "=== RUN   "+name+"\n")
+}
+
+// output reports the lines in text as output of the test with the given
+// name, or of the package if it's empty. It must be called with the lock
+// held.
+func (w *test2jsonWriter) output(c *C, test, text string) {
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			w.event(c, test2jsonEvent{Action: "output", Test: test, Output: line})
+		}
+	}
+}
+
+// event writes an event of the package of c, starting the package first if
+// needed. It must be called with the lock held.
+func (w *test2jsonWriter) event(c *C, event test2jsonEvent) {
+	now := time.Now()
+	if w.pkg == "" {
+		w.pkg = funcImportPath(c.method.PC())
+		w.encoder.Encode(&test2jsonEvent{Time: &now, Action: "start", Package: w.pkg})
+	}
+	event.Time = &now
+	event.Package = w.pkg
+	w.encoder.Encode(&event)
+}
+
+// test2jsonAction returns the action ending the test of c. Tests which
+// failed as expected pass, and tests which failed while quarantined are
+// skipped, so that neither fails the package.
+func test2jsonAction(c *C) string {
+	switch c.status {
+	case succeededSt:
+		return "pass"
+	case skippedSt, quarantinedSt:
+		return "skip"
+	}
+	return "fail"
+}
+
+// indentLog indents each line of log the way "go test -v" indents the log
+// of a test.
+func indentLog(log string) string {
+	if log = strings.TrimRight(log, "\n"); log == "" {
+		return ""
+	}
+	return "    " + strings.Replace(log, "\n", "\n    ", -1) + "\n"
+}
+
+// funcImportPath returns the import path of the package of the function
+// at pc. External test packages report the path of the package they test,
+// as "go test" does.
+func funcImportPath(pc uintptr) string {
+	function := runtime.FuncForPC(pc)
+	if function == nil {
+		return "<unknown package>"
+	}
+	name := function.Name()
+	dir := ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		dir, name = name[:i+1], name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return dir + strings.TrimSuffix(name, "_test")
+}
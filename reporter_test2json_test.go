@@ -0,0 +1,106 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+/*************** test2json writer tests *****************/
+type Test2jsonWriterSuite struct {
+	output *bytes.Buffer
+	writer *test2jsonWriter
+}
+
+var _ = Suite(&Test2jsonWriterSuite{})
+
+func (s *Test2jsonWriterSuite) SetUpTest(c *C) {
+	s.output = &bytes.Buffer{}
+	s.writer = newTest2jsonWriter(s.output, &RunConf{})
+}
+
+func parseTest2json(c *C, output string) []test2jsonEvent {
+	var events []test2jsonEvent
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event test2jsonEvent
+		c.Assert(json.Unmarshal([]byte(line), &event), IsNil)
+		c.Assert(event.Time, NotNil)
+		c.Assert(event.Package, Equals, "github.com/masukomi/check")
+		events = append(events, event)
+	}
+	return events
+}
+
+func (s *Test2jsonWriterSuite) TestFailedTest(c *C) {
+	s.writer.WriteCallStarted("PASS", c)
+	c.Log("Expected failure!")
+	c.status = failedSt
+	s.writer.WriteCallFailure("FAIL", c)
+	c.status = succeededSt
+
+	name := "Test2jsonWriterSuite.TestFailedTest"
+	events := parseTest2json(c, s.output.String())
+	c.Assert(events, HasLen, 6)
+	c.Check(events[0].Action, Equals, "start")
+	c.Check(events[1].Action, Equals, "run")
+	c.Check(events[1].Test, Equals, name)
+	c.Check(events[2].Output, Equals, "=== RUN   "+name+"\n")
+	c.Check(events[3].Output, Matches, "--- FAIL: "+name+` \(\d+\.\d\ds\)\n`)
+	c.Check(events[4].Output, Equals, "    Expected failure!\n")
+	c.Check(events[4].Test, Equals, name)
+	c.Check(events[5].Action, Equals, "fail")
+	c.Check(events[5].Test, Equals, name)
+	c.Check(events[5].Elapsed, NotNil)
+
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	events = parseTest2json(c, string(report))
+	c.Assert(events, HasLen, 2)
+	c.Check(events[0].Output, Equals, "FAIL\n")
+	c.Check(events[1].Action, Equals, "fail")
+	c.Check(events[1].Test, Equals, "")
+}
+
+func (s *Test2jsonWriterSuite) TestSkippedTest(c *C) {
+	c.reason = "not today"
+	c.status = skippedSt
+	s.writer.WriteCallSkipped("SKIP", c)
+	c.status = succeededSt
+	c.reason = ""
+
+	events := parseTest2json(c, s.output.String())
+	c.Assert(events, HasLen, 6)
+	c.Check(events[1].Action, Equals, "run")
+	c.Check(events[3].Output, Matches, "--- SKIP: .*\n")
+	c.Check(events[4].Output, Equals, "    not today\n")
+	c.Check(events[5].Action, Equals, "skip")
+
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	events = parseTest2json(c, string(report))
+	c.Check(events[1].Action, Equals, "pass")
+}
+
+func (s *Test2jsonWriterSuite) TestFixtureProblems(c *C) {
+	fixture := &C{method: c.method, kind: fixtureKd, logb: &logger{}}
+	s.writer.WriteCallSuccess("PASS", fixture)
+	c.Assert(s.output.String(), Equals, "")
+
+	fixture.logf("Oops")
+	fixture.status = panickedSt
+	s.writer.WriteCallError("PANIC", fixture)
+	events := parseTest2json(c, s.output.String())
+	c.Assert(events, HasLen, 3)
+	c.Check(events[1].Output, Equals, "PANIC: Test2jsonWriterSuite.TestFixtureProblems\n")
+	c.Check(events[1].Test, Equals, "")
+	c.Check(events[2].Output, Equals, "    Oops\n")
+
+	report, err := s.writer.GetReport()
+	c.Assert(err, IsNil)
+	events = parseTest2json(c, string(report))
+	c.Check(events[1].Action, Equals, "fail")
+}
+
+func (s *Test2jsonWriterSuite) TestFuncImportPath(c *C) {
+	c.Check(funcImportPath(c.method.PC()), Equals, "github.com/masukomi/check")
+}
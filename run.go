@@ -2,11 +2,18 @@ package check
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/rand"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -35,6 +42,36 @@ func ConcurrentSuite(suite interface{}) interface{} {
 	return suite
 }
 
+// CheckAllSuitesRegistered verifies that the type of each of the given
+// values was registered as a suite with Suite or ConcurrentSuite, and
+// returns an error naming the suites which weren't. Since the defined
+// types of a package can't be enumerated, a value of each suite type must
+// be provided. Pointers and the values they point to are considered the
+// same type.
+//
+// For example:
+//
+//     func (s *MetaSuite) TestAllRegistered(c *C) {
+//         c.Assert(CheckAllSuitesRegistered(&FooSuite{}, &BarSuite{}), IsNil)
+//     }
+//
+func CheckAllSuitesRegistered(suites ...interface{}) error {
+	registered := make(map[reflect.Type]bool)
+	for _, suite := range allSuites {
+		registered[suiteType(suite.suite)] = true
+	}
+	var missing []string
+	for _, suite := range suites {
+		if !registered[suiteType(suite)] {
+			missing = append(missing, suiteName(suite))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("suites not registered with Suite or ConcurrentSuite: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 // -----------------------------------------------------------------------
 // Public running interface.
 
@@ -55,39 +92,82 @@ var (
 	newBenchMem        = flag.Bool("check.bmem", false, "Report memory benchmarks")
 	newListFlag        = flag.Bool("check.list", false, "List the names of all tests that will be run")
 	newWorkFlag        = flag.Bool("check.work", false, "Display and do not remove the test working directory")
-	reporterFlag       = flag.String("check.r", "plain", "Name of reporter for outputting result: [plain|xunit]")
-	outputFlag         = flag.String("check.output", "", "Name of the file to print report into. If empty, stdout is used")
+	reporterFlag       = flag.String("check.r", "plain", "Comma-separated reporters for outputting result, each optionally followed by :file: [plain|quiet|xunit|json|tap|teamcity|github|html|test2json|allure|markdown]")
+	outputFlag         = flag.String("check.output", "", "Name of the file to print report into. If empty, stdout is used. If it ends with a slash, a file per suite is written into that directory")
 	newConcurrencyFlag = flag.Int("check.c", 5, "How many tests to run concurrently for concurrent test suites")
+	newVerboseFailFlag = flag.Bool("check.vof", false, "Verbose mode only for suites with failures (incompatible with check.vv)")
+	newMaxRunFlag      = flag.Duration("check.maxrun", 0, "Maximum run time; tests not started by then are missed. Zero means no limit")
+	newDedupFlag       = flag.Bool("check.dedup", false, "Collapse consecutive identical log lines and problems of a test (ignored with check.vv)")
+	newWarnEmptyFlag   = flag.Bool("check.warnempty", true, "Warn about suites which contribute no tests to the run")
+	newStrictEmptyFlag = flag.Bool("check.strictempty", false, "Fail the run if a suite has no test methods at all")
+	newShuffleFlag     = flag.Bool("check.shuffle-suites", false, "Dispatch concurrent suites in random order")
+	newSeedFlag        = flag.Int64("check.seed", 0, "Seed for check.shuffle-suites. If zero, a seed is picked from the clock")
+	newConfigFlag      = flag.String("check.config", "", "Name of a JSON file providing defaults for the other check.* flags")
+	newJunitFlag       = flag.String("check.junit", "", "Name of a file to also write a JUnit XML report into, besides the normal output")
+	newXunitStreamFlag = flag.Bool("check.xunit-stream", false, "Write xunit reports suite by suite as they finish, rather than at the end")
+	newOrderFileFlag   = flag.String("check.orderfile", "", "Name of a file to write the names of the tests run into, in the order they started")
+	newOrderFromFlag   = flag.String("check.order-from", "", "Name of a file listing tests to run serially in that order, as written by check.orderfile")
+	newFailsAtEndFlag  = flag.Bool("check.failures-at-end", false, "Repeat all problems after the summary line (ignored with check.vv)")
+	newAllureDirFlag   = flag.String("check.allure-dir", "allure-results", "Name of the directory the allure reporter writes results into")
+	newColorFlag       = flag.String("check.color", "auto", "Colorize the output of the plain reporter: [auto|always|never]. With auto, only when writing to a terminal")
+	newSlowestFlag     = flag.Int("check.slowest", 0, "List this many slowest tests after the run")
+	newSummaryFlag     = flag.String("check.summary", "", "Name of a file to write a JSON summary of the run into, whatever the reporter")
 )
 
+func init() {
+	flag.Var(propertyFlag{}, "check.property", "Property of the run to include in reports, as key=value. May be repeated")
+}
+
 // TestingT runs all test suites registered with the Suite function,
 // printing results to stdout, and reporting any failures back to
 // the "testing" package.
 func TestingT(testingT *testing.T) {
+	if *newConfigFlag != "" {
+		if err := applyConfigFile(flag.CommandLine, *newConfigFlag); err != nil {
+			testingT.Fatal(err.Error())
+		}
+	}
+	if err := applyEnvDefaults(flag.CommandLine, os.Getenv); err != nil {
+		testingT.Fatal(err.Error())
+	}
 	benchTime := *newBenchTime
 	if benchTime == 1*time.Second {
 		benchTime = *oldBenchTime
 	}
 	conf := &RunConf{
-		Filter:           *oldFilterFlag + *newFilterFlag,
-		Verbose:          *oldVerboseFlag || *newVerboseFlag,
-		Stream:           *oldStreamFlag || *newStreamFlag,
-		Benchmark:        *oldBenchFlag || *newBenchFlag,
-		BenchmarkTime:    benchTime,
-		BenchmarkMem:     *newBenchMem,
-		KeepWorkDir:      *oldWorkFlag || *newWorkFlag,
-		ConcurrencyLevel: *newConcurrencyFlag,
+		Filter:            *oldFilterFlag + *newFilterFlag,
+		Verbose:           *oldVerboseFlag || *newVerboseFlag,
+		Stream:            *oldStreamFlag || *newStreamFlag,
+		Benchmark:         *oldBenchFlag || *newBenchFlag,
+		BenchmarkTime:     benchTime,
+		BenchmarkMem:      *newBenchMem,
+		KeepWorkDir:       *oldWorkFlag || *newWorkFlag,
+		ConcurrencyLevel:  *newConcurrencyFlag,
+		VerboseOnFailure:  *newVerboseFailFlag,
+		MaxRunTime:        *newMaxRunFlag,
+		DedupOutput:       *newDedupFlag,
+		NoWarnEmptySuites: !*newWarnEmptyFlag,
+		StrictEmpty:       *newStrictEmptyFlag,
+		ShuffleSuites:     *newShuffleFlag,
+		Seed:              *newSeedFlag,
+		FailuresAtEnd:     *newFailsAtEndFlag,
+		StreamXunit:       *newXunitStreamFlag,
+		OrderFile:         *newOrderFileFlag,
+		OrderFrom:         *newOrderFromFlag,
+		AllureDir:         *newAllureDirFlag,
+		Slowest:           *newSlowestFlag,
 	}
 	var err error
 	conf.Output, err = getOutput(*outputFlag)
 	if err != nil {
 		testingT.Fatal(err.Error())
 	}
-
-	conf.Writer, err = getWriter(*reporterFlag, conf.Output, conf.Verbose, conf.Stream)
+	conf.Color, err = useColor(*newColorFlag, conf.Output, os.Getenv)
 	if err != nil {
 		testingT.Fatal(err.Error())
 	}
+	conf.colorMode = *newColorFlag
+
 	if *oldListFlag || *newListFlag {
 		w := bufio.NewWriter(os.Stdout)
 		for _, name := range ListAll(conf) {
@@ -96,45 +176,441 @@ func TestingT(testingT *testing.T) {
 		w.Flush()
 		return
 	}
-	result := RunAll(conf)
 
-	if reporter, ok := conf.Writer.(reporter); ok {
-		report, err := reporter.GetReport()
+	spec := *reporterFlag
+	if isDirTarget(*outputFlag) {
+		spec = defaultTarget(spec, *outputFlag)
+	}
+	targets, err := getWriters(spec, conf.Output, conf)
+	if err != nil {
+		testingT.Fatal(err.Error())
+	}
+	defer func() { closeTargets(targets, conf.Output) }()
+	if *newJunitFlag != "" {
+		junitFile, err := os.Create(*newJunitFlag)
 		if err != nil {
-			testingT.Fatalf("could not generate report: %s", err.Error())
+			testingT.Fatal(err.Error())
 		}
-		fmt.Fprintf(conf.Output, "%s", string(report))
-	} else {
+		targets = append(targets, reporterTarget{xunitWriterFor(nil, junitFile, conf), junitFile})
+	}
+	var writers []outputWriter
+	for _, target := range targets {
+		writers = append(writers, target.writer)
+	}
+	var summary *summaryWriter
+	if *newSummaryFlag != "" {
+		summary = newSummaryWriter()
+		writers = append(writers, summary)
+	}
+	conf.Writer = writers[0]
+	if len(writers) > 1 {
+		conf.Writer = newMultiWriter(writers...)
+	}
+	var progress *progressWriter
+	if conf.Stream && isTerminal(conf.Output) {
+		progress = newProgressWriter(conf.Writer, conf.Output, len(ListAll(conf)))
+		conf.Writer = progress
+	}
+	result := RunAll(conf)
+	if progress != nil {
+		progress.Finish()
+	}
+	if summary != nil {
+		content, err := summary.summary(result)
+		if err == nil {
+			err = ioutil.WriteFile(*newSummaryFlag, content, 0644)
+		}
+		if err != nil {
+			testingT.Fatalf("could not write summary file: %s", err.Error())
+		}
+	}
+
+	// The summary goes to the output unless a report is written there.
+	summarized := false
+	for _, target := range targets {
+		if reporter, ok := target.writer.(reporter); ok {
+			if err := writeReport(target.output, reporter); err != nil {
+				testingT.Fatalf("could not generate report: %s", err.Error())
+			}
+			summarized = summarized || target.output == conf.Output
+		}
+	}
+	if !summarized {
 		fmt.Fprintf(conf.Output, "%s\n", result.String())
 	}
+	for _, target := range targets {
+		if trailer, ok := target.writer.(trailerWriter); ok {
+			trailer.WriteTrailer()
+		}
+	}
 
 	if !result.Passed() {
 		testingT.Fail()
 	}
 }
 
+// useColor tells whether output should be colorized according to the
+// given check.color mode. With "auto", it is when output is a terminal,
+// unless the NO_COLOR environment variable is set or TERM is "dumb".
+func useColor(mode string, output io.Writer, getenv func(string) string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if getenv("NO_COLOR") != "" || getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return isTerminal(output), nil
+	}
+	return false, fmt.Errorf("invalid value %q for check.color: must be auto, always or never", mode)
+}
+
+// envDefaults lists the environment variables which provide default
+// values for flags. Flags set explicitly on the command line win.
+var envDefaults = []struct{ env, flag string }{
+	{"CHECK_VERBOSE", "check.v"},
+	{"CHECK_REPORTER", "check.r"},
+	{"CHECK_OUTPUT", "check.output"},
+	{"CHECK_CONCURRENCY", "check.c"},
+}
+
+// applyEnvDefaults sets the flags in envDefaults which were not explicitly
+// set in fs from the respective environment variable, if it's not empty.
+func applyEnvDefaults(fs *flag.FlagSet, getenv func(string) string) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, d := range envDefaults {
+		value := getenv(d.env)
+		if value == "" || explicit[d.flag] {
+			continue
+		}
+		if err := fs.Set(d.flag, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %v", value, d.env, err)
+		}
+	}
+	return nil
+}
+
+// applyConfigFile sets the flags which were not explicitly set in fs from
+// the JSON object in the named file. Its keys are flag names without the
+// "check." prefix, as in {"f": "MySuite", "v": true, "c": 2}. Since values
+// from the file count as set, they also take precedence over the
+// environment defaults.
+func applyConfigFile(fs *flag.FlagSet, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("cannot parse config file %s: %v", filename, err)
+	}
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := "check." + key
+		if key == "config" || fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown key %q in config file %s", key, filename)
+		}
+		if explicit[name] {
+			continue
+		}
+		var value string
+		switch v := values[key].(type) {
+		case string, bool, json.Number:
+			value = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("invalid value for %q in config file %s: %#v", key, filename, v)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for %q in config file %s: %v", value, key, filename, err)
+		}
+	}
+	return nil
+}
+
 func getOutput(filename string) (io.Writer, error) {
-	if filename == "" {
+	if filename == "" || isDirTarget(filename) {
+		// Reports go into the directory, and the rest to stdout.
 		return os.Stdout, nil
 	}
 	return os.Create(filename)
 }
 
+// writeReport writes the report of r into w.
+func writeReport(w io.Writer, r reporter) error {
+	report, err := r.GetReport()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(report)
+	return err
+}
+
+// reporterTarget is an output writer along with where its report goes.
+type reporterTarget struct {
+	writer outputWriter
+	output io.Writer
+}
+
+// getWriters returns the output writers for a comma-separated list of
+// reporter names. Each name may be followed by a colon and the name of the
+// file the reporter writes into, as in "plain,xunit:report.xml", which is
+// created, or of a directory to write a file per suite into, if it ends
+// with a slash. Otherwise the reporter writes into output.
+func getWriters(spec string, output io.Writer, conf *RunConf) ([]reporterTarget, error) {
+	var targets []reporterTarget
+	for _, part := range strings.Split(spec, ",") {
+		name, filename := part, ""
+		if i := strings.Index(part, ":"); i >= 0 {
+			name, filename = part[:i], part[i+1:]
+		}
+		if isDirTarget(filename) {
+			if !reporterExists(name) {
+				return nil, errors.New("unknown reporter name provided: " + name)
+			}
+			targets = append(targets, reporterTarget{newPerSuiteWriter(name, filename, conf), output})
+			continue
+		}
+		target := output
+		if filename != "" {
+			file, err := os.Create(filename)
+			if err != nil {
+				closeTargets(targets, output)
+				return nil, err
+			}
+			target = file
+		}
+		writer, err := getWriter(name, target, targetConf(target, conf))
+		if err != nil {
+			closeTargets(append(targets, reporterTarget{output: target}), output)
+			return nil, err
+		}
+		if xunit, ok := writer.(*xunitWriter); ok && filename != "" {
+			// Streamed log lines would corrupt the report file.
+			xunit.writer = nil
+		}
+		targets = append(targets, reporterTarget{writer, target})
+	}
+	return targets, nil
+}
+
+// targetConf returns the run configuration for a reporter writing into
+// target. With a check.color mode set, Color is decided by whether target
+// itself is a terminal.
+func targetConf(target io.Writer, conf *RunConf) *RunConf {
+	if conf.colorMode == "" {
+		return conf
+	}
+	color, err := useColor(conf.colorMode, target, os.Getenv)
+	if err != nil || color == conf.Color {
+		return conf
+	}
+	copied := *conf
+	copied.Color = color
+	return &copied
+}
+
+// closeTargets closes the files created for targets, which are those
+// other than output.
+func closeTargets(targets []reporterTarget, output io.Writer) {
+	for _, target := range targets {
+		if file, ok := target.output.(*os.File); ok && target.output != output {
+			file.Close()
+		}
+	}
+}
+
+// defaultTarget returns the given check.r list of reporters, with those
+// which have no target of their own writing into target.
+func defaultTarget(spec, target string) string {
+	parts := strings.Split(spec, ",")
+	for i, part := range parts {
+		if !strings.Contains(part, ":") {
+			parts[i] = part + ":" + target
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// builtinReporters holds the factories of the reporters which come with
+// the package, by name.
+var builtinReporters = map[string]func(io.Writer, *RunConf) outputWriter{
+	"plain":     func(w io.Writer, conf *RunConf) outputWriter { return plainWriterFor(w, conf) },
+	"quiet":     func(w io.Writer, conf *RunConf) outputWriter { return quietWriterFor(w, conf) },
+	"xunit":     func(w io.Writer, conf *RunConf) outputWriter { return xunitWriterFor(w, w, conf) },
+	"json":      func(w io.Writer, conf *RunConf) outputWriter { return newJSONWriter(w, conf) },
+	"tap":       func(w io.Writer, conf *RunConf) outputWriter { return newTapWriter(w, conf) },
+	"teamcity":  func(w io.Writer, conf *RunConf) outputWriter { return newTeamCityWriter(w, conf) },
+	"github":    func(w io.Writer, conf *RunConf) outputWriter { return newGithubWriter(w, conf) },
+	"html":      func(w io.Writer, conf *RunConf) outputWriter { return newHTMLWriter(conf) },
+	"test2json": func(w io.Writer, conf *RunConf) outputWriter { return newTest2jsonWriter(w, conf) },
+	"allure":    func(w io.Writer, conf *RunConf) outputWriter { return newAllureWriter(conf) },
+	"markdown":  func(w io.Writer, conf *RunConf) outputWriter { return newMarkdownWriter(conf) },
+}
+
+var (
+	reportersMutex sync.Mutex
+	reporters      = make(map[string]func(io.Writer, RunConf) OutputWriter)
+)
+
+// RegisterReporter makes a custom reporter available under the given name,
+// so that it may be selected with -check.r like the built-in ones. The
+// factory is called with the output the reporter writes into and the run
+// configuration. If the returned writer also has a GetReport() ([]byte,
+// error) method, the report it returns is written into the output once
+// the run is over. It panics if the name is taken or contains a comma or
+// a colon, which have a meaning in -check.r. It returns a function which
+// removes the reporter again, for tests.
+//
+// It's meant to be called from an init function:
+//
+//     func init() {
+//         check.RegisterReporter("custom", newCustomWriter)
+//     }
+//
+func RegisterReporter(name string, factory func(io.Writer, RunConf) OutputWriter) (unregister func()) {
+	if name == "" || strings.ContainsAny(name, ",:") {
+		panic(fmt.Sprintf("check: invalid reporter name %q", name))
+	}
+	reportersMutex.Lock()
+	defer reportersMutex.Unlock()
+	if _, ok := builtinReporters[name]; ok {
+		panic("check: RegisterReporter called for built-in reporter " + name)
+	}
+	if _, ok := reporters[name]; ok {
+		panic("check: RegisterReporter called twice for reporter " + name)
+	}
+	reporters[name] = factory
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			reportersMutex.Lock()
+			defer reportersMutex.Unlock()
+			delete(reporters, name)
+		})
+	}
+}
+
+var (
+	propertiesMutex sync.Mutex
+	properties      = make(map[string]string)
+)
+
+// SetReportProperty sets a property of the run, such as a build number or
+// a commit hash, which is included in the reports that support it: the
+// xunit reporter writes them as the properties of each suite, and the json
+// reporter into its summary. Properties may also be set with the
+// -check.property flag, as in -check.property=build=1234. It returns a
+// function which gives the property back the value it had before, or
+// removes it if it had none, for tests.
+func SetReportProperty(key, value string) (restore func()) {
+	propertiesMutex.Lock()
+	defer propertiesMutex.Unlock()
+	previous, existed := properties[key]
+	properties[key] = value
+	return func() {
+		propertiesMutex.Lock()
+		defer propertiesMutex.Unlock()
+		if existed {
+			properties[key] = previous
+		} else {
+			delete(properties, key)
+		}
+	}
+}
+
+// reportProperties returns a copy of the properties of the run.
+func reportProperties() map[string]string {
+	propertiesMutex.Lock()
+	defer propertiesMutex.Unlock()
+	copied := make(map[string]string, len(properties))
+	for key, value := range properties {
+		copied[key] = value
+	}
+	return copied
+}
+
+// propertyFlag is a flag setting report properties, each given as
+// key=value. It may be repeated.
+type propertyFlag struct{}
+
+func (propertyFlag) String() string { return "" }
+
+func (propertyFlag) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("property %q is not of the form key=value", value)
+	}
+	SetReportProperty(value[:i], value[i+1:])
+	return nil
+}
+
+// reporterExists tells whether there's a reporter with the given name.
+func reporterExists(name string) bool {
+	if _, ok := builtinReporters[name]; ok {
+		return true
+	}
+	reportersMutex.Lock()
+	defer reportersMutex.Unlock()
+	_, ok := reporters[name]
+	return ok
+}
+
 // factory method that returns instance of reporter by name
-func getWriter(name string, writer io.Writer, verbose, stream bool) (outputWriter, error) {
-	switch name {
-	case "plain":
-		return newPlainWriter(writer, verbose, stream), nil
-	case "xunit":
-		return newXunitWriter(writer, stream), nil
-	default:
+func getWriter(name string, writer io.Writer, conf *RunConf) (outputWriter, error) {
+	if factory, ok := builtinReporters[name]; ok {
+		return factory(writer, conf), nil
+	}
+	reportersMutex.Lock()
+	factory, ok := reporters[name]
+	reportersMutex.Unlock()
+	if !ok {
 		return nil, errors.New("unknown reporter name provided: " + name)
 	}
+	return factory(writer, *conf), nil
 }
 
 // RunAll runs all test suites registered with the Suite function, using the
 // provided run configuration.
 func RunAll(runConf *RunConf) *Result {
+	if runConf.MaxRunTime > 0 && runConf.deadline == nil {
+		// Share a single deadline among all the suites.
+		conf := *runConf
+		deadline, stop := startDeadline(conf.MaxRunTime)
+		defer stop()
+		conf.deadline = deadline
+		runConf = &conf
+	}
+	if runConf.OnTestResult != nil && runConf.testResultMutex == nil {
+		// Serialize the calls to the hook among all the suites, but not
+		// with those of other runs, which the hook may start itself.
+		conf := *runConf
+		conf.testResultMutex = &sync.Mutex{}
+		runConf = &conf
+	}
+	if runConf.OrderFile != "" && runConf.order == nil {
+		conf := *runConf
+		conf.order = &testOrder{}
+		defer func() {
+			if err := conf.order.write(conf.OrderFile); err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: cannot write the order of tests: %v\n", err)
+			}
+		}()
+		runConf = &conf
+	}
+	if runConf.OrderFrom != "" {
+		return replayOrder(runConf, allSuites)
+	}
 	concurrent := make([]interface{}, 0, len(allSuites))
 	serial := make([]interface{}, 0, len(allSuites))
 	for _, s := range allSuites {
@@ -144,6 +620,14 @@ func RunAll(runConf *RunConf) *Result {
 			serial = append(serial, s.suite)
 		}
 	}
+	if runConf.ShuffleSuites && len(concurrent) > 1 {
+		seed := runConf.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		fmt.Fprintf(os.Stderr, "NOTE: shuffling concurrent suites with -check.seed=%d\n", seed)
+		shuffleSuites(concurrent, seed)
+	}
 	result := Result{}
 	if len(concurrent) > 0 {
 		bucket := newConcurrencyBucket(runConf.ConcurrencyLevel)
@@ -168,6 +652,98 @@ func RunAll(runConf *RunConf) *Result {
 	return &result
 }
 
+// shuffleSuites permutes suites in place, in an order determined by seed.
+func shuffleSuites(suites []interface{}, seed int64) {
+	r := rand.New(rand.NewSource(seed))
+	r.Shuffle(len(suites), func(i, j int) {
+		suites[i], suites[j] = suites[j], suites[i]
+	})
+}
+
+// -----------------------------------------------------------------------
+// Recording and replaying the order in which tests run.
+
+// testOrder records the names of tests as they start running.
+type testOrder struct {
+	sync.Mutex
+	names []string
+}
+
+func (o *testOrder) record(name string) {
+	o.Lock()
+	o.names = append(o.names, name)
+	o.Unlock()
+}
+
+// write writes the recorded names into the named file, one per line.
+func (o *testOrder) write(filename string) error {
+	o.Lock()
+	defer o.Unlock()
+	var buf bytes.Buffer
+	for _, name := range o.names {
+		buf.WriteString(name + "\n")
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// readTestOrder reads the names of tests from the named file, one per line.
+// Blank lines and lines starting with # are ignored.
+func readTestOrder(filename string) ([]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// replayOrder runs the tests of the registered suites which are named in
+// runConf.OrderFrom serially, in exactly the order in which they're listed.
+// Each stretch of consecutive tests from the same suite is run as a suite
+// of its own, so the suite fixtures of a suite whose tests were interleaved
+// with those of other suites when recorded run once per stretch. Tests not
+// listed aren't run, while listed tests which aren't found are warned about.
+func replayOrder(runConf *RunConf, registered []s) *Result {
+	names, err := readTestOrder(runConf.OrderFrom)
+	if err != nil {
+		return &Result{RunError: err}
+	}
+	suites := make(map[string]interface{}, len(registered))
+	for _, s := range registered {
+		suites[suiteName(s.suite)] = s.suite
+	}
+	type stretch struct {
+		suite string
+		tests []string
+	}
+	var stretches []stretch
+	for _, name := range names {
+		suite := strings.SplitN(name, ".", 2)[0]
+		if _, ok := suites[suite]; !ok {
+			fmt.Fprintf(os.Stderr, "WARNING: skipping %s from the replayed order: suite not found\n", name)
+			continue
+		}
+		if n := len(stretches); n == 0 || stretches[n-1].suite != suite {
+			stretches = append(stretches, stretch{suite: suite})
+		}
+		last := &stretches[len(stretches)-1]
+		last.tests = append(last.tests, name)
+	}
+	result := Result{}
+	for _, st := range stretches {
+		runner := newSuiteRunner(suites[st.suite], runConf, false, nil)
+		runner.reorderTests(st.tests)
+		result.Add(runner.run())
+	}
+	return &result
+}
+
 // Run runs the provided test suite using the provided run configuration.
 func Run(suite interface{}, runConf *RunConf) *Result {
 	runner := newSuiteRunner(suite, runConf, false, nil)
@@ -212,6 +788,12 @@ func (r *Result) Add(other *Result) {
 	r.FixturePanicked += other.FixturePanicked
 	r.ExpectedFailures += other.ExpectedFailures
 	r.Missed += other.Missed
+	r.DeadlineMissed += other.DeadlineMissed
+	r.Quarantined += other.Quarantined
+	r.QuarantinePassed += other.QuarantinePassed
+	if r.RunError == nil {
+		r.RunError = other.RunError
+	}
 	if r.WorkDir != "" && other.WorkDir != "" {
 		r.WorkDir += ":" + other.WorkDir
 	} else if other.WorkDir != "" {
@@ -244,6 +826,12 @@ func (r *Result) String() string {
 	if r.ExpectedFailures != 0 {
 		value += fmt.Sprintf(", %d expected failures", r.ExpectedFailures)
 	}
+	if r.Quarantined != 0 {
+		value += fmt.Sprintf(", %d quarantined", r.Quarantined)
+	}
+	if r.QuarantinePassed != 0 {
+		value += fmt.Sprintf(", %d quarantined but passed", r.QuarantinePassed)
+	}
 	if r.Failed != 0 {
 		value += fmt.Sprintf(", %d FAILED", r.Failed)
 	}
@@ -255,6 +843,9 @@ func (r *Result) String() string {
 	}
 	if r.Missed != 0 {
 		value += fmt.Sprintf(", %d MISSED", r.Missed)
+		if r.DeadlineMissed != 0 {
+			value += fmt.Sprintf(" (%d past run deadline)", r.DeadlineMissed)
+		}
 	}
 	if r.WorkDir != "" {
 		value += "\nWORK=" + r.WorkDir
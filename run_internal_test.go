@@ -0,0 +1,464 @@
+package check
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*************** Environment defaults tests *****************/
+type EnvDefaultsS struct{}
+
+var _ = Suite(&EnvDefaultsS{})
+
+func newEnvTestFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("check.v", false, "")
+	fs.String("check.r", "plain", "")
+	fs.String("check.output", "", "")
+	fs.Int("check.c", 5, "")
+	return fs
+}
+
+func fakeEnv(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func (s *EnvDefaultsS) TestEnvProvidesDefaults(c *C) {
+	fs := newEnvTestFlags()
+	err := applyEnvDefaults(fs, fakeEnv(map[string]string{
+		"CHECK_VERBOSE":     "true",
+		"CHECK_REPORTER":    "xunit",
+		"CHECK_OUTPUT":      "report.xml",
+		"CHECK_CONCURRENCY": "2",
+	}))
+	c.Assert(err, IsNil)
+	c.Check(fs.Lookup("check.v").Value.String(), Equals, "true")
+	c.Check(fs.Lookup("check.r").Value.String(), Equals, "xunit")
+	c.Check(fs.Lookup("check.output").Value.String(), Equals, "report.xml")
+	c.Check(fs.Lookup("check.c").Value.String(), Equals, "2")
+}
+
+func (s *EnvDefaultsS) TestExplicitFlagsWin(c *C) {
+	fs := newEnvTestFlags()
+	c.Assert(fs.Parse([]string{"-check.r=plain", "-check.c=7"}), IsNil)
+	err := applyEnvDefaults(fs, fakeEnv(map[string]string{
+		"CHECK_REPORTER":    "xunit",
+		"CHECK_CONCURRENCY": "2",
+		"CHECK_VERBOSE":     "true",
+	}))
+	c.Assert(err, IsNil)
+	c.Check(fs.Lookup("check.r").Value.String(), Equals, "plain")
+	c.Check(fs.Lookup("check.c").Value.String(), Equals, "7")
+	c.Check(fs.Lookup("check.v").Value.String(), Equals, "true")
+}
+
+func (s *EnvDefaultsS) TestNoEnvKeepsBuiltinDefaults(c *C) {
+	fs := newEnvTestFlags()
+	c.Assert(applyEnvDefaults(fs, fakeEnv(nil)), IsNil)
+	c.Check(fs.Lookup("check.r").Value.String(), Equals, "plain")
+	c.Check(fs.Lookup("check.c").Value.String(), Equals, "5")
+}
+
+func (s *EnvDefaultsS) TestInvalidEnvValue(c *C) {
+	fs := newEnvTestFlags()
+	err := applyEnvDefaults(fs, fakeEnv(map[string]string{"CHECK_CONCURRENCY": "lots"}))
+	c.Assert(err, ErrorMatches, `invalid value "lots" for CHECK_CONCURRENCY: .*`)
+}
+
+/*************** Config file tests *****************/
+type ConfigFileS struct{}
+
+var _ = Suite(&ConfigFileS{})
+
+func writeConfig(c *C, content string) string {
+	filename := filepath.Join(c.MkDir(), "check.json")
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+	return filename
+}
+
+func (s *ConfigFileS) TestConfigProvidesDefaults(c *C) {
+	fs := newEnvTestFlags()
+	filename := writeConfig(c, `{"v": true, "r": "xunit", "output": "report.xml", "c": 2}`)
+	c.Assert(applyConfigFile(fs, filename), IsNil)
+	c.Check(fs.Lookup("check.v").Value.String(), Equals, "true")
+	c.Check(fs.Lookup("check.r").Value.String(), Equals, "xunit")
+	c.Check(fs.Lookup("check.output").Value.String(), Equals, "report.xml")
+	c.Check(fs.Lookup("check.c").Value.String(), Equals, "2")
+}
+
+func (s *ConfigFileS) TestExplicitFlagsWin(c *C) {
+	fs := newEnvTestFlags()
+	c.Assert(fs.Parse([]string{"-check.c=7"}), IsNil)
+	filename := writeConfig(c, `{"r": "xunit", "c": 2}`)
+	c.Assert(applyConfigFile(fs, filename), IsNil)
+	c.Check(fs.Lookup("check.r").Value.String(), Equals, "xunit")
+	c.Check(fs.Lookup("check.c").Value.String(), Equals, "7")
+}
+
+func (s *ConfigFileS) TestConfigWinsOverEnv(c *C) {
+	fs := newEnvTestFlags()
+	filename := writeConfig(c, `{"r": "xunit"}`)
+	c.Assert(applyConfigFile(fs, filename), IsNil)
+	err := applyEnvDefaults(fs, fakeEnv(map[string]string{
+		"CHECK_REPORTER":    "plain",
+		"CHECK_CONCURRENCY": "3",
+	}))
+	c.Assert(err, IsNil)
+	c.Check(fs.Lookup("check.r").Value.String(), Equals, "xunit")
+	c.Check(fs.Lookup("check.c").Value.String(), Equals, "3")
+}
+
+func (s *ConfigFileS) TestUnknownKey(c *C) {
+	fs := newEnvTestFlags()
+	filename := writeConfig(c, `{"v": true, "verbosity": 2}`)
+	c.Assert(applyConfigFile(fs, filename), ErrorMatches, `unknown key "verbosity" in config file .*check.json`)
+}
+
+func (s *ConfigFileS) TestInvalidValue(c *C) {
+	fs := newEnvTestFlags()
+	filename := writeConfig(c, `{"c": 1.5}`)
+	c.Assert(applyConfigFile(fs, filename), ErrorMatches, `invalid value "1.5" for "c" in config file .*`)
+	filename = writeConfig(c, `{"r": ["plain"]}`)
+	c.Assert(applyConfigFile(fs, filename), ErrorMatches, `invalid value for "r" in config file .*`)
+}
+
+func (s *ConfigFileS) TestMalformedFile(c *C) {
+	fs := newEnvTestFlags()
+	filename := writeConfig(c, `{"v": true,`)
+	c.Assert(applyConfigFile(fs, filename), ErrorMatches, `cannot parse config file .*`)
+}
+
+/*************** Suite shuffling tests *****************/
+type ShuffleSuitesS struct{}
+
+var _ = Suite(&ShuffleSuitesS{})
+
+func shuffledNames(seed int64) []interface{} {
+	suites := []interface{}{"a", "b", "c", "d", "e", "f", "g", "h"}
+	shuffleSuites(suites, seed)
+	return suites
+}
+
+func (s *ShuffleSuitesS) TestSameSeedSameOrder(c *C) {
+	c.Assert(shuffledNames(42), DeepEquals, shuffledNames(42))
+}
+
+func (s *ShuffleSuitesS) TestDifferentSeedsDifferentOrders(c *C) {
+	orders := make(map[string]bool)
+	for seed := int64(1); seed <= 10; seed++ {
+		orders[fmt.Sprint(shuffledNames(seed))] = true
+	}
+	c.Assert(len(orders) > 1, Equals, true)
+}
+
+func (s *ShuffleSuitesS) TestShuffleIsPermutation(c *C) {
+	names := shuffledNames(7)
+	seen := make(map[interface{}]bool)
+	for _, name := range names {
+		seen[name] = true
+	}
+	c.Assert(names, HasLen, 8)
+	c.Assert(seen, HasLen, 8)
+}
+
+/*************** Test order tests *****************/
+type TestOrderS struct{}
+
+var _ = Suite(&TestOrderS{})
+
+type orderHelper struct {
+	calls *[]string
+}
+
+func (s orderHelper) TestA(c *C) { *s.calls = append(*s.calls, "A") }
+func (s orderHelper) TestB(c *C) { *s.calls = append(*s.calls, "B") }
+func (s orderHelper) TestC(c *C) { *s.calls = append(*s.calls, "C") }
+
+type otherOrderHelper struct {
+	calls *[]string
+}
+
+func (s otherOrderHelper) TestX(c *C) { *s.calls = append(*s.calls, "X") }
+
+func (s *TestOrderS) TestRecordAndRead(c *C) {
+	var calls []string
+	order := &testOrder{}
+	runner := newSuiteRunner(orderHelper{&calls}, &RunConf{Output: ioutil.Discard, order: order}, false, nil)
+	runner.run()
+	c.Assert(order.names, DeepEquals, []string{"orderHelper.TestA", "orderHelper.TestB", "orderHelper.TestC"})
+
+	filename := filepath.Join(c.MkDir(), "order")
+	c.Assert(order.write(filename), IsNil)
+	names, err := readTestOrder(filename)
+	c.Assert(err, IsNil)
+	c.Assert(names, DeepEquals, order.names)
+}
+
+func (s *TestOrderS) TestReadIgnoresBlankAndCommentLines(c *C) {
+	filename := filepath.Join(c.MkDir(), "order")
+	content := "# recorded order\norderHelper.TestB\n\n  orderHelper.TestA  \n"
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+	names, err := readTestOrder(filename)
+	c.Assert(err, IsNil)
+	c.Assert(names, DeepEquals, []string{"orderHelper.TestB", "orderHelper.TestA"})
+}
+
+func (o *TestOrderS) TestReplay(c *C) {
+	var calls []string
+	registered := []s{{orderHelper{&calls}, true}, {otherOrderHelper{&calls}, false}}
+	filename := filepath.Join(c.MkDir(), "order")
+	content := "orderHelper.TestC\notherOrderHelper.TestX\norderHelper.TestA\n" +
+		"orderHelper.TestMissing\nMissingSuite.TestA\n"
+	c.Assert(ioutil.WriteFile(filename, []byte(content), 0644), IsNil)
+
+	var result *Result
+	warnings := CaptureStderr(func() {
+		result = replayOrder(&RunConf{Output: ioutil.Discard, OrderFrom: filename}, registered)
+	})
+	c.Assert(result.Succeeded, Equals, 3)
+	c.Assert(calls, DeepEquals, []string{"C", "X", "A"})
+	c.Assert(warnings, Equals,
+		"WARNING: skipping MissingSuite.TestA from the replayed order: suite not found\n"+
+			"WARNING: skipping orderHelper.TestMissing from the replayed order: test not found\n")
+}
+
+func (s *TestOrderS) TestReplayMissingFile(c *C) {
+	conf := &RunConf{Output: ioutil.Discard, OrderFrom: filepath.Join(c.MkDir(), "missing")}
+	result := replayOrder(conf, nil)
+	c.Assert(result.RunError, ErrorMatches, "open .*missing: no such file or directory")
+}
+
+/*************** Reporter list tests *****************/
+type GetWritersS struct{}
+
+var _ = Suite(&GetWritersS{})
+
+func (s *GetWritersS) TestSingleReporter(c *C) {
+	var output bytes.Buffer
+	targets, err := getWriters("plain", &output, &RunConf{})
+	c.Assert(err, IsNil)
+	c.Assert(targets, HasLen, 1)
+	c.Check(targets[0].writer, FitsTypeOf, &plainWriter{})
+	c.Check(targets[0].output, Equals, io.Writer(&output))
+}
+
+func (s *GetWritersS) TestReportersWithFiles(c *C) {
+	var output bytes.Buffer
+	filename := filepath.Join(c.MkDir(), "report.xml")
+	targets, err := getWriters("plain,xunit:"+filename+",json:", &output, &RunConf{})
+	c.Assert(err, IsNil)
+	c.Assert(targets, HasLen, 3)
+	c.Check(targets[0].writer, FitsTypeOf, &plainWriter{})
+	c.Check(targets[0].output, Equals, io.Writer(&output))
+	c.Check(targets[1].writer, FitsTypeOf, &xunitWriter{})
+	file, ok := targets[1].output.(*os.File)
+	c.Assert(ok, Equals, true)
+	c.Check(file.Name(), Equals, filename)
+	file.Close()
+	c.Check(targets[2].writer, FitsTypeOf, &jsonWriter{})
+	c.Check(targets[2].output, Equals, io.Writer(&output))
+}
+
+func (s *GetWritersS) TestXunitFileIsntStreamedInto(c *C) {
+	var output bytes.Buffer
+	filename := filepath.Join(c.MkDir(), "report.xml")
+	targets, err := getWriters("xunit,xunit:"+filename, &output, &RunConf{Stream: true})
+	c.Assert(err, IsNil)
+	c.Assert(targets, HasLen, 2)
+	defer targets[1].output.(*os.File).Close()
+	c.Check(targets[0].writer.(*xunitWriter).writer, Equals, io.Writer(&output))
+	c.Check(targets[1].writer.(*xunitWriter).writer, IsNil)
+}
+
+func (s *GetWritersS) TestColorPerTarget(c *C) {
+	filename := filepath.Join(c.MkDir(), "output")
+	// As decided for a terminal output.
+	conf := &RunConf{Color: true, colorMode: "auto"}
+	targets, err := getWriters("plain:"+filename, &bytes.Buffer{}, conf)
+	c.Assert(err, IsNil)
+	targets[0].output.(*os.File).Close()
+	c.Check(targets[0].writer.(*plainWriter).color, Equals, false)
+
+	conf = &RunConf{Color: true, colorMode: "always"}
+	targets, err = getWriters("plain:"+filename, &bytes.Buffer{}, conf)
+	c.Assert(err, IsNil)
+	targets[0].output.(*os.File).Close()
+	c.Check(targets[0].writer.(*plainWriter).color, Equals, true)
+}
+
+func (s *GetWritersS) TestUnknownReporter(c *C) {
+	_, err := getWriters("plain,bogus", &bytes.Buffer{}, &RunConf{})
+	c.Assert(err, ErrorMatches, "unknown reporter name provided: bogus")
+}
+
+func (s *GetWritersS) TestReporterPerSuite(c *C) {
+	var output bytes.Buffer
+	targets, err := getWriters("plain,xunit:"+c.MkDir()+"/", &output, &RunConf{})
+	c.Assert(err, IsNil)
+	c.Assert(targets, HasLen, 2)
+	c.Check(targets[1].writer, FitsTypeOf, &perSuiteWriter{})
+	c.Check(targets[1].output, Equals, io.Writer(&output))
+
+	_, err = getWriters("bogus:"+c.MkDir()+"/", &output, &RunConf{})
+	c.Assert(err, ErrorMatches, "unknown reporter name provided: bogus")
+}
+
+func (s *GetWritersS) TestDefaultTarget(c *C) {
+	c.Assert(defaultTarget("plain,xunit:report.xml,json", "reports/"), Equals,
+		"plain:reports/,xunit:report.xml,json:reports/")
+}
+
+type recordingReporter struct {
+	*plainWriter
+	conf RunConf
+}
+
+func (s *GetWritersS) TestRegisteredReporter(c *C) {
+	defer RegisterReporter("recording", func(w io.Writer, conf RunConf) OutputWriter {
+		return &recordingReporter{plainWriterFor(w, &conf), conf}
+	})()
+
+	var output bytes.Buffer
+	targets, err := getWriters("plain,recording", &output, &RunConf{Verbose: true})
+	c.Assert(err, IsNil)
+	c.Assert(targets, HasLen, 2)
+	recording, ok := targets[1].writer.(*recordingReporter)
+	c.Assert(ok, Equals, true)
+	c.Check(recording.conf.Verbose, Equals, true)
+	c.Check(targets[1].output, Equals, io.Writer(&output))
+}
+
+func (s *GetWritersS) TestRegisterReporterTwice(c *C) {
+	factory := func(w io.Writer, conf RunConf) OutputWriter { return plainWriterFor(w, &conf) }
+	defer RegisterReporter("twice", factory)()
+	c.Check(func() { RegisterReporter("twice", factory) }, PanicMatches,
+		"check: RegisterReporter called twice for reporter twice")
+	c.Check(func() { RegisterReporter("xunit", factory) }, PanicMatches,
+		"check: RegisterReporter called for built-in reporter xunit")
+	c.Check(func() { RegisterReporter("xunit:file", factory) }, PanicMatches,
+		`check: invalid reporter name "xunit:file"`)
+}
+
+func (s *GetWritersS) TestUnregisterReporter(c *C) {
+	factory := func(w io.Writer, conf RunConf) OutputWriter { return plainWriterFor(w, &conf) }
+	unregister := RegisterReporter("again", factory)
+	unregister()
+	_, err := getWriters("again", ioutil.Discard, &RunConf{})
+	c.Check(err, NotNil)
+	defer RegisterReporter("again", factory)()
+	// Unregistering twice doesn't remove the reporter registered since.
+	unregister()
+	_, err = getWriters("again", ioutil.Discard, &RunConf{})
+	c.Check(err, IsNil)
+}
+
+/*************** Color mode tests *****************/
+type UseColorS struct{}
+
+var _ = Suite(&UseColorS{})
+
+func (s *UseColorS) TestModes(c *C) {
+	var output bytes.Buffer
+	color, err := useColor("always", &output, fakeEnv(nil))
+	c.Check(err, IsNil)
+	c.Check(color, Equals, true)
+	color, err = useColor("never", &output, fakeEnv(nil))
+	c.Check(err, IsNil)
+	c.Check(color, Equals, false)
+	_, err = useColor("sometimes", &output, fakeEnv(nil))
+	c.Check(err, ErrorMatches, `invalid value "sometimes" for check.color: must be auto, always or never`)
+}
+
+func (s *UseColorS) TestAutoNeedsTerminal(c *C) {
+	color, err := useColor("auto", &bytes.Buffer{}, fakeEnv(nil))
+	c.Check(err, IsNil)
+	c.Check(color, Equals, false)
+
+	file, err := os.Create(filepath.Join(c.MkDir(), "output"))
+	c.Assert(err, IsNil)
+	defer file.Close()
+	color, err = useColor("auto", file, fakeEnv(nil))
+	c.Check(err, IsNil)
+	c.Check(color, Equals, false)
+}
+
+func (s *UseColorS) TestAutoHonorsEnvironment(c *C) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		c.Skip("no terminal available")
+	}
+	defer tty.Close()
+	color, _ := useColor("auto", tty, fakeEnv(nil))
+	c.Check(color, Equals, true)
+	color, _ = useColor("auto", tty, fakeEnv(map[string]string{"NO_COLOR": "1"}))
+	c.Check(color, Equals, false)
+	color, _ = useColor("auto", tty, fakeEnv(map[string]string{"TERM": "dumb"}))
+	c.Check(color, Equals, false)
+}
+
+/*************** Run deadline tests *****************/
+type DeadlineS struct{}
+
+var _ = Suite(&DeadlineS{})
+
+func (s *DeadlineS) TestListStartsNoDeadline(c *C) {
+	var calls []string
+	conf := &RunConf{MaxRunTime: time.Minute}
+	c.Check(List(orderHelper{&calls}, conf), HasLen, 3)
+	runner := newSuiteRunner(orderHelper{&calls}, conf, false, nil)
+	c.Check(runner.deadline, IsNil)
+}
+
+func (s *DeadlineS) TestRunStartsDeadline(c *C) {
+	var calls []string
+	runner := newSuiteRunner(orderHelper{&calls}, &RunConf{Output: &bytes.Buffer{}, MaxRunTime: time.Minute}, false, nil)
+	c.Check(runner.run().Passed(), Equals, true)
+	c.Check(runner.deadline, NotNil)
+	c.Check(runner.deadlineExceeded(), Equals, false)
+}
+
+/*************** Report property tests *****************/
+type ReportPropertyS struct{}
+
+var _ = Suite(&ReportPropertyS{})
+
+func (s *ReportPropertyS) TestPropertyFlag(c *C) {
+	// Setting the property first gets the function removing it once done.
+	defer SetReportProperty("env", "")()
+	c.Assert(propertyFlag{}.Set("env=staging=2"), IsNil)
+	c.Check(reportProperties(), DeepEquals, map[string]string{"env": "staging=2"})
+	c.Check(propertyFlag{}.Set("env"), ErrorMatches, `property "env" is not of the form key=value`)
+	c.Check(propertyFlag{}.Set("=x"), ErrorMatches, `property "=x" is not of the form key=value`)
+}
+
+func (s *ReportPropertyS) TestRestoreProperty(c *C) {
+	restoreFirst := SetReportProperty("build", "1")
+	restoreSecond := SetReportProperty("build", "2")
+	c.Check(reportProperties(), DeepEquals, map[string]string{"build": "2"})
+	restoreSecond()
+	c.Check(reportProperties(), DeepEquals, map[string]string{"build": "1"})
+	restoreFirst()
+	c.Check(reportProperties(), DeepEquals, map[string]string{})
+}
+
+// CaptureStderr runs f and returns what it wrote to os.Stderr. It's
+// exported for the tests of package check_test as well.
+func CaptureStderr(f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	f()
+	w.Close()
+	data, _ := ioutil.ReadAll(r)
+	return string(data)
+}
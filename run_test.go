@@ -7,6 +7,7 @@ import (
 	. "github.com/masukomi/check"
 	"os"
 	"sync"
+	"time"
 )
 
 var runnerS = Suite(&RunS{})
@@ -17,6 +18,13 @@ func (s *RunS) TestCountSuite(c *C) {
 	suitesRun += 1
 }
 
+func (s *RunS) TestCheckAllSuitesRegistered(c *C) {
+	c.Check(CheckAllSuitesRegistered(), IsNil)
+	c.Check(CheckAllSuitesRegistered(&RunS{}, RunS{}), IsNil)
+	c.Check(CheckAllSuitesRegistered(&RunS{}, &FailHelper{}, SuccessHelper{}), ErrorMatches,
+		"suites not registered with Suite or ConcurrentSuite: FailHelper, SuccessHelper")
+}
+
 // -----------------------------------------------------------------------
 // Tests ensuring result counting works properly.
 
@@ -95,6 +103,146 @@ func (s *RunS) TestPanicOnSetUpSuite(c *C) {
 	c.Check(result.RunError, IsNil)
 }
 
+// -----------------------------------------------------------------------
+// Verify that the OnPanic hook is called for panics.
+
+func (s *RunS) TestOnPanic(c *C) {
+	output := String{}
+	var infos []PanicInfo
+	helper := &FixtureHelper{panicOn: "Test1"}
+	result := Run(helper, &RunConf{Output: &output, OnPanic: func(info PanicInfo) {
+		infos = append(infos, info)
+	}})
+	c.Check(result.Succeeded, Equals, 1)
+	c.Check(result.Panicked, Equals, 1)
+	c.Assert(infos, HasLen, 1)
+	c.Check(infos[0].Suite, Equals, "FixtureHelper")
+	c.Check(infos[0].Test, Equals, "FixtureHelper.Test1")
+	c.Check(infos[0].Value, Equals, "Test1")
+	c.Check(string(infos[0].Stack), Matches, "(?s).*FixtureHelper.*")
+}
+
+func (s *RunS) TestOnPanicInFixture(c *C) {
+	output := String{}
+	var infos []PanicInfo
+	helper := &FixtureHelper{panicOn: "SetUpTest"}
+	result := Run(helper, &RunConf{Output: &output, OnPanic: func(info PanicInfo) {
+		infos = append(infos, info)
+	}})
+	c.Check(result.FixturePanicked, Equals, 1)
+	c.Check(result.Missed, Equals, 2)
+	c.Assert(infos, HasLen, 1)
+	c.Check(infos[0].Test, Equals, "FixtureHelper.SetUpTest")
+}
+
+func (s *RunS) TestOnPanicInCleanup(c *C) {
+	output := String{}
+	var infos []PanicInfo
+	Run(&CleanupHelper{}, &RunConf{Output: &output, OnPanic: func(info PanicInfo) {
+		infos = append(infos, info)
+	}})
+	c.Assert(infos, HasLen, 1)
+	c.Check(infos[0].Test, Equals, "CleanupHelper.Test2")
+	c.Check(infos[0].Value, Equals, "cleanup panic")
+	c.Check(string(infos[0].Stack), Matches, "(?s).*CleanupHelper.*")
+}
+
+func (s *RunS) TestOnPanicHookPanicking(c *C) {
+	output := String{}
+	helper := &FixtureHelper{panicOn: "Test1"}
+	result := Run(helper, &RunConf{Output: &output, OnPanic: func(info PanicInfo) {
+		panic("hook")
+	}})
+	c.Check(result.Succeeded, Equals, 1)
+	c.Check(result.Panicked, Equals, 1)
+	c.Check(result.Missed, Equals, 0)
+}
+
+func (s *RunS) TestOnTestResult(c *C) {
+	output := String{}
+	results := make(map[string]TestResult)
+	Run(&QuarantineHelper{}, &RunConf{Output: &output, OnTestResult: func(r TestResult) {
+		results[r.Name] = r
+	}})
+	c.Assert(results, HasLen, 4)
+	c.Check(results["QuarantineHelper.TestNormal"].Suite, Equals, "QuarantineHelper")
+	c.Check(results["QuarantineHelper.TestNormal"].Status, Equals, TestPassed)
+	c.Check(results["QuarantineHelper.TestNormal"].Message, Equals, "")
+	c.Check(results["QuarantineHelper.TestPasses"].Status, Equals, TestQuarantinePassed)
+	c.Check(results["QuarantineHelper.TestPasses"].Reason, Equals, "fixed already?")
+	c.Check(results["QuarantineHelper.TestFails"].Status, Equals, TestQuarantined)
+	c.Check(results["QuarantineHelper.TestFails"].Message, Matches, "(?s)Expected failure!\n.*")
+	c.Check(results["QuarantineHelper.TestPanics"].Status, Equals, TestQuarantined)
+}
+
+func (s *RunS) TestOnTestResultStatuses(c *C) {
+	output := String{}
+	var statuses []string
+	onResult := func(r TestResult) { statuses = append(statuses, r.Status) }
+	Run(&FixtureHelper{panicOn: "Test1"}, &RunConf{Output: &output, OnTestResult: onResult})
+	Run(&FixtureHelper{panicOn: "SetUpTest"}, &RunConf{Output: &output, OnTestResult: onResult})
+	Run(&FixtureHelper{skip: true, skipOnN: 1}, &RunConf{Output: &output, OnTestResult: onResult})
+	Run(&FailHelper{}, &RunConf{Output: &output, OnTestResult: onResult})
+	c.Check(statuses, DeepEquals, []string{
+		TestPanicked, TestPassed,
+		TestFixturePanicked, TestMissed,
+		TestSkipped, TestPassed,
+		TestFailed,
+	})
+}
+
+type MetaHelper struct{}
+
+func (s *MetaHelper) TestWithMeta(c *C) {
+	c.SetMeta("version", "1.0")
+	c.SetMeta("dataset", "small")
+	c.SetMeta("version", "1.1")
+}
+
+func (s *MetaHelper) TestWithoutMeta(c *C) {
+}
+
+func (s *RunS) TestOnTestResultMeta(c *C) {
+	output := String{}
+	results := make(map[string]TestResult)
+	Run(&MetaHelper{}, &RunConf{Output: &output, OnTestResult: func(r TestResult) {
+		results[r.Name] = r
+	}})
+	c.Check(results["MetaHelper.TestWithMeta"].Meta, DeepEquals, map[string]string{
+		"version": "1.1",
+		"dataset": "small",
+	})
+	c.Check(results["MetaHelper.TestWithoutMeta"].Meta, IsNil)
+}
+
+func (s *RunS) TestOnTestResultHookPanicking(c *C) {
+	output := String{}
+	result := Run(&FixtureHelper{}, &RunConf{Output: &output, OnTestResult: func(r TestResult) {
+		panic("hook")
+	}})
+	c.Check(result.Succeeded, Equals, 2)
+}
+
+func (s *RunS) TestOnTestResultHookRunningTests(c *C) {
+	output := String{}
+	var nested []string
+	done := make(chan *Result)
+	go func() {
+		done <- Run(&FixtureHelper{}, &RunConf{Output: &output, OnTestResult: func(r TestResult) {
+			Run(&SuccessHelper{}, &RunConf{Output: &output, OnTestResult: func(r TestResult) {
+				nested = append(nested, r.Name)
+			}})
+		}})
+	}()
+	select {
+	case result := <-done:
+		c.Check(result.Succeeded, Equals, 2)
+		c.Check(nested, DeepEquals, []string{"SuccessHelper.TestLogAndSucceed", "SuccessHelper.TestLogAndSucceed"})
+	case <-time.After(5 * time.Second):
+		c.Fatal("The nested run waited for the OnTestResult hook of the outer one")
+	}
+}
+
 // -----------------------------------------------------------------------
 // Check result aggregation.
 
@@ -107,6 +255,9 @@ func (s *RunS) TestAdd(c *C) {
 		FixturePanicked:  5,
 		Missed:           6,
 		ExpectedFailures: 7,
+		DeadlineMissed:   8,
+		Quarantined:      9,
+		QuarantinePassed: 10,
 	}
 	result.Add(&Result{
 		Succeeded:        10,
@@ -116,6 +267,9 @@ func (s *RunS) TestAdd(c *C) {
 		FixturePanicked:  50,
 		Missed:           60,
 		ExpectedFailures: 70,
+		DeadlineMissed:   80,
+		Quarantined:      90,
+		QuarantinePassed: 100,
 	})
 	c.Check(result.Succeeded, Equals, 11)
 	c.Check(result.Skipped, Equals, 22)
@@ -124,6 +278,9 @@ func (s *RunS) TestAdd(c *C) {
 	c.Check(result.FixturePanicked, Equals, 55)
 	c.Check(result.Missed, Equals, 66)
 	c.Check(result.ExpectedFailures, Equals, 77)
+	c.Check(result.DeadlineMissed, Equals, 88)
+	c.Check(result.Quarantined, Equals, 99)
+	c.Check(result.QuarantinePassed, Equals, 110)
 	c.Check(result.RunError, IsNil)
 }
 
@@ -138,6 +295,8 @@ func (s *RunS) TestPassed(c *C) {
 	c.Assert((&Result{Panicked: 1}).Passed(), Equals, false)
 	c.Assert((&Result{FixturePanicked: 1}).Passed(), Equals, false)
 	c.Assert((&Result{Missed: 1}).Passed(), Equals, false)
+	c.Assert((&Result{Quarantined: 1}).Passed(), Equals, true)
+	c.Assert((&Result{QuarantinePassed: 1}).Passed(), Equals, true)
 	c.Assert((&Result{RunError: errors.New("!")}).Passed(), Equals, false)
 }
 
@@ -179,6 +338,16 @@ func (s *RunS) TestPrintMissed(c *C) {
 	c.Check(result.String(), Equals, "OOPS: 0 passed, 5 MISSED")
 }
 
+func (s *RunS) TestPrintDeadlineMissed(c *C) {
+	result := &Result{Missed: 5, DeadlineMissed: 3}
+	c.Check(result.String(), Equals, "OOPS: 0 passed, 5 MISSED (3 past run deadline)")
+}
+
+func (s *RunS) TestPrintQuarantined(c *C) {
+	result := &Result{Succeeded: 1, Quarantined: 3, QuarantinePassed: 2}
+	c.Check(result.String(), Equals, "OK: 1 passed, 3 quarantined, 2 quarantined but passed")
+}
+
 func (s *RunS) TestPrintAll(c *C) {
 	result := &Result{Succeeded: 1, Skipped: 2, ExpectedFailures: 3,
 		Panicked: 4, FixturePanicked: 5, Missed: 6}
@@ -193,6 +362,177 @@ func (s *RunS) TestPrintRunError(c *C) {
 	c.Check(result.String(), Equals, "ERROR: Kaboom!")
 }
 
+// -----------------------------------------------------------------------
+// Verify the handling of suites which contribute no tests.
+
+type EmptyHelper struct{}
+
+func (s *EmptyHelper) SetUpSuite(c *C) {}
+func (s *EmptyHelper) Tset1(c *C)      {}
+
+func (s *RunS) TestWarnEmptySuite(c *C) {
+	output := String{}
+	var result *Result
+	warnings := CaptureStderr(func() {
+		result = Run(&EmptyHelper{}, &RunConf{Output: &output})
+	})
+	c.Check(warnings, Equals, "WARNING: suite EmptyHelper has no test methods\n")
+	c.Check(result.Passed(), Equals, true)
+	c.Check(output.value, Equals, "")
+}
+
+func (s *RunS) TestWarnEmptySuiteDisabled(c *C) {
+	output := String{}
+	warnings := CaptureStderr(func() {
+		Run(&EmptyHelper{}, &RunConf{Output: &output, NoWarnEmptySuites: true})
+	})
+	c.Check(warnings, Equals, "")
+}
+
+func (s *RunS) TestWarnEmptySuiteFilteredOut(c *C) {
+	output := String{}
+	runConf := RunConf{Output: &output, Filter: "Bogus"}
+	warnings := CaptureStderr(func() {
+		Run(&FixtureHelper{}, &runConf)
+	})
+	c.Check(warnings, Equals, "")
+
+	runConf.Verbose = true
+	warnings = CaptureStderr(func() {
+		Run(&FixtureHelper{}, &runConf)
+	})
+	c.Check(warnings, Equals, "NOTE: all 2 tests of suite FixtureHelper were filtered out\n")
+}
+
+func (s *RunS) TestStrictEmptySuite(c *C) {
+	output := String{}
+	result := Run(&EmptyHelper{}, &RunConf{Output: &output, StrictEmpty: true})
+	c.Check(result.RunError, ErrorMatches, "suite EmptyHelper has no test methods")
+	c.Check(result.Passed(), Equals, false)
+
+	// Filtering everything out is fine even in strict mode.
+	result = Run(&FixtureHelper{}, &RunConf{Output: &output, StrictEmpty: true, Filter: "Bogus"})
+	c.Check(result.RunError, IsNil)
+}
+
+func (s *RunS) TestAddKeepsRunError(c *C) {
+	result := &Result{Succeeded: 1}
+	result.Add(&Result{RunError: errors.New("first")})
+	result.Add(&Result{RunError: errors.New("second")})
+	c.Check(result.RunError, ErrorMatches, "first")
+}
+
+// -----------------------------------------------------------------------
+// Verify that quarantined tests run without failing the run.
+
+type QuarantineHelper struct{}
+
+func (s *QuarantineHelper) TestFails(c *C) {
+	c.Quarantine("flaky network")
+	c.Log("Expected failure!")
+	c.Fail()
+}
+
+func (s *QuarantineHelper) TestPanics(c *C) {
+	c.Quarantine("bug #42")
+	panic("BOOM")
+}
+
+func (s *QuarantineHelper) TestPasses(c *C) {
+	c.Quarantine("fixed already?")
+}
+
+func (s *QuarantineHelper) TestNormal(c *C) {
+}
+
+func (s *RunS) TestQuarantine(c *C) {
+	output := String{}
+	result := Run(&QuarantineHelper{}, &RunConf{Output: &output})
+
+	c.Check(result.Succeeded, Equals, 1)
+	c.Check(result.Quarantined, Equals, 2)
+	c.Check(result.QuarantinePassed, Equals, 1)
+	c.Check(result.Failed, Equals, 0)
+	c.Check(result.Panicked, Equals, 0)
+	c.Check(result.Passed(), Equals, true)
+
+	c.Check(output.value, Matches, "(?s).*QUARANTINED: run_test\\.go:[0-9]+: QuarantineHelper\\.TestFails\n\n"+
+		"Expected failure!\n\\.\\.\\. Quarantined: flaky network\n.*")
+	c.Check(output.value, Matches, "(?s).*QUARANTINED: run_test\\.go:[0-9]+: QuarantineHelper\\.TestPanics\n.*BOOM.*")
+	c.Check(output.value, Matches, "(?s).*QUARANTINE PASSED: run_test\\.go:[0-9]+: QuarantineHelper\\.TestPasses \\(fixed already\\?\\)\t *[.0-9]+s\n.*")
+	c.Check(output.value, Not(Matches), "(?s).*TestNormal.*")
+}
+
+// -----------------------------------------------------------------------
+// Verify that DedupOutput collapses repeated problems.
+
+type DedupHelper struct{}
+
+func (s *DedupHelper) TestLoop(c *C) {
+	for i := 0; i < 100; i++ {
+		c.Check(i%2 == 0 || i < 50, Equals, true)
+	}
+}
+
+func (s *RunS) TestDedupOutput(c *C) {
+	output := String{}
+	Run(&DedupHelper{}, &RunConf{Output: &output, DedupOutput: true})
+	c.Check(output.value, Matches, "(?s)\n-+\nFAIL: run_test\\.go:[0-9]+: DedupHelper\\.TestLoop\n\n"+
+		"run_test\\.go:[0-9]+:\n"+
+		"    c\\.Check\\(i%2 == 0 \\|\\| i < 50, Equals, true\\)\n"+
+		"\\.\\.\\. obtained bool = false\n"+
+		"\\.\\.\\. expected bool = true\n"+
+		"\\.\\.\\. \\(repeated 25 times\\)\n\n")
+}
+
+// -----------------------------------------------------------------------
+// Verify that MaxRunTime stops dispatching tests once exhausted.
+
+type DeadlineHelper struct {
+	calls []string
+}
+
+func (s *DeadlineHelper) TearDownSuite(c *C) {
+	s.calls = append(s.calls, "TearDownSuite")
+}
+
+func (s *DeadlineHelper) Test1(c *C) {
+	s.calls = append(s.calls, "Test1")
+	time.Sleep(50 * time.Millisecond)
+}
+
+func (s *DeadlineHelper) Test2(c *C) {
+	s.calls = append(s.calls, "Test2")
+}
+
+func (s *DeadlineHelper) Test3(c *C) {
+	s.calls = append(s.calls, "Test3")
+}
+
+func (s *RunS) TestMaxRunTime(c *C) {
+	helper := DeadlineHelper{}
+	output := String{}
+	runConf := RunConf{Output: &output, Verbose: true, MaxRunTime: 10 * time.Millisecond}
+	result := Run(&helper, &runConf)
+
+	c.Check(helper.calls, DeepEquals, []string{"Test1", "TearDownSuite"})
+	c.Check(result.Succeeded, Equals, 1)
+	c.Check(result.Missed, Equals, 2)
+	c.Check(result.DeadlineMissed, Equals, 2)
+	c.Check(output.value, Matches, "(?s).*MISS: run_test\\.go:[0-9]+: DeadlineHelper\\.Test2 \\(run deadline exceeded\\)\n.*")
+}
+
+func (s *RunS) TestMaxRunTimeNotExceeded(c *C) {
+	helper := DeadlineHelper{}
+	output := String{}
+	runConf := RunConf{Output: &output, MaxRunTime: time.Minute}
+	result := Run(&helper, &runConf)
+
+	c.Check(helper.calls, DeepEquals, []string{"Test1", "Test2", "Test3", "TearDownSuite"})
+	c.Check(result.Succeeded, Equals, 3)
+	c.Check(result.DeadlineMissed, Equals, 0)
+}
+
 // -----------------------------------------------------------------------
 // Verify that the method pattern flag works correctly.
 
@@ -325,6 +665,45 @@ func (s *RunS) TestVerboseModeWithFailBeforePass(c *C) {
 	c.Assert(output.value, Matches, expected)
 }
 
+func (s *RunS) TestVerboseOnFailureWithPassingSuite(c *C) {
+	helper := FixtureHelper{}
+	output := String{}
+	runConf := RunConf{Output: &output, VerboseOnFailure: true}
+	Run(&helper, &runConf)
+	c.Assert(output.value, Equals, "")
+}
+
+func (s *RunS) TestVerboseOnFailureWithFailingSuite(c *C) {
+	helper := FixtureHelper{panicOn: "Test1"}
+	output := String{}
+	runConf := RunConf{Output: &output, VerboseOnFailure: true}
+	Run(&helper, &runConf)
+
+	expected := "(?s).*PANIC.*FixtureHelper\\.Test1.*\n-+\n" +
+		"PASS: check_test\\.go:[0-9]+: FixtureHelper\\.Test2\t *[.0-9]+s\n"
+
+	c.Assert(output.value, Matches, expected)
+}
+
+func (s *RunS) TestVerboseOnFailureOnlyFailingSuites(c *C) {
+	output := String{}
+	runConf := RunConf{Output: &output, VerboseOnFailure: true}
+	Run(&SuccessHelper{}, &runConf)
+	Run(&FailHelper{}, &runConf)
+	Run(&SuccessHelper{}, &runConf)
+
+	c.Assert(output.value, Not(Matches), "(?s).*SuccessHelper.*")
+	c.Assert(output.value, Matches, "(?s).*FAIL: check_test\\.go:[0-9]+: FailHelper\\.TestLogAndFail.*")
+}
+
+func (s *RunS) TestVerboseOnFailureIgnoredWhenStreaming(c *C) {
+	helper := FixtureHelper{}
+	output := String{}
+	runConf := RunConf{Output: &output, Stream: true, VerboseOnFailure: true}
+	Run(&helper, &runConf)
+	c.Assert(output.value, Matches, "(?s).*PASS: check_test\\.go:[0-9]+: FixtureHelper\\.Test1.*")
+}
+
 // -----------------------------------------------------------------------
 // Verify the stream output mode.  In this mode there's no output caching.
 